@@ -0,0 +1,8 @@
+# Tests ocultos: solo se ejecutan en el servidor al enviar la solución.
+# USER_OUTPUT contiene el stdout capturado del código del usuario.
+
+assert USER_OUTPUT == "Hello, World!\n", (
+    "El programa debe imprimir exactamente una línea"
+)
+
+print("ALL_TESTS_PASSED")
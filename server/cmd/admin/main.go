@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"slices"
+
+	"apschool/internal/auth"
+	"apschool/internal/config"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Sets the role of an existing user. Used to bootstrap the first admin, who
+// can then manage everyone else through PATCH /api/admin/users/{id}/role.
+func main() {
+	email := flag.String("email", "", "email of the user to update")
+	role := flag.String("role", auth.RoleAdmin, "role to assign (student, instructor or admin)")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *email == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if !slices.Contains(auth.Roles, *role) {
+		log.Fatalf("invalid role %q", *role)
+	}
+
+	db, err := openDB(cfg.DB.URL())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	result, err := db.Exec(`UPDATE users SET role = $2, updated_at = NOW() WHERE email = $1`, *email, *role)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if rows == 0 {
+		log.Fatalf("no user with email %s, log in once before promoting", *email)
+	}
+
+	log.Printf("User %s is now %s", *email, *role)
+}
+
+func openDB(url string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -3,67 +3,137 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/exec"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"apschool/internal/achievements"
+	"apschool/internal/assignments"
 	"apschool/internal/auth"
+	"apschool/internal/categories"
 	"apschool/internal/challenges"
+	"apschool/internal/config"
+	"apschool/internal/courses"
+	"apschool/internal/gradebook"
+	"apschool/internal/grader"
+	"apschool/internal/jobs"
+	"apschool/internal/leaderboard"
+	mw "apschool/internal/middleware"
+	"apschool/internal/progress"
+	"apschool/internal/similarity"
 	"apschool/internal/submissions"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
-	_ "github.com/joho/godotenv/autoload"
 )
 
-var (
-	database   = os.Getenv("APSCHOOL_DB_DATABASE")
-	password   = os.Getenv("APSCHOOL_DB_PASSWORD")
-	username   = os.Getenv("APSCHOOL_DB_USERNAME")
-	port       = os.Getenv("APSCHOOL_DB_PORT")
-	host       = os.Getenv("APSCHOOL_DB_HOST")
-	schema     = os.Getenv("APSCHOOL_DB_SCHEMA")
-	serverPort = os.Getenv("PORT")
-)
+// jobsShutdownTimeout is how long in-flight jobs get to finish on shutdown
+// before they are cancelled and left for a retry.
+const jobsShutdownTimeout = 30 * time.Second
 
 type application struct {
-	db          *sql.DB
-	logger      *slog.Logger
-	auth        *auth.Handler
-	challenges  *challenges.Handler
-	submissions *submissions.Handler
+	db           *sql.DB
+	logger       *slog.Logger
+	middleware   *mw.Middleware
+	achievements *achievements.Handler
+	assignments  *assignments.Handler
+	auth         *auth.Handler
+	categories   *categories.Handler
+	challenges   *challenges.Handler
+	courses      *courses.Handler
+	gradebook    *gradebook.Handler
+	jobs         *jobs.Handler
+	leaderboard  *leaderboard.Handler
+	progress     *progress.Handler
+	similarity   *similarity.Handler
+	submissions  *submissions.Handler
 }
 
 func main() {
 
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.CheckServer(); err != nil {
+		log.Fatal(err)
+	}
+
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	db, err := openDB()
+	db, err := openDB(cfg.DB.URL())
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	g, err := newGrader(cfg.Grader)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tokens := auth.NewTokenService(auth.NewTokenRepository(db), []byte(cfg.JWTSecret))
+
+	var github auth.OAuthProvider
+	if cfg.GitHub.Enabled() {
+		github = auth.NewGitHubClient(cfg.GitHub, nil)
+	}
+	courseService := courses.NewService(courses.NewRepository(db))
+	leaderboardService := leaderboard.NewService(leaderboard.NewRepository(db))
+	achievementService := achievements.NewService(achievements.NewRepository(db))
+	jobRepo := jobs.NewRepository(db)
+	jobService := jobs.NewService(jobRepo)
+	similarityService := similarity.NewService(similarity.NewRepository(db), courseService, jobService)
+	submissionService := submissions.NewService(submissions.NewRepository(db), g, achievementService, jobService)
+
+	runner := jobs.NewRunner(jobRepo, cfg.JobWorkers, logger)
+	runner.Handle(similarity.KindReport, similarityService.RunReportJob)
+	runner.Handle(leaderboard.KindRefresh, leaderboardService.RefreshJob)
+	runner.Handle(auth.KindCleanupTokens, tokens.CleanupJob)
+	if g != nil {
+		runner.Handle(submissions.KindGrade, submissionService.RunGradeJob)
+		runner.OnDead(submissions.KindGrade, submissionService.FailGradeJob)
+	}
+	runner.Every(leaderboard.KindRefresh, leaderboard.RefreshInterval)
+	runner.Every(auth.KindCleanupTokens, auth.CleanupInterval)
+
 	app := &application{
-		db:          db,
-		logger:      logger,
-		auth:        auth.NewHandler(auth.NewService(auth.NewRepository(db)), logger),
-		challenges:  challenges.NewHandler(challenges.NewService(challenges.NewRepository(db)), logger),
-		submissions: submissions.NewHandler(submissions.NewService(submissions.NewRepository(db)), logger),
+		db:           db,
+		logger:       logger,
+		middleware:   mw.New(tokens, logger),
+		achievements: achievements.NewHandler(achievementService, logger),
+		assignments:  assignments.NewHandler(assignments.NewService(assignments.NewRepository(db), courseService), logger),
+		auth:         auth.NewHandler(auth.NewService(auth.NewRepository(db)), tokens, github, cfg, logger),
+		categories:   categories.NewHandler(categories.NewService(categories.NewRepository(db)), logger),
+		challenges:   challenges.NewHandler(challenges.NewService(challenges.NewRepository(db)), logger),
+		courses:      courses.NewHandler(courseService, logger),
+		gradebook:    gradebook.NewHandler(gradebook.NewService(gradebook.NewRepository(db), courseService), logger),
+		jobs:         jobs.NewHandler(jobService, logger),
+		leaderboard:  leaderboard.NewHandler(leaderboardService, logger),
+		progress:     progress.NewHandler(progress.NewService(progress.NewRepository(db)), logger),
+		similarity:   similarity.NewHandler(similarityService, logger),
+		submissions:  submissions.NewHandler(submissionService, logger),
 	}
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%s", serverPort),
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
 		Handler:      app.routes(),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
 
+	// With JOB_WORKERS=0 jobs are only queued here and cmd/worker runs them
+	if cfg.JobWorkers > 0 {
+		runner.Start()
+	}
+
 	done := make(chan bool, 1)
-	go gracefulShutdown(server, done)
+	go gracefulShutdown(server, runner, done)
 
 	log.Printf("Starting server on port %s", server.Addr)
 
@@ -76,7 +146,7 @@ func main() {
 	log.Println("Graceful shutdown complete.")
 }
 
-func gracefulShutdown(apiServer *http.Server, done chan bool) {
+func gracefulShutdown(apiServer *http.Server, runner *jobs.Runner, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -95,16 +165,37 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 		log.Printf("Server forced to shutdown with error: %v", err)
 	}
 
+	// Requests may have queued jobs, so workers are drained after the server
+	jobsCtx, jobsCancel := context.WithTimeout(context.Background(), jobsShutdownTimeout)
+	defer jobsCancel()
+	if err := runner.Shutdown(jobsCtx); err != nil {
+		log.Printf("Jobs interrupted on shutdown, they will be retried: %v", err)
+	}
+
 	log.Println("Server exiting")
 
 	// Notify the main goroutine that the shutdown is complete
 	done <- true
 }
 
-func openDB() (*sql.DB, error) {
+// newGrader picks how submissions are verified. GRADER_MODE=client keeps the
+// old behaviour of trusting the browser; server grades on the server.
+func newGrader(cfg config.Grader) (grader.Grader, error) {
+	if cfg.Mode == config.GraderClient {
+		return nil, nil
+	}
+
+	path, err := exec.LookPath(cfg.Python)
+	if err != nil {
+		return nil, fmt.Errorf("server-side grading needs a python interpreter (set GRADER_PYTHON or GRADER_MODE=client): %w", err)
+	}
+
+	return grader.NewPythonGrader(path, grader.DefaultLimits), nil
+}
+
+func openDB(url string) (*sql.DB, error) {
 
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s", username, password, host, port, database, schema)
-	db, err := sql.Open("pgx", connStr)
+	db, err := sql.Open("pgx", url)
 	if err != nil {
 		return nil, err
 	}
@@ -1,13 +1,12 @@
 package main
 
 import (
+	"apschool/internal/auth"
 	"apschool/internal/response"
 	"context"
 	"net/http"
 	"time"
 
-	mw "apschool/internal/middleware"
-
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -32,23 +31,98 @@ func (app *application) routes() http.Handler {
 	r.Route("/api/auth", func(r chi.Router) {
 		r.Get("/github/login", app.auth.GithubLogin)
 		r.Get("/github/callback", app.auth.GithubCallback)
+		r.Post("/register", app.auth.Register)
+		r.Post("/login", app.auth.Login)
+		r.Post("/exchange", app.auth.Exchange)
+		r.Post("/refresh", app.auth.Refresh)
 
 		r.Group(func(r chi.Router) {
-			r.Use(mw.RequireAuth)
+			r.Use(app.middleware.RequireAuth)
 			r.Get("/me", app.auth.GetMe)
+			r.Post("/logout", app.auth.Logout)
+			r.Post("/stream-ticket", app.auth.StreamTicket)
+		})
+	})
+
+	// Admin routes
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Use(app.middleware.RequireAuth)
+
+		r.Group(func(r chi.Router) {
+			r.Use(app.middleware.RequireRole(auth.RoleAdmin))
+			r.Patch("/users/{id}/role", app.auth.UpdateUserRole)
+			r.Get("/jobs", app.jobs.ListJobsHandler)
+			r.Post("/jobs/{id}/retry", app.jobs.RetryJobHandler)
+			r.Get("/challenges", app.challenges.AdminListChallengesHandler)
+			r.Post("/challenges", app.challenges.CreateChallengeHandler)
+			r.Get("/challenges/{id}", app.challenges.AdminGetChallengeHandler)
+			r.Put("/challenges/{id}", app.challenges.ReplaceChallengeHandler)
+			r.Patch("/challenges/{id}", app.challenges.UpdateChallengeHandler)
+			r.Delete("/challenges/{id}", app.challenges.DeleteChallengeHandler)
 		})
 	})
 
+	r.Get("/api/categories", app.categories.ListCategoriesHandler)
 
 	// Challenges routes
-	r.Get("/api/challenges", app.challenges.ListChallengesHandler)
-	r.Get("/api/challenges/{id}", app.challenges.GetChallengeHandler)
+	r.With(app.middleware.OptionalAuth).Get("/api/challenges", app.challenges.ListChallengesHandler)
+	r.With(app.middleware.OptionalAuth).Get("/api/challenges/{id}", app.challenges.GetChallengeHandler)
+	r.With(app.middleware.RequireAuth).Post("/api/challenges/{id}/hints/{n}", app.challenges.RevealHintHandler)
+
+	r.With(app.middleware.RequireAuth).Get("/api/progress", app.progress.GetProgressHandler)
+	r.With(app.middleware.RequireAuth).Get("/api/me/achievements", app.achievements.GetAchievementsHandler)
+
+	r.Route("/api/leaderboard", func(r chi.Router) {
+		r.With(app.middleware.OptionalAuth).Get("/", app.leaderboard.GetLeaderboardHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(app.middleware.RequireAuth)
+			r.Get("/participation", app.leaderboard.GetParticipationHandler)
+			r.Put("/participation", app.leaderboard.UpdateParticipationHandler)
+		})
+	})
+
+	r.Route("/api/courses", func(r chi.Router) {
+		r.Use(app.middleware.RequireAuth)
+		r.Get("/", app.courses.ListCoursesHandler)
+		r.Post("/join", app.courses.JoinCourseHandler)
+		r.Get("/{id}", app.courses.GetCourseHandler)
+
+		// Per-course permissions are checked against the membership
+		r.Put("/{id}/units", app.courses.SetUnitsHandler)
+		r.Post("/{id}/join-code", app.courses.RegenerateJoinCodeHandler)
+		r.Get("/{id}/members", app.courses.ListMembersHandler)
+		r.Get("/{id}/submissions", app.courses.ListSubmissionsHandler)
+		r.Get("/{id}/assignments", app.assignments.ListCourseAssignmentsHandler)
+		r.Post("/{id}/assignments", app.assignments.CreateAssignmentHandler)
+		r.Get("/{id}/gradebook", app.gradebook.ExportHandler)
+		r.Get("/{id}/similarity", app.similarity.ListReportsHandler)
+		r.Post("/{id}/similarity", app.similarity.RequestReportHandler)
+		r.Get("/{id}/similarity/{reportID}", app.similarity.GetReportHandler)
+
+		r.With(app.middleware.RequireRole(auth.RoleInstructor, auth.RoleAdmin)).Post("/", app.courses.CreateCourseHandler)
+	})
+
+	r.Route("/api/assignments", func(r chi.Router) {
+		r.Use(app.middleware.RequireAuth)
+		r.Get("/pending", app.assignments.ListPendingHandler)
+		r.Get("/{id}", app.assignments.GetAssignmentHandler)
+		r.Get("/{id}/report", app.assignments.ReportHandler)
+		r.Delete("/{id}", app.assignments.DeleteAssignmentHandler)
+	})
 
 	r.Route("/api/submissions", func(r chi.Router) {
-		r.Use(mw.RequireAuth)
-		r.Post("/", app.submissions.CreateSubmissionsHandler)
-		r.Get("/", app.submissions.GetSubmissionsHandler)
-		r.Get("/{challenge_id}", app.submissions.GetSubmissionHandler)
+		// EventSource can't send the Authorization header, so the stream
+		// also takes a ticket
+		r.With(app.middleware.RequireStreamAuth).Get("/{id}/events", app.submissions.EventsHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(app.middleware.RequireAuth)
+			r.Post("/", app.submissions.CreateSubmissionsHandler)
+			r.Get("/", app.submissions.GetSubmissionsHandler)
+			r.Get("/{challenge_id}", app.submissions.GetSubmissionHandler)
+			r.Get("/{challenge_id}/attempts", app.submissions.ListAttemptsHandler)
+		})
 	})
 
 	return r
@@ -1,64 +1,128 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"apschool/internal/config"
+	"apschool/internal/grader"
+
 	_ "github.com/jackc/pgx/v5/stdlib"
-	_ "github.com/joho/godotenv/autoload"
+	"gopkg.in/yaml.v3"
 )
 
-var (
-	database = os.Getenv("APSCHOOL_DB_DATABASE")
-	password = os.Getenv("APSCHOOL_DB_PASSWORD")
-	username = os.Getenv("APSCHOOL_DB_USERNAME")
-	host     = os.Getenv("APSCHOOL_DB_HOST")
-	port     = os.Getenv("APSCHOOL_DB_PORT")
-	schema   = os.Getenv("APSCHOOL_DB_SCHEMA")
-)
+// manifestFile describes a unit; it lives at the root of each unit folder.
+const manifestFile = "unit.yaml"
+
+type Category struct {
+	Slug         string `yaml:"-"`
+	Title        string `yaml:"title"`
+	Description  string `yaml:"description"`
+	DisplayOrder int    `yaml:"order"`
+	Visible      *bool  `yaml:"visible"`
+}
 
 type Challenge struct {
-	Slug        string
-	Category    string
-	Title       string
-	Description string
-	Template    string
-	TestCode    string
-	Hints       string
+	Slug           string
+	Category       string
+	Title          string
+	Description    string
+	Template       string
+	TestCode       string
+	HiddenTestCode string
+	TestCases      []grader.Case
+	Solution       string
+	Hints          string
+	Difficulty     string
+	Tags           []string
+}
+
+// FrontMatter is the optional YAML block between "---" lines at the top of
+// a challenge README.
+type FrontMatter struct {
+	Difficulty string   `yaml:"difficulty"`
+	Tags       []string `yaml:"tags"`
 }
 
 func main() {
-	db, err := openDB()
+	verify := flag.Bool("verify", false, "run every reference solution and template against its tests and skip challenges that fail")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var verifier *Verifier
+	if *verify {
+		v, err := NewVerifier(cfg.Grader.Python)
+		if err != nil {
+			log.Fatal(err)
+		}
+		verifier = v
+	}
+
+	db, err := openDB(cfg.DB.URL())
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
+	categories, err := loadCategories("challenges")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Categories go first, challenges reference them by slug
+	for _, c := range categories {
+		if err := upsertCategory(db, c); err != nil {
+			log.Fatalf("Error upserting category %s: %v", c.Slug, err)
+		}
+		log.Printf("Upserted category: %s", c.Slug)
+	}
+
 	challenges, err := loadChallenges("challenges")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	count := 0
+	count, rejected, failed := 0, 0, 0
 	for _, c := range challenges {
+		if verifier != nil {
+			if err := verifier.Verify(context.Background(), c); err != nil {
+				log.Printf("Refusing to upsert challenge %s: %v", c.Slug, err)
+				rejected++
+				continue
+			}
+		}
+
 		err := upsertChallenge(db, c)
 		if err != nil {
 			log.Printf("Error upserting challenge %s: %v", c.Slug, err)
+			failed++
 			continue
 		}
 		count++
 		log.Printf("Upserted challenge: %s (%s)", c.Slug, c.Category)
 	}
-}
 
-func openDB() (*sql.DB, error) {
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s", username, password, host, port, database, schema)
+	log.Printf("Upserted %d of %d challenge(s)", count, len(challenges))
 
-	db, err := sql.Open("pgx", connStr)
+	// A partial seed must fail the deploy that ran it
+	if rejected > 0 || failed > 0 {
+		log.Fatalf("%d challenge(s) failed verification, %d failed to upsert", rejected, failed)
+	}
+}
+
+func openDB(url string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", url)
 	if err != nil {
 		return nil, err
 	}
@@ -70,6 +134,53 @@ func openDB() (*sql.DB, error) {
 	return db, nil
 }
 
+func loadCategories(basePath string) ([]Category, error) {
+	var categories []Category
+
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		category, err := loadCategory(filepath.Join(basePath, entry.Name()), entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, nil
+}
+
+func loadCategory(path, slug string) (Category, error) {
+	category := Category{Slug: slug, Title: slug}
+
+	content, err := os.ReadFile(filepath.Join(path, manifestFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Warning: %s has no %s, using the folder name as title", path, manifestFile)
+			return category, nil
+		}
+		return Category{}, err
+	}
+
+	if err := yaml.Unmarshal(content, &category); err != nil {
+		return Category{}, fmt.Errorf("%s: %w", manifestFile, err)
+	}
+
+	if strings.TrimSpace(category.Title) == "" {
+		category.Title = slug
+	}
+
+	return category, nil
+}
+
 func loadChallenges(basePath string) ([]Challenge, error) {
 	var challenges []Challenge
 
@@ -114,6 +225,17 @@ func loadChallenge(path, category, slug string) (Challenge, error) {
 		return Challenge{}, fmt.Errorf("README.md :%w", err)
 	}
 
+	meta, readme, err := splitFrontMatter(readme)
+	if err != nil {
+		return Challenge{}, fmt.Errorf("README.md :%w", err)
+	}
+	if meta.Difficulty == "" {
+		meta.Difficulty = "easy"
+	}
+	if meta.Tags == nil {
+		meta.Tags = []string{}
+	}
+
 	title, description := parseReadme(readme)
 
 	template, err := readFile(filepath.Join(path, "template.py"))
@@ -126,23 +248,65 @@ func loadChallenge(path, category, slug string) (Challenge, error) {
 		return Challenge{}, fmt.Errorf("tests.py :%w", err)
 	}
 
+	// Hidden tests are optional and only ever run on the server
+	hiddenTestCode, err := readFile(filepath.Join(path, "hidden_tests.py"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Challenge{}, fmt.Errorf("hidden_tests.py :%w", err)
+	}
+
+	// The reference solution is optional unless the seed runs with -verify
+	solution, err := readFile(filepath.Join(path, "solution.py"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Challenge{}, fmt.Errorf("solution.py :%w", err)
+	}
+
+	testCases, err := loadTestCases(filepath.Join(path, "cases.yaml"))
+	if err != nil {
+		return Challenge{}, fmt.Errorf("cases.yaml :%w", err)
+	}
+
 	hints, err := readFile(filepath.Join(path, "hints.md"))
 	if err != nil {
 		return Challenge{}, fmt.Errorf("hints.md :%w", err)
 	}
 
 	return Challenge{
-		Slug:        slug,
-		Category:    category,
-		Title:       title,
-		Description: description,
-		Template:    template,
-		TestCode:    testCode,
-		Hints:       hints,
+		Slug:           slug,
+		Category:       category,
+		Title:          title,
+		Description:    description,
+		Template:       template,
+		TestCode:       testCode,
+		HiddenTestCode: hiddenTestCode,
+		TestCases:      testCases,
+		Solution:       solution,
+		Hints:          hints,
+		Difficulty:     meta.Difficulty,
+		Tags:           meta.Tags,
 	}, nil
 
 }
 
+// loadTestCases reads the optional list of declarative test cases of a
+// challenge.
+func loadTestCases(path string) ([]grader.Case, error) {
+	cases := []grader.Case{}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return cases, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(content, &cases); err != nil {
+		return nil, err
+	}
+
+	return cases, nil
+}
+
 func readFile(path string) (string, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
@@ -151,6 +315,31 @@ func readFile(path string) (string, error) {
 	return string(content), nil
 }
 
+// splitFrontMatter separates the front-matter block from the rest of the
+// README. A README without one gets a zero FrontMatter.
+func splitFrontMatter(content string) (FrontMatter, string, error) {
+	var meta FrontMatter
+
+	// READMEs saved on Windows would otherwise never match the delimiters
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	rest, ok := strings.CutPrefix(content, "---\n")
+	if !ok {
+		return meta, content, nil
+	}
+
+	block, body, ok := strings.Cut(rest, "\n---\n")
+	if !ok {
+		return meta, content, errors.New("front-matter is not closed with ---")
+	}
+
+	if err := yaml.Unmarshal([]byte(block), &meta); err != nil {
+		return meta, content, fmt.Errorf("front-matter: %w", err)
+	}
+
+	return meta, strings.TrimLeft(body, "\n"), nil
+}
+
 func parseReadme(content string) (title, description string) {
 	lines := strings.SplitN(content, "\n", 2)
 
@@ -170,20 +359,48 @@ func parseReadme(content string) (title, description string) {
 	return title, description
 }
 
+func upsertCategory(db *sql.DB, c Category) error {
+	visible := c.Visible == nil || *c.Visible
+
+	query := `
+	INSERT INTO categories(slug, title, description, display_order, is_visible)
+	VALUES($1, $2, $3, $4, $5)
+	ON CONFLICT (slug) DO UPDATE SET
+		title = $2,
+		description = $3,
+		display_order = $4,
+		is_visible = $5,
+		updated_at = NOW()
+	`
+
+	_, err := db.Exec(query, c.Slug, c.Title, c.Description, c.DisplayOrder, visible)
+	return err
+}
+
 func upsertChallenge(db *sql.DB, c Challenge) error {
+	testCases, err := json.Marshal(c.TestCases)
+	if err != nil {
+		return err
+	}
+
 	query := `
-	INSERT INTO challenges(slug, category, title, description, template, test_code, hints)
-	VALUES($1, $2, $3, $4, $5, $6, $7)
+	INSERT INTO challenges(slug, category, title, description, template, test_code, hidden_test_code, test_cases, solution, hints, difficulty, tags)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	ON CONFLICT (slug) DO UPDATE SET
 		category = $2,
 		title = $3,
 		description = $4,
 		template = $5,
 		test_code = $6,
-		hints = $7,
+		hidden_test_code = $7,
+		test_cases = $8,
+		solution = $9,
+		hints = $10,
+		difficulty = $11,
+		tags = $12,
 		updated_at = NOW()
 	`
 
-	_, err := db.Exec(query, c.Slug, c.Category, c.Title, c.Description, c.Template, c.TestCode, c.Hints)
+	_, err = db.Exec(query, c.Slug, c.Category, c.Title, c.Description, c.Template, c.TestCode, c.HiddenTestCode, testCases, c.Solution, c.Hints, c.Difficulty, c.Tags)
 	return err
 }
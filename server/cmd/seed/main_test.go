@@ -0,0 +1,60 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	readme := "---\ndifficulty: hard\ntags: [loops, math]\n---\n\n# Factorial\n\nCalcula n!\n"
+
+	tests := []struct {
+		name    string
+		content string
+		want    FrontMatter
+		body    string
+		wantErr bool
+	}{
+		{
+			name:    "front-matter",
+			content: readme,
+			want:    FrontMatter{Difficulty: "hard", Tags: []string{"loops", "math"}},
+			body:    "# Factorial\n\nCalcula n!\n",
+		},
+		{
+			name:    "windows line endings",
+			content: strings.ReplaceAll(readme, "\n", "\r\n"),
+			want:    FrontMatter{Difficulty: "hard", Tags: []string{"loops", "math"}},
+			body:    "# Factorial\n\nCalcula n!\n",
+		},
+		{
+			name:    "none",
+			content: "# Factorial\n",
+			body:    "# Factorial\n",
+		},
+		{
+			name:    "not closed",
+			content: "---\ndifficulty: hard\n# Factorial\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body, err := splitFrontMatter(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitFrontMatter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if meta.Difficulty != tt.want.Difficulty || !slices.Equal(meta.Tags, tt.want.Tags) {
+				t.Errorf("front-matter = %+v, want %+v", meta, tt.want)
+			}
+			if body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
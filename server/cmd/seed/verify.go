@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"apschool/internal/grader"
+)
+
+// Verifier checks a challenge's tests before it is seeded: the reference
+// solution has to pass them and the starting template has to fail them,
+// otherwise the tests can't tell a correct answer from no answer at all.
+type Verifier struct {
+	grader grader.Grader
+}
+
+func NewVerifier(python string) (*Verifier, error) {
+	path, err := exec.LookPath(python)
+	if err != nil {
+		return nil, fmt.Errorf("-verify needs a python interpreter (set -python or GRADER_PYTHON): %w", err)
+	}
+
+	return &Verifier{grader: grader.NewPythonGrader(path, grader.DefaultLimits)}, nil
+}
+
+func (v *Verifier) Verify(ctx context.Context, c Challenge) error {
+	if c.Solution == "" {
+		return errors.New("solution.py is missing")
+	}
+
+	tests := grader.ChallengeTests(c.TestCode, c.HiddenTestCode, c.TestCases)
+
+	result, err := v.grader.Grade(ctx, c.Solution, tests)
+	if err != nil {
+		return fmt.Errorf("grading solution.py: %w", err)
+	}
+	if !result.Passed {
+		return fmt.Errorf("solution.py does not pass the tests: %s", describeFailure(result))
+	}
+
+	result, err = v.grader.Grade(ctx, c.Template, tests)
+	if err != nil {
+		return fmt.Errorf("grading template.py: %w", err)
+	}
+	if result.Passed {
+		return errors.New("template.py passes the tests")
+	}
+
+	return nil
+}
+
+// describeFailure names the first thing that went wrong. The grader redacts
+// hidden tests, so for those only their name is known.
+func describeFailure(result *grader.Result) string {
+	for _, c := range result.Cases {
+		if !c.Passed {
+			if c.Hidden {
+				return fmt.Sprintf("hidden case %q failed", c.Name)
+			}
+			if c.Error != "" {
+				return fmt.Sprintf("case %q: %s", c.Name, c.Error)
+			}
+			return fmt.Sprintf("case %q: expected %#v, got %#v", c.Name, c.Expected, c.Actual)
+		}
+	}
+	if result.Error != "" {
+		return result.Error
+	}
+	return "tests did not print " + grader.PassMarker
+}
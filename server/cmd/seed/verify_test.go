@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"apschool/internal/grader"
+)
+
+func newTestVerifier(t *testing.T) *Verifier {
+	t.Helper()
+
+	v, err := NewVerifier("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+	return v
+}
+
+// addChallenge is a small challenge with a suite, a public case and a
+// hidden one.
+func addChallenge() Challenge {
+	return Challenge{
+		Slug:     "add",
+		Template: "def add(a, b):\n    pass\n",
+		Solution: "def add(a, b):\n    return a + b\n",
+		TestCode: "assert add(1, 2) == 3\nprint(\"ALL_TESTS_PASSED\")\n",
+		TestCases: []grader.Case{
+			{Name: "small", Call: "add", Args: []any{2, 2}, Expected: 4},
+			{Name: "negative", Call: "add", Args: []any{-1, -2}, Expected: -3, Hidden: true},
+		},
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	v := newTestVerifier(t)
+
+	tests := []struct {
+		name    string
+		modify  func(c *Challenge)
+		wantErr []string
+	}{
+		{
+			name: "solution passes and template fails",
+		},
+		{
+			name:    "template passes",
+			modify:  func(c *Challenge) { c.Template = c.Solution },
+			wantErr: []string{"template.py passes the tests"},
+		},
+		{
+			name:    "missing solution",
+			modify:  func(c *Challenge) { c.Solution = "" },
+			wantErr: []string{"solution.py is missing"},
+		},
+		{
+			name: "solution fails a case",
+			modify: func(c *Challenge) {
+				c.TestCode = ""
+				c.Solution = "def add(a, b):\n    return a - b\n"
+			},
+			wantErr: []string{`solution.py does not pass the tests: case "small": expected 4, got 0`},
+		},
+		{
+			name: "solution fails a hidden case",
+			modify: func(c *Challenge) {
+				c.Solution = "def add(a, b):\n    return abs(a + b)\n"
+			},
+			wantErr: []string{`solution.py does not pass the tests: hidden case "negative" failed`},
+		},
+		{
+			name: "solution raises",
+			modify: func(c *Challenge) {
+				c.TestCode = ""
+				c.Solution = "def add(a, b):\n    raise ValueError('nope')\n"
+			},
+			wantErr: []string{`solution.py does not pass the tests: case "small": `, "ValueError: nope"},
+		},
+		{
+			name: "solution fails the suite",
+			modify: func(c *Challenge) {
+				c.Solution = "def add(a, b):\n    return 0 if (a, b) == (1, 2) else a + b\n"
+			},
+			wantErr: []string{"solution.py does not pass the tests: ", "AssertionError"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := addChallenge()
+			if tt.modify != nil {
+				tt.modify(&c)
+			}
+
+			err := v.Verify(context.Background(), c)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Verify() error = nil, want %q", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Verify() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDescribeFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *grader.Result
+		want   string
+	}{
+		{
+			name: "wrong value",
+			result: &grader.Result{Cases: []grader.CaseResult{
+				{Name: "ok", Passed: true},
+				{Name: "small", Expected: 4.0, Actual: "4"},
+			}},
+			want: `case "small": expected 4, got "4"`,
+		},
+		{
+			name:   "case error",
+			result: &grader.Result{Cases: []grader.CaseResult{{Name: "small", Error: "ZeroDivisionError: division by zero"}}},
+			want:   `case "small": ZeroDivisionError: division by zero`,
+		},
+		{
+			name:   "hidden case",
+			result: &grader.Result{Cases: []grader.CaseResult{{Name: "negative", Hidden: true}}},
+			want:   `hidden case "negative" failed`,
+		},
+		{
+			name:   "suite error",
+			result: &grader.Result{Error: "AssertionError"},
+			want:   "AssertionError",
+		},
+		{
+			name:   "no marker",
+			result: &grader.Result{},
+			want:   "tests did not print " + grader.PassMarker,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeFailure(tt.result); got != tt.want {
+				t.Errorf("describeFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"apschool/internal/config"
+	"apschool/internal/similarity"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Prints the pairs of course students whose latest code for a challenge is
+// suspiciously similar. Instructors get the same report through
+// POST /api/courses/{id}/similarity.
+func main() {
+	courseID := flag.Int("course", 0, "id of the course whose students are compared")
+	challengeID := flag.Int("challenge", 0, "id of the challenge")
+	threshold := flag.Float64("threshold", similarity.DefaultThreshold, "minimum similarity to report, between 0 and 1")
+	asJSON := flag.Bool("json", false, "print the pairs as JSON")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *courseID <= 0 || *challengeID <= 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *threshold <= 0 || *threshold > 1 {
+		log.Fatalf("invalid threshold %v", *threshold)
+	}
+
+	db, err := openDB(cfg.DB.URL())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	service := similarity.NewService(similarity.NewRepository(db), nil, nil)
+
+	count, pairs, err := service.Analyze(context.Background(), *courseID, *challengeID, *threshold)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(pairs); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	fmt.Printf("Compared %d submissions, %d pair(s) at or above %.0f%%\n\n", count, len(pairs), *threshold*100)
+	if len(pairs) == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SIMILARITY\tSTUDENT A\tSTUDENT B\tMATCHING LINES (A / B)")
+	for _, p := range pairs {
+		fmt.Fprintf(tw, "%.0f%%\t%s (#%d)\t%s (#%d)\t%s\n",
+			p.Similarity*100, p.A.Username, p.A.ID, p.B.Username, p.B.ID, formatRegions(p.Regions))
+	}
+	if err := tw.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func formatRegions(regions []similarity.Region) string {
+	out := ""
+	for i, r := range regions {
+		if i > 0 {
+			out += ", "
+		}
+		out += fmt.Sprintf("%d-%d / %d-%d", r.StartA, r.EndA, r.StartB, r.EndB)
+	}
+	return out
+}
+
+func openDB(url string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"apschool/internal/achievements"
+	"apschool/internal/auth"
+	"apschool/internal/config"
+	"apschool/internal/grader"
+	"apschool/internal/jobs"
+	"apschool/internal/leaderboard"
+	"apschool/internal/similarity"
+	"apschool/internal/submissions"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Runs background jobs outside the API process, for deployments that start
+// the API with JOB_WORKERS=0. Several workers can share the same database.
+func main() {
+	workers := flag.Int("workers", 4, "number of jobs run at the same time")
+	drain := flag.Duration("drain", time.Minute, "how long in-flight jobs get to finish on shutdown")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *workers < 1 {
+		log.Fatalf("invalid number of workers %d", *workers)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	db, err := openDB(cfg.DB.URL())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	g, err := newGrader(cfg.Grader)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Report jobs never request new reports, so they need neither the course
+	// checks nor a queue
+	similarityService := similarity.NewService(similarity.NewRepository(db), nil, nil)
+	leaderboardService := leaderboard.NewService(leaderboard.NewRepository(db))
+	tokens := auth.NewTokenService(auth.NewTokenRepository(db), []byte(cfg.JWTSecret))
+	submissionService := submissions.NewService(submissions.NewRepository(db), g, achievements.NewService(achievements.NewRepository(db)), nil)
+
+	runner := jobs.NewRunner(jobs.NewRepository(db), *workers, logger)
+	runner.Handle(similarity.KindReport, similarityService.RunReportJob)
+	runner.Handle(leaderboard.KindRefresh, leaderboardService.RefreshJob)
+	runner.Every(leaderboard.KindRefresh, leaderboard.RefreshInterval)
+	runner.Handle(auth.KindCleanupTokens, tokens.CleanupJob)
+	runner.Every(auth.KindCleanupTokens, auth.CleanupInterval)
+	if g != nil {
+		runner.Handle(submissions.KindGrade, submissionService.RunGradeJob)
+		runner.OnDead(submissions.KindGrade, submissionService.FailGradeJob)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	runner.Start()
+	log.Printf("Worker started with %d workers", *workers)
+
+	<-ctx.Done()
+	stop() // Allow Ctrl+C to force shutdown
+
+	log.Println("draining jobs, press Ctrl+C again to force")
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), *drain)
+	defer cancel()
+	if err := runner.Shutdown(drainCtx); err != nil {
+		log.Printf("Jobs interrupted on shutdown, they will be retried: %v", err)
+	}
+
+	log.Println("Worker exiting")
+}
+
+// newGrader mirrors the API: with GRADER_MODE=client nothing is graded on
+// the server, so this worker doesn't take grading jobs.
+func newGrader(cfg config.Grader) (grader.Grader, error) {
+	if cfg.Mode == config.GraderClient {
+		return nil, nil
+	}
+
+	path, err := exec.LookPath(cfg.Python)
+	if err != nil {
+		return nil, fmt.Errorf("grading needs a python interpreter (set GRADER_PYTHON or GRADER_MODE=client): %w", err)
+	}
+
+	return grader.NewPythonGrader(path, grader.DefaultLimits), nil
+}
+
+func openDB(url string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,34 @@
+package achievements
+
+import (
+	"log/slog"
+	"net/http"
+
+	"apschool/internal/ctxkeys"
+	"apschool/internal/response"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+func (h *Handler) GetAchievementsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+
+	achievements, err := h.service.GetAchievements(r.Context(), userID)
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"achievements": achievements}, nil)
+}
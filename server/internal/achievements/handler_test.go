@@ -0,0 +1,57 @@
+package achievements
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"apschool/internal/ctxkeys"
+)
+
+func TestGetAchievementsHandler(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	h := NewHandler(NewService(repo), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ana := createUser(t, "ana")
+	submit(t, ana, ids["resta"], true, time.Date(2025, 3, 10, 12, 0, 0, 0, ecuador))
+
+	rr := httptest.NewRecorder()
+	h.GetAchievementsHandler(rr, httptest.NewRequest(http.MethodGet, "/api/me/achievements", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("signed out status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me/achievements", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxkeys.UserIDKey, ana))
+	rr = httptest.NewRecorder()
+	h.GetAchievementsHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+	}
+
+	var envelope struct {
+		Achievements Achievements `json:"achievements"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decoding %s: %v", rr.Body, err)
+	}
+
+	got := envelope.Achievements
+	if got.Stats.Solved != 1 || got.Stats.XP != 25 {
+		t.Errorf("stats = %+v, want 1 solved and 25 xp", got.Stats)
+	}
+	if len(got.Earned) != 1 || got.Earned[0].Slug != "first-challenge" || got.Earned[0].AwardedAt == nil {
+		t.Errorf("earned = %+v, want first-challenge with its award time", got.Earned)
+	}
+	if len(got.Earned)+len(got.Locked) != 5 {
+		t.Errorf("%d earned and %d locked, want the 5 seeded badges", len(got.Earned), len(got.Locked))
+	}
+}
@@ -0,0 +1,59 @@
+package achievements
+
+import "time"
+
+// Badge criteria types, matching the badges.criteria_type column.
+const (
+	CriteriaSolvedCount   = "solved_count"
+	CriteriaUnitCompleted = "unit_completed"
+	CriteriaStreakDays    = "streak_days"
+	CriteriaXPTotal       = "xp_total"
+)
+
+type Badge struct {
+	ID            int        `json:"-"`
+	Slug          string     `json:"slug"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Icon          string     `json:"icon"`
+	CriteriaType  string     `json:"-"`
+	CriteriaValue int        `json:"-"`
+	CriteriaUnit  string     `json:"-"`
+	AwardedAt     *time.Time `json:"awarded_at,omitempty"`
+}
+
+type Stats struct {
+	XP             int      `json:"xp"`
+	Solved         int      `json:"solved"`
+	CurrentStreak  int      `json:"current_streak"`
+	LongestStreak  int      `json:"longest_streak"`
+	CompletedUnits []string `json:"completed_units"`
+}
+
+type Achievements struct {
+	Stats  Stats   `json:"stats"`
+	Earned []Badge `json:"earned"`
+	Locked []Badge `json:"locked"`
+}
+
+// Earned reports whether stats meet the badge's criteria. Unknown criteria
+// types never match, so a badge added for a newer server is just locked.
+func (b *Badge) Earned(stats *Stats) bool {
+	switch b.CriteriaType {
+	case CriteriaSolvedCount:
+		return stats.Solved >= b.CriteriaValue
+	case CriteriaUnitCompleted:
+		for _, unit := range stats.CompletedUnits {
+			if unit == b.CriteriaUnit {
+				return true
+			}
+		}
+		return false
+	case CriteriaStreakDays:
+		return stats.LongestStreak >= b.CriteriaValue
+	case CriteriaXPTotal:
+		return stats.XP >= b.CriteriaValue
+	default:
+		return false
+	}
+}
@@ -0,0 +1,151 @@
+package achievements
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// ListBadges returns every badge definition, with the time the user earned it
+// when they have.
+func (r *Repository) ListBadges(ctx context.Context, userID int) ([]Badge, error) {
+
+	query := `SELECT b.id, b.slug, b.name, b.description, b.icon, b.criteria_type, b.criteria_value, b.criteria_unit, ub.awarded_at
+	FROM badges b
+	LEFT JOIN user_badges ub ON ub.badge_id = b.id AND ub.user_id = $1
+	ORDER BY b.id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	badges := []Badge{}
+	for rows.Next() {
+		var b Badge
+		err := rows.Scan(&b.ID, &b.Slug, &b.Name, &b.Description, &b.Icon, &b.CriteriaType, &b.CriteriaValue, &b.CriteriaUnit, &b.AwardedAt)
+		if err != nil {
+			return nil, err
+		}
+		badges = append(badges, b)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return badges, nil
+}
+
+// AwardBadge reports false when the user already had the badge.
+func (r *Repository) AwardBadge(ctx context.Context, userID, badgeID int) (time.Time, bool, error) {
+	var awardedAt time.Time
+
+	query := `INSERT INTO user_badges (user_id, badge_id)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id, badge_id) DO NOTHING
+	RETURNING awarded_at`
+
+	err := r.db.QueryRowContext(ctx, query, userID, badgeID).Scan(&awardedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return awardedAt, true, nil
+}
+
+// GetSolvedAndXP counts distinct solved challenges and sums their XP by
+// difficulty.
+func (r *Repository) GetSolvedAndXP(ctx context.Context, userID int) (solved, xp int, err error) {
+
+	query := `SELECT count(*), coalesce(sum(dx.xp), 0)
+	FROM (
+		SELECT DISTINCT challenge_id FROM submissions
+		WHERE user_id = $1 AND passed = true
+	) s
+	JOIN challenges ch ON ch.id = s.challenge_id
+	LEFT JOIN difficulty_xp dx ON dx.difficulty = ch.difficulty`
+
+	err = r.db.QueryRowContext(ctx, query, userID).Scan(&solved, &xp)
+	return solved, xp, err
+}
+
+// GetSolveDates returns the distinct calendar days, in timezone, on which the
+// user had an accepted submission, oldest first.
+func (r *Repository) GetSolveDates(ctx context.Context, userID int, timezone string) ([]time.Time, error) {
+
+	query := `SELECT DISTINCT (created_at AT TIME ZONE $2)::date AS day
+	FROM submissions
+	WHERE user_id = $1 AND passed = true
+	ORDER BY day`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, timezone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []time.Time
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// GetCompletedUnits returns the units whose active challenges the user has
+// all solved.
+func (r *Repository) GetCompletedUnits(ctx context.Context, userID int) ([]string, error) {
+
+	query := `SELECT ch.category
+	FROM challenges ch
+	LEFT JOIN (
+		SELECT DISTINCT challenge_id FROM submissions
+		WHERE user_id = $1 AND passed = true
+	) s ON s.challenge_id = ch.id
+	WHERE ch.is_active = true
+	GROUP BY ch.category
+	HAVING count(*) = count(s.challenge_id)
+	ORDER BY ch.category`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []string{}
+	for rows.Next() {
+		var unit string
+		if err := rows.Scan(&unit); err != nil {
+			return nil, err
+		}
+		units = append(units, unit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return units, nil
+}
@@ -0,0 +1,283 @@
+package achievements
+
+import (
+	"context"
+	"flag"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"apschool/internal/testutil"
+)
+
+var testDB *testutil.TestDB
+
+func TestMain(m *testing.M) {
+	// Parse flags first so we can check -short
+	flag.Parse()
+
+	// Skip container setup entirely in short mode (for CI without Docker)
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	ctx := context.Background()
+
+	var err error
+	testDB, err = testutil.SetupTestDB(ctx)
+	if err != nil {
+		panic("failed to setup test db: " + err.Error())
+	}
+
+	code := m.Run()
+
+	testDB.Teardown(ctx)
+	os.Exit(code)
+}
+
+// ecuador is Guayaquil's offset, which has no daylight saving time.
+var ecuador = time.FixedZone("ECT", -5*60*60)
+
+// setupRepository empties users, which earned badges cascade from, and the
+// catalog, then creates unit-1-intro, the unit of the seeded unit-1-complete
+// badge, with an easy and a medium challenge plus an inactive one, and a
+// unit-2 with a hard challenge. Badges and XP per difficulty come from the
+// migrations.
+func setupRepository(t *testing.T) (*Repository, map[string]int) {
+	t.Helper()
+
+	testDB.TruncateTables(t)
+	if _, err := testDB.DB.Exec("TRUNCATE challenges, categories CASCADE"); err != nil {
+		t.Fatalf("failed to truncate challenges: %v", err)
+	}
+	if _, err := testDB.DB.Exec(`INSERT INTO categories (slug, title) VALUES ('unit-1-intro', 'Unidad 1'), ('unit-2', 'Unidad 2')`); err != nil {
+		t.Fatalf("failed to create units: %v", err)
+	}
+
+	challenges := []struct {
+		slug, unit, difficulty string
+		active                 bool
+	}{
+		{"suma", "unit-1-intro", "easy", true},
+		{"resta", "unit-1-intro", "medium", true},
+		{"viejo", "unit-1-intro", "hard", false},
+		{"lista", "unit-2", "hard", true},
+	}
+
+	ids := make(map[string]int)
+	for _, c := range challenges {
+		var id int
+		err := testDB.DB.QueryRow(`INSERT INTO challenges (slug, category, title, description, template, test_code, difficulty, is_active)
+		VALUES ($1, $2, $1, '', '', 'assert True', $3, $4) RETURNING id`, c.slug, c.unit, c.difficulty, c.active).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create challenge %s: %v", c.slug, err)
+		}
+		ids[c.slug] = id
+	}
+
+	return NewRepository(testDB.DB), ids
+}
+
+func createUser(t *testing.T, username string) int {
+	t.Helper()
+
+	var id int
+	err := testDB.DB.QueryRow(`INSERT INTO users (username, email) VALUES ($1, $2) RETURNING id`,
+		username, username+"@example.com").Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create user %s: %v", username, err)
+	}
+	return id
+}
+
+func submit(t *testing.T, userID, challengeID int, passed bool, at time.Time) {
+	t.Helper()
+
+	status := "failed"
+	if passed {
+		status = "passed"
+	}
+	_, err := testDB.DB.Exec(`INSERT INTO submissions (user_id, challenge_id, code, passed, status, created_at) VALUES ($1, $2, 'x', $3, $4, $5)`,
+		userID, challengeID, passed, status, at)
+	if err != nil {
+		t.Fatalf("failed to create submission: %v", err)
+	}
+}
+
+func TestRepository_GetSolveDates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	ana := createUser(t, "ana")
+	beto := createUser(t, "beto")
+
+	// 04:30 and 03:00 UTC are still the previous evening in Guayaquil
+	submit(t, ana, ids["suma"], true, time.Date(2025, 3, 8, 23, 30, 0, 0, ecuador))
+	submit(t, ana, ids["suma"], true, time.Date(2025, 3, 9, 10, 0, 0, 0, ecuador))
+	submit(t, ana, ids["resta"], true, time.Date(2025, 3, 9, 22, 0, 0, 0, ecuador))
+	submit(t, ana, ids["resta"], false, time.Date(2025, 3, 11, 12, 0, 0, 0, ecuador))
+	submit(t, beto, ids["suma"], true, time.Date(2025, 3, 10, 12, 0, 0, 0, ecuador))
+
+	days, err := repo.GetSolveDates(context.Background(), ana, TimeZone)
+	if err != nil {
+		t.Fatalf("GetSolveDates() error = %v", err)
+	}
+
+	got := []string{}
+	for _, d := range days {
+		got = append(got, d.Format(time.DateOnly))
+	}
+	if want := []string{"2025-03-08", "2025-03-09"}; !slices.Equal(got, want) {
+		t.Errorf("GetSolveDates() = %v, want %v", got, want)
+	}
+}
+
+func TestRepository_GetSolvedAndXP(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	ana := createUser(t, "ana")
+	at := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// XP counts once per challenge, however many times it was passed
+	submit(t, ana, ids["suma"], true, at)
+	submit(t, ana, ids["suma"], true, at.Add(time.Hour))
+	submit(t, ana, ids["resta"], true, at)
+	submit(t, ana, ids["lista"], false, at)
+
+	solved, xp, err := repo.GetSolvedAndXP(context.Background(), ana)
+	if err != nil {
+		t.Fatalf("GetSolvedAndXP() error = %v", err)
+	}
+	if solved != 2 || xp != 35 {
+		t.Errorf("GetSolvedAndXP() = %d solved, %d xp, want 2 solved, 35 xp", solved, xp)
+	}
+}
+
+func TestRepository_GetCompletedUnits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	ctx := context.Background()
+	ana := createUser(t, "ana")
+	beto := createUser(t, "beto")
+	at := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// The inactive challenge doesn't hold the unit back
+	submit(t, ana, ids["suma"], true, at)
+	submit(t, ana, ids["resta"], true, at)
+	submit(t, beto, ids["suma"], true, at)
+	submit(t, beto, ids["resta"], false, at)
+
+	tests := []struct {
+		name   string
+		userID int
+		want   []string
+	}{
+		{"every active challenge solved", ana, []string{"unit-1-intro"}},
+		{"one challenge failed", beto, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			units, err := repo.GetCompletedUnits(ctx, tt.userID)
+			if err != nil {
+				t.Fatalf("GetCompletedUnits() error = %v", err)
+			}
+			if !slices.Equal(units, tt.want) {
+				t.Errorf("GetCompletedUnits() = %v, want %v", units, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_AwardBadge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, _ := setupRepository(t)
+	ctx := context.Background()
+	ana := createUser(t, "ana")
+
+	var badgeID int
+	if err := testDB.DB.QueryRow(`SELECT id FROM badges WHERE slug = 'first-challenge'`).Scan(&badgeID); err != nil {
+		t.Fatal(err)
+	}
+
+	awardedAt, isNew, err := repo.AwardBadge(ctx, ana, badgeID)
+	if err != nil || !isNew || awardedAt.IsZero() {
+		t.Fatalf("AwardBadge() = %v, %v, %v, want a new award", awardedAt, isNew, err)
+	}
+	if _, isNew, err := repo.AwardBadge(ctx, ana, badgeID); err != nil || isNew {
+		t.Errorf("AwardBadge() again = %v, %v, want no new award", isNew, err)
+	}
+
+	badges, err := repo.ListBadges(ctx, ana)
+	if err != nil {
+		t.Fatalf("ListBadges() error = %v", err)
+	}
+	for _, b := range badges {
+		if earned := b.AwardedAt != nil; earned != (b.ID == badgeID) {
+			t.Errorf("badge %s awarded_at = %v, want only first-challenge earned", b.Slug, b.AwardedAt)
+		}
+	}
+}
+
+func TestService_GetAchievements(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	service := NewService(repo)
+	ctx := context.Background()
+	ana := createUser(t, "ana")
+
+	// Seven days in a row in Guayaquil. Evening solves fall on the next UTC
+	// day, so in UTC they would only span four separate days.
+	for i := range 7 {
+		hour := 8
+		if i%2 == 0 {
+			hour = 20
+		}
+		submit(t, ana, ids["suma"], true, time.Date(2025, 3, 3+i, hour, 0, 0, 0, ecuador))
+	}
+
+	achievements, err := service.GetAchievements(ctx, ana)
+	if err != nil {
+		t.Fatalf("GetAchievements() error = %v", err)
+	}
+	if achievements.Stats.LongestStreak != 7 || achievements.Stats.CurrentStreak != 0 {
+		t.Errorf("streaks = %d current, %d longest, want 0 and 7", achievements.Stats.CurrentStreak, achievements.Stats.LongestStreak)
+	}
+
+	earned := []string{}
+	for _, b := range achievements.Earned {
+		earned = append(earned, b.Slug)
+	}
+	if want := []string{"first-challenge", "streak-7"}; !slices.Equal(earned, want) {
+		t.Errorf("earned = %v, want %v", earned, want)
+	}
+
+	// Completing the unit awards only what's new
+	submit(t, ana, ids["resta"], true, time.Date(2025, 3, 10, 12, 0, 0, 0, ecuador))
+
+	awarded, err := service.Award(ctx, ana)
+	if err != nil {
+		t.Fatalf("Award() error = %v", err)
+	}
+	if len(awarded) != 1 || awarded[0].Slug != "unit-1-complete" {
+		t.Errorf("Award() = %+v, want only unit-1-complete", awarded)
+	}
+	if awarded, err := service.Award(ctx, ana); err != nil || len(awarded) != 0 {
+		t.Errorf("Award() again = %+v, %v, want nothing new", awarded, err)
+	}
+}
@@ -0,0 +1,159 @@
+package achievements
+
+import (
+	"context"
+	"time"
+	_ "time/tzdata"
+)
+
+// TimeZone decides where a day starts and ends for streaks. Students are at
+// ESPOL, so days follow Ecuador's clock rather than the server's.
+const TimeZone = "America/Guayaquil"
+
+var location = mustLoadLocation(TimeZone)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Award grants every badge the user now qualifies for and returns the ones
+// that are new. It is idempotent, so it is safe to call after every accepted
+// submission.
+func (s *Service) Award(ctx context.Context, userID int) ([]Badge, error) {
+	stats, err := s.GetStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	badges, err := s.repo.ListBadges(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.award(ctx, userID, stats, badges)
+}
+
+func (s *Service) GetAchievements(ctx context.Context, userID int) (*Achievements, error) {
+	stats, err := s.GetStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	badges, err := s.repo.ListBadges(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Catch up on anything a failed award or a new badge definition missed
+	if _, err := s.award(ctx, userID, stats, badges); err != nil {
+		return nil, err
+	}
+
+	result := &Achievements{Stats: *stats, Earned: []Badge{}, Locked: []Badge{}}
+	for _, b := range badges {
+		if b.AwardedAt != nil {
+			result.Earned = append(result.Earned, b)
+		} else {
+			result.Locked = append(result.Locked, b)
+		}
+	}
+
+	return result, nil
+}
+
+// award stores the badges stats qualify for, setting AwardedAt on them in
+// place, and returns the newly awarded ones.
+func (s *Service) award(ctx context.Context, userID int, stats *Stats, badges []Badge) ([]Badge, error) {
+	awarded := []Badge{}
+
+	for i := range badges {
+		b := &badges[i]
+		if b.AwardedAt != nil || !b.Earned(stats) {
+			continue
+		}
+
+		awardedAt, isNew, err := s.repo.AwardBadge(ctx, userID, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		if isNew {
+			b.AwardedAt = &awardedAt
+			awarded = append(awarded, *b)
+		}
+	}
+
+	return awarded, nil
+}
+
+func (s *Service) GetStats(ctx context.Context, userID int) (*Stats, error) {
+	solved, xp, err := s.repo.GetSolvedAndXP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := s.repo.GetSolveDates(ctx, userID, TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	units, err := s.repo.GetCompletedUnits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, longest := streaks(days, time.Now().In(location))
+
+	return &Stats{
+		XP:             xp,
+		Solved:         solved,
+		CurrentStreak:  current,
+		LongestStreak:  longest,
+		CompletedUnits: units,
+	}, nil
+}
+
+// streaks computes the current and longest runs of consecutive days from
+// sorted, distinct solve days. The current streak survives until the end of
+// the day after the last solve, so it doesn't drop to zero every morning.
+func streaks(days []time.Time, now time.Time) (current, longest int) {
+	run := 0
+	var prev time.Time
+
+	for i, day := range days {
+		if i > 0 && civilDays(prev, day) == 1 {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+		prev = day
+	}
+
+	if len(days) > 0 {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		if gap := civilDays(prev, today); gap == 0 || gap == 1 {
+			current = run
+		}
+	}
+
+	return current, longest
+}
+
+// civilDays counts calendar days from a to b, ignoring time of day and zone.
+func civilDays(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
@@ -0,0 +1,75 @@
+package achievements
+
+import (
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestStreaks(t *testing.T) {
+	now := time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		days        []time.Time
+		wantCurrent int
+		wantLongest int
+	}{
+		{"no solves", nil, 0, 0},
+		{"solved today", []time.Time{day("2025-03-10")}, 1, 1},
+		{"solved yesterday keeps the streak", []time.Time{day("2025-03-08"), day("2025-03-09")}, 2, 2},
+		{"gap breaks the current streak", []time.Time{day("2025-03-06"), day("2025-03-07")}, 0, 2},
+		{
+			"longest streak in the past",
+			[]time.Time{day("2025-02-01"), day("2025-02-02"), day("2025-02-03"), day("2025-03-09"), day("2025-03-10")},
+			2, 3,
+		},
+		{
+			"across a month boundary",
+			[]time.Time{day("2025-02-27"), day("2025-02-28"), day("2025-03-01")},
+			0, 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest := streaks(tt.days, now)
+			if current != tt.wantCurrent || longest != tt.wantLongest {
+				t.Errorf("streaks() = (%d, %d), want (%d, %d)", current, longest, tt.wantCurrent, tt.wantLongest)
+			}
+		})
+	}
+}
+
+func TestBadge_Earned(t *testing.T) {
+	stats := &Stats{XP: 120, Solved: 3, LongestStreak: 7, CompletedUnits: []string{"unit-1-intro"}}
+
+	tests := []struct {
+		name  string
+		badge Badge
+		want  bool
+	}{
+		{"solved count met", Badge{CriteriaType: CriteriaSolvedCount, CriteriaValue: 1}, true},
+		{"solved count not met", Badge{CriteriaType: CriteriaSolvedCount, CriteriaValue: 10}, false},
+		{"unit completed", Badge{CriteriaType: CriteriaUnitCompleted, CriteriaUnit: "unit-1-intro"}, true},
+		{"other unit", Badge{CriteriaType: CriteriaUnitCompleted, CriteriaUnit: "unit-2-variables"}, false},
+		{"streak met", Badge{CriteriaType: CriteriaStreakDays, CriteriaValue: 7}, true},
+		{"xp not met", Badge{CriteriaType: CriteriaXPTotal, CriteriaValue: 500}, false},
+		{"unknown criteria", Badge{CriteriaType: "future_rule", CriteriaValue: 0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.badge.Earned(stats); got != tt.want {
+				t.Errorf("Earned() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
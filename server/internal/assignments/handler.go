@@ -0,0 +1,179 @@
+package assignments
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"apschool/internal/courses"
+	"apschool/internal/ctxkeys"
+	"apschool/internal/response"
+	"apschool/internal/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+func (h *Handler) CreateAssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, courseID, ok := h.idRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Title        string    `json:"title"`
+		ChallengeIDs []int     `json:"challenge_ids"`
+		OpensAt      time.Time `json:"opens_at"`
+		DueAt        time.Time `json:"due_at"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	assignment := &Assignment{
+		CourseID:     courseID,
+		Title:        input.Title,
+		ChallengeIDs: input.ChallengeIDs,
+		OpensAt:      input.OpensAt,
+		DueAt:        input.DueAt,
+		CreatedBy:    userID,
+	}
+
+	v := validator.New()
+	if ValidateAssignment(v, assignment); !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	err := h.service.CreateAssignment(r.Context(), assignment, role)
+	if err != nil {
+		if errors.Is(err, ErrUnknownChallenge) {
+			v.AddError("challenge_ids", "contains a challenge that does not exist")
+			response.ValidationError(w, v.Errors)
+			return
+		}
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusCreated, response.Envelope{"assignment": assignment}, nil)
+}
+
+func (h *Handler) ListCourseAssignmentsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, courseID, ok := h.idRequest(w, r)
+	if !ok {
+		return
+	}
+
+	assignments, err := h.service.ListForCourse(r.Context(), courseID, userID, role)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"assignments": assignments}, nil)
+}
+
+func (h *Handler) ListPendingHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+
+	assignments, err := h.service.ListPending(r.Context(), userID)
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"assignments": assignments}, nil)
+}
+
+func (h *Handler) GetAssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, id, ok := h.idRequest(w, r)
+	if !ok {
+		return
+	}
+
+	assignment, err := h.service.GetAssignment(r.Context(), id, userID, role)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"assignment": assignment}, nil)
+}
+
+func (h *Handler) DeleteAssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, id, ok := h.idRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteAssignment(r.Context(), id, userID, role); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"message": "assignment deleted"}, nil)
+}
+
+func (h *Handler) ReportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, id, ok := h.idRequest(w, r)
+	if !ok {
+		return
+	}
+
+	assignment, statuses, err := h.service.Report(r.Context(), id, userID, role)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"assignment": assignment, "students": statuses}, nil)
+}
+
+// idRequest reads the caller and the {id} URL param. It writes the error
+// response itself when it fails.
+func (h *Handler) idRequest(w http.ResponseWriter, r *http.Request) (userID int, role string, id int, ok bool) {
+	userID, ok = ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return 0, "", 0, false
+	}
+	role, _ = ctxkeys.GetRole(r.Context())
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid id")
+		return 0, "", 0, false
+	}
+
+	return userID, role, id, true
+}
+
+func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrAssignmentNotFound),
+		errors.Is(err, courses.ErrCourseNotFound),
+		errors.Is(err, courses.ErrNotCourseMember):
+		response.NotFound(w)
+	case errors.Is(err, courses.ErrNotCourseInstructor):
+		response.Forbidden(w)
+	default:
+		response.ServerError(w, r, h.logger, err)
+	}
+}
@@ -0,0 +1,137 @@
+package assignments
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"apschool/internal/auth"
+	"apschool/internal/courses"
+	"apschool/internal/ctxkeys"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newAssignmentRouter mounts the assignment handlers the way routes.go does,
+// minus the auth middleware.
+func newAssignmentRouter(repo *Repository) http.Handler {
+	service := NewService(repo, courses.NewService(courses.NewRepository(testDB.DB)))
+	h := NewHandler(service, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r := chi.NewRouter()
+	r.Get("/api/courses/{id}/assignments", h.ListCourseAssignmentsHandler)
+	r.Post("/api/courses/{id}/assignments", h.CreateAssignmentHandler)
+	r.Get("/api/assignments/pending", h.ListPendingHandler)
+	r.Get("/api/assignments/{id}", h.GetAssignmentHandler)
+	r.Get("/api/assignments/{id}/report", h.ReportHandler)
+	r.Delete("/api/assignments/{id}", h.DeleteAssignmentHandler)
+	return r
+}
+
+// serveAs sends the request as the given user, the way RequireAuth leaves
+// the context.
+func serveAs(router http.Handler, userID int, role, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx := context.WithValue(req.Context(), ctxkeys.UserIDKey, userID)
+	ctx = context.WithValue(ctx, ctxkeys.RoleKey, role)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req.WithContext(ctx))
+	return rr
+}
+
+func TestAssignmentHandlers_Permissions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t, "suma")
+	router := newAssignmentRouter(repo)
+
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	otherInstructor := createUser(t, "otro", auth.RoleInstructor)
+	student := createUser(t, "ana", auth.RoleStudent)
+	outsider := createUser(t, "beto", auth.RoleStudent)
+	admin := createUser(t, "admin", auth.RoleAdmin)
+
+	courseID := createCourse(t, "ABCD2345", owner, student)
+	createCourse(t, "EFGH6789", otherInstructor, outsider)
+
+	now := time.Now()
+	open := createAssignment(t, repo, courseID, owner, now.Add(-24*time.Hour), now.Add(24*time.Hour), ids["suma"])
+	unopened := createAssignment(t, repo, courseID, owner, now.Add(24*time.Hour), now.Add(48*time.Hour), ids["suma"])
+
+	coursePath := "/api/courses/" + strconv.Itoa(courseID) + "/assignments"
+	openPath := "/api/assignments/" + strconv.Itoa(open.ID)
+	unopenedPath := "/api/assignments/" + strconv.Itoa(unopened.ID)
+	body := fmt.Sprintf(`{"title": "Tarea 2", "challenge_ids": [%d], "opens_at": %q, "due_at": %q}`,
+		ids["suma"], now.Format(time.RFC3339), now.Add(24*time.Hour).Format(time.RFC3339))
+
+	tests := []struct {
+		name       string
+		userID     int
+		role       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{"student creates an assignment", student, auth.RoleStudent, http.MethodPost, coursePath, body, http.StatusForbidden},
+		{"student lists assignments", student, auth.RoleStudent, http.MethodGet, coursePath, "", http.StatusOK},
+		{"student reads an open assignment", student, auth.RoleStudent, http.MethodGet, openPath, "", http.StatusOK},
+		{"student reads an unopened assignment", student, auth.RoleStudent, http.MethodGet, unopenedPath, "", http.StatusNotFound},
+		{"student reads the report", student, auth.RoleStudent, http.MethodGet, openPath + "/report", "", http.StatusForbidden},
+		{"student deletes an assignment", student, auth.RoleStudent, http.MethodDelete, openPath, "", http.StatusForbidden},
+		{"non-member lists assignments", outsider, auth.RoleStudent, http.MethodGet, coursePath, "", http.StatusNotFound},
+		{"non-member reads an assignment", outsider, auth.RoleStudent, http.MethodGet, openPath, "", http.StatusNotFound},
+		{"instructor of another course creates an assignment", otherInstructor, auth.RoleInstructor, http.MethodPost, coursePath, body, http.StatusForbidden},
+		{"instructor of another course reads the report", otherInstructor, auth.RoleInstructor, http.MethodGet, openPath + "/report", "", http.StatusForbidden},
+		{"instructor of another course deletes an assignment", otherInstructor, auth.RoleInstructor, http.MethodDelete, openPath, "", http.StatusForbidden},
+		{"owner reads an unopened assignment", owner, auth.RoleInstructor, http.MethodGet, unopenedPath, "", http.StatusOK},
+		{"owner reads the report", owner, auth.RoleInstructor, http.MethodGet, openPath + "/report", "", http.StatusOK},
+		{"owner creates an assignment", owner, auth.RoleInstructor, http.MethodPost, coursePath, body, http.StatusCreated},
+		{"admin reads the report without joining", admin, auth.RoleAdmin, http.MethodGet, openPath + "/report", "", http.StatusOK},
+		{"unknown assignment", owner, auth.RoleInstructor, http.MethodGet, "/api/assignments/999999/report", "", http.StatusNotFound},
+		{"owner deletes an assignment", owner, auth.RoleInstructor, http.MethodDelete, openPath, "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serveAs(router, tt.userID, tt.role, tt.method, tt.target, tt.body)
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+		})
+	}
+}
+
+func TestAssignmentHandlers_CreateUnknownChallenge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, _ := setupRepository(t)
+	router := newAssignmentRouter(repo)
+
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	courseID := createCourse(t, "ABCD2345", owner)
+
+	now := time.Now()
+	body := fmt.Sprintf(`{"title": "Tarea 1", "challenge_ids": [999999], "opens_at": %q, "due_at": %q}`,
+		now.Format(time.RFC3339), now.Add(24*time.Hour).Format(time.RFC3339))
+
+	rr := serveAs(router, owner, auth.RoleInstructor, http.MethodPost, "/api/courses/"+strconv.Itoa(courseID)+"/assignments", body)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d; body %s", rr.Code, http.StatusUnprocessableEntity, rr.Body)
+	}
+	if !strings.Contains(rr.Body.String(), "challenge_ids") {
+		t.Errorf("body = %s, want an error for challenge_ids", rr.Body)
+	}
+}
@@ -0,0 +1,76 @@
+package assignments
+
+import (
+	"time"
+
+	"apschool/internal/validator"
+)
+
+// Completion status of a challenge within an assignment. A challenge is late
+// when its first accepted submission came after the due date.
+const (
+	StatusPending = "pending"
+	StatusOnTime  = "on_time"
+	StatusLate    = "late"
+)
+
+type Assignment struct {
+	ID           int                   `json:"id"`
+	CourseID     int                   `json:"course_id"`
+	CourseName   string                `json:"course_name,omitzero"`
+	Title        string                `json:"title"`
+	OpensAt      time.Time             `json:"opens_at"`
+	DueAt        time.Time             `json:"due_at"`
+	ChallengeIDs []int                 `json:"-"`
+	Challenges   []AssignmentChallenge `json:"challenges,omitempty"`
+	Total        int                   `json:"total"`
+	Completed    int                   `json:"completed"`
+	Overdue      bool                  `json:"overdue"`
+	CreatedBy    int                   `json:"created_by"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"-"`
+}
+
+// AssignmentChallenge is one challenge of an assignment, with the caller's
+// status on it.
+type AssignmentChallenge struct {
+	ChallengeID int        `json:"id"`
+	Title       string     `json:"title"`
+	Category    string     `json:"category"`
+	Status      string     `json:"status"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+}
+
+// StudentStatus is one row of the instructor report: a student's status on
+// one challenge of the assignment.
+type StudentStatus struct {
+	UserID      int        `json:"user_id"`
+	Username    string     `json:"username"`
+	ChallengeID int        `json:"challenge_id"`
+	Status      string     `json:"status"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+}
+
+// statusOf classifies a challenge by its first accepted submission.
+func statusOf(firstPass *time.Time, dueAt time.Time) string {
+	switch {
+	case firstPass == nil:
+		return StatusPending
+	case firstPass.After(dueAt):
+		return StatusLate
+	default:
+		return StatusOnTime
+	}
+}
+
+func ValidateAssignment(v *validator.Validator, a *Assignment) {
+	v.Check(validator.NotBlank(a.Title), "title", "is required")
+	v.Check(validator.MaxChars(a.Title, 200), "title", "must not be more than 200 characters long")
+
+	v.Check(!a.OpensAt.IsZero(), "opens_at", "is required")
+	v.Check(!a.DueAt.IsZero(), "due_at", "is required")
+	v.Check(a.DueAt.After(a.OpensAt), "due_at", "must be after opens_at")
+
+	v.Check(len(a.ChallengeIDs) > 0, "challenge_ids", "must contain at least one challenge")
+	v.Check(validator.Unique(a.ChallengeIDs), "challenge_ids", "must not contain duplicates")
+}
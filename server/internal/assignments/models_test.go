@@ -0,0 +1,31 @@
+package assignments
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusOf(t *testing.T) {
+	due := time.Date(2025, 3, 10, 23, 59, 0, 0, time.UTC)
+	before := due.Add(-time.Hour)
+	after := due.Add(time.Second)
+
+	tests := []struct {
+		name      string
+		firstPass *time.Time
+		want      string
+	}{
+		{"never solved", nil, StatusPending},
+		{"solved before the deadline", &before, StatusOnTime},
+		{"solved exactly at the deadline", &due, StatusOnTime},
+		{"solved after the deadline", &after, StatusLate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusOf(tt.firstPass, due); got != tt.want {
+				t.Errorf("statusOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
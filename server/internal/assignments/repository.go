@@ -0,0 +1,251 @@
+package assignments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Insert(ctx context.Context, a *Assignment) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO assignments (course_id, title, opens_at, due_at, created_by)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, a.CourseID, a.Title, a.OpensAt, a.DueAt, a.CreatedBy).Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO assignment_challenges (assignment_id, challenge_id, position) VALUES ($1, $2, $3)`
+
+	for i, challengeID := range a.ChallengeIDs {
+		if _, err := tx.ExecContext(ctx, query, a.ID, challengeID, i); err != nil {
+			return translateError(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*Assignment, error) {
+
+	query := `SELECT a.id, a.course_id, c.name, a.title, a.opens_at, a.due_at, a.created_by, a.created_at, a.updated_at
+	FROM assignments a
+	JOIN courses c ON c.id = a.course_id
+	WHERE a.id = $1`
+
+	var a Assignment
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&a.ID,
+		&a.CourseID,
+		&a.CourseName,
+		&a.Title,
+		&a.OpensAt,
+		&a.DueAt,
+		&a.CreatedBy,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int) error {
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM assignments WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetChallenges returns the assignment's challenges in order, each with the
+// time of the user's first accepted submission.
+func (r *Repository) GetChallenges(ctx context.Context, assignmentID, userID int, dueAt time.Time) ([]AssignmentChallenge, error) {
+
+	query := `SELECT ch.id, ch.title, ch.category, fp.first_pass
+	FROM assignment_challenges ac
+	JOIN challenges ch ON ch.id = ac.challenge_id
+	LEFT JOIN LATERAL (
+		SELECT min(s.created_at) AS first_pass
+		FROM submissions s
+		WHERE s.user_id = $2 AND s.challenge_id = ac.challenge_id AND s.passed = true
+	) fp ON true
+	WHERE ac.assignment_id = $1
+	ORDER BY ac.position`
+
+	rows, err := r.db.QueryContext(ctx, query, assignmentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	challenges := []AssignmentChallenge{}
+	for rows.Next() {
+		var c AssignmentChallenge
+		if err := rows.Scan(&c.ChallengeID, &c.Title, &c.Category, &c.SubmittedAt); err != nil {
+			return nil, err
+		}
+		c.Status = statusOf(c.SubmittedAt, dueAt)
+		challenges = append(challenges, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return challenges, nil
+}
+
+// ListByCourse returns the course's assignments by due date, with how many of
+// their challenges the user has solved. Unopened assignments are only
+// included when includeUnopened is set.
+func (r *Repository) ListByCourse(ctx context.Context, courseID, userID int, includeUnopened bool) ([]Assignment, error) {
+
+	query := `SELECT a.id, a.course_id, a.title, a.opens_at, a.due_at, a.created_by, a.created_at, a.updated_at,
+		count(ac.challenge_id) AS total,
+		count(ac.challenge_id) FILTER (WHERE EXISTS(
+			SELECT 1 FROM submissions s
+			WHERE s.user_id = $2 AND s.challenge_id = ac.challenge_id AND s.passed = true
+		)) AS completed
+	FROM assignments a
+	LEFT JOIN assignment_challenges ac ON ac.assignment_id = a.id
+	WHERE a.course_id = $1 AND ($3 OR a.opens_at <= NOW())
+	GROUP BY a.id
+	ORDER BY a.due_at, a.id`
+
+	return r.list(ctx, query, false, courseID, userID, includeUnopened)
+}
+
+// ListPending returns the open assignments the user still has challenges to
+// solve in, across every course they study in, by due date. Overdue
+// assignments stay listed since late work is still accepted.
+func (r *Repository) ListPending(ctx context.Context, userID int) ([]Assignment, error) {
+
+	query := `SELECT a.id, a.course_id, c.name, a.title, a.opens_at, a.due_at, a.created_by, a.created_at, a.updated_at,
+		count(ac.challenge_id) AS total,
+		count(ac.challenge_id) FILTER (WHERE EXISTS(
+			SELECT 1 FROM submissions s
+			WHERE s.user_id = $1 AND s.challenge_id = ac.challenge_id AND s.passed = true
+		)) AS completed
+	FROM assignments a
+	JOIN courses c ON c.id = a.course_id
+	JOIN course_members m ON m.course_id = a.course_id AND m.user_id = $1 AND m.role = 'student'
+	JOIN assignment_challenges ac ON ac.assignment_id = a.id
+	WHERE a.opens_at <= NOW()
+	GROUP BY a.id, c.name
+	HAVING count(ac.challenge_id) FILTER (WHERE EXISTS(
+		SELECT 1 FROM submissions s
+		WHERE s.user_id = $1 AND s.challenge_id = ac.challenge_id AND s.passed = true
+	)) < count(ac.challenge_id)
+	ORDER BY a.due_at, a.id`
+
+	return r.list(ctx, query, true, userID)
+}
+
+func (r *Repository) list(ctx context.Context, query string, withCourseName bool, args ...any) ([]Assignment, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []Assignment{}
+	for rows.Next() {
+		var a Assignment
+		dest := []any{&a.ID, &a.CourseID}
+		if withCourseName {
+			dest = append(dest, &a.CourseName)
+		}
+		dest = append(dest, &a.Title, &a.OpensAt, &a.DueAt, &a.CreatedBy, &a.CreatedAt, &a.UpdatedAt, &a.Total, &a.Completed)
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+// Report returns every student's status on every challenge of the
+// assignment, ordered by student then challenge.
+func (r *Repository) Report(ctx context.Context, assignmentID int, dueAt time.Time) ([]StudentStatus, error) {
+
+	query := `SELECT u.id, u.username, ac.challenge_id, fp.first_pass
+	FROM assignments a
+	JOIN course_members m ON m.course_id = a.course_id AND m.role = 'student'
+	JOIN users u ON u.id = m.user_id
+	JOIN assignment_challenges ac ON ac.assignment_id = a.id
+	LEFT JOIN LATERAL (
+		SELECT min(s.created_at) AS first_pass
+		FROM submissions s
+		WHERE s.user_id = m.user_id AND s.challenge_id = ac.challenge_id AND s.passed = true
+	) fp ON true
+	WHERE a.id = $1
+	ORDER BY u.username, u.id, ac.position`
+
+	rows, err := r.db.QueryContext(ctx, query, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := []StudentStatus{}
+	for rows.Next() {
+		var s StudentStatus
+		if err := rows.Scan(&s.UserID, &s.Username, &s.ChallengeID, &s.SubmittedAt); err != nil {
+			return nil, err
+		}
+		s.Status = statusOf(s.SubmittedAt, dueAt)
+		statuses = append(statuses, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" && pgErr.ConstraintName == "assignment_challenges_challenge_id_fkey" {
+		return ErrUnknownChallenge
+	}
+	return err
+}
@@ -0,0 +1,353 @@
+package assignments
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"apschool/internal/auth"
+	"apschool/internal/courses"
+	"apschool/internal/testutil"
+)
+
+var testDB *testutil.TestDB
+
+func TestMain(m *testing.M) {
+	// Parse flags first so we can check -short
+	flag.Parse()
+
+	// Skip container setup entirely in short mode (for CI without Docker)
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	ctx := context.Background()
+
+	var err error
+	testDB, err = testutil.SetupTestDB(ctx)
+	if err != nil {
+		panic("failed to setup test db: " + err.Error())
+	}
+
+	code := m.Run()
+
+	testDB.Teardown(ctx)
+	os.Exit(code)
+}
+
+// setupRepository empties users, which courses and their assignments cascade
+// from, and the catalog, then creates a unit with the given challenges and
+// returns their ids by slug.
+func setupRepository(t *testing.T, challenges ...string) (*Repository, map[string]int) {
+	t.Helper()
+
+	testDB.TruncateTables(t)
+	if _, err := testDB.DB.Exec("TRUNCATE challenges, categories CASCADE"); err != nil {
+		t.Fatalf("failed to truncate challenges: %v", err)
+	}
+	if _, err := testDB.DB.Exec(`INSERT INTO categories (slug, title) VALUES ('unit-1', 'Unidad 1')`); err != nil {
+		t.Fatalf("failed to create unit: %v", err)
+	}
+
+	ids := make(map[string]int)
+	for _, slug := range challenges {
+		var id int
+		err := testDB.DB.QueryRow(`INSERT INTO challenges (slug, category, title, description, template, test_code)
+		VALUES ($1, 'unit-1', $2, '', '', 'assert True') RETURNING id`, slug, slug).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create challenge %s: %v", slug, err)
+		}
+		ids[slug] = id
+	}
+
+	return NewRepository(testDB.DB), ids
+}
+
+// createUser inserts a user with the given global role and returns their id.
+func createUser(t *testing.T, username, role string) int {
+	t.Helper()
+
+	var id int
+	err := testDB.DB.QueryRow(`INSERT INTO users (username, email, role) VALUES ($1, $2, $3) RETURNING id`,
+		username, username+"@example.com", role).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create user %s: %v", username, err)
+	}
+	return id
+}
+
+// createCourse creates a course taught by ownerID with the given students.
+func createCourse(t *testing.T, code string, ownerID int, students ...int) int {
+	t.Helper()
+
+	repo := courses.NewRepository(testDB.DB)
+	course := &courses.Course{Name: "Fundamentos " + code, JoinCode: code, OwnerID: ownerID, Units: []string{"unit-1"}}
+	if err := repo.Insert(context.Background(), course); err != nil {
+		t.Fatalf("failed to create course %s: %v", code, err)
+	}
+	for _, id := range students {
+		if err := repo.AddMember(context.Background(), course.ID, id, courses.MemberStudent); err != nil {
+			t.Fatalf("failed to enroll student %d: %v", id, err)
+		}
+	}
+	return course.ID
+}
+
+func createAssignment(t *testing.T, repo *Repository, courseID, createdBy int, opensAt, dueAt time.Time, challengeIDs ...int) *Assignment {
+	t.Helper()
+
+	a := &Assignment{CourseID: courseID, Title: "Tarea", OpensAt: opensAt, DueAt: dueAt, ChallengeIDs: challengeIDs, CreatedBy: createdBy}
+	if err := repo.Insert(context.Background(), a); err != nil {
+		t.Fatalf("failed to create assignment: %v", err)
+	}
+	return a
+}
+
+func submit(t *testing.T, userID, challengeID int, passed bool, at time.Time) {
+	t.Helper()
+
+	status := "failed"
+	if passed {
+		status = "passed"
+	}
+	_, err := testDB.DB.Exec(`INSERT INTO submissions (user_id, challenge_id, code, passed, status, created_at) VALUES ($1, $2, 'x', $3, $4, $5)`,
+		userID, challengeID, passed, status, at)
+	if err != nil {
+		t.Fatalf("failed to create submission: %v", err)
+	}
+}
+
+func TestRepository_Insert(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t, "suma", "resta")
+	ctx := context.Background()
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	courseID := createCourse(t, "ABCD2345", owner)
+	opensAt := time.Now().Add(-time.Hour)
+
+	a := createAssignment(t, repo, courseID, owner, opensAt, opensAt.Add(48*time.Hour), ids["resta"], ids["suma"])
+
+	challenges, err := repo.GetChallenges(ctx, a.ID, owner, a.DueAt)
+	if err != nil {
+		t.Fatalf("GetChallenges() error = %v", err)
+	}
+	got := []int{}
+	for _, c := range challenges {
+		got = append(got, c.ChallengeID)
+	}
+	if want := []int{ids["resta"], ids["suma"]}; !slices.Equal(got, want) {
+		t.Errorf("challenges = %v, want %v in the given order", got, want)
+	}
+
+	unknown := &Assignment{CourseID: courseID, Title: "Otra", OpensAt: opensAt, DueAt: opensAt.Add(time.Hour), ChallengeIDs: []int{ids["suma"], 999999}, CreatedBy: owner}
+	if err := repo.Insert(ctx, unknown); !errors.Is(err, ErrUnknownChallenge) {
+		t.Errorf("Insert() with an unknown challenge error = %v, want %v", err, ErrUnknownChallenge)
+	}
+
+	var count int
+	if err := testDB.DB.QueryRow(`SELECT count(*) FROM assignments`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("%d assignments stored, want the failed one rolled back", count)
+	}
+}
+
+func TestRepository_GetChallenges(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t, "suma", "resta", "producto", "division")
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	ana := createUser(t, "ana", auth.RoleStudent)
+	courseID := createCourse(t, "ABCD2345", owner, ana)
+
+	dueAt := time.Date(2025, 3, 10, 23, 59, 0, 0, time.UTC)
+	a := createAssignment(t, repo, courseID, owner, dueAt.Add(-7*24*time.Hour), dueAt,
+		ids["suma"], ids["resta"], ids["producto"], ids["division"])
+
+	onTime := dueAt.Add(-time.Hour)
+	late := dueAt.Add(time.Hour)
+
+	// Passed before the deadline, then again after it
+	submit(t, ana, ids["suma"], true, onTime)
+	submit(t, ana, ids["suma"], true, late.Add(time.Hour))
+	// Failed before the deadline and only passed after it
+	submit(t, ana, ids["resta"], false, onTime)
+	submit(t, ana, ids["resta"], true, late)
+	// Never passed
+	submit(t, ana, ids["producto"], false, onTime)
+
+	challenges, err := repo.GetChallenges(context.Background(), a.ID, ana, a.DueAt)
+	if err != nil {
+		t.Fatalf("GetChallenges() error = %v", err)
+	}
+
+	want := []struct {
+		status      string
+		submittedAt *time.Time
+	}{
+		{StatusOnTime, &onTime},
+		{StatusLate, &late},
+		{StatusPending, nil},
+		{StatusPending, nil},
+	}
+	if len(challenges) != len(want) {
+		t.Fatalf("GetChallenges() = %+v, want %d challenges", challenges, len(want))
+	}
+	for i, c := range challenges {
+		if c.Status != want[i].status {
+			t.Errorf("%s status = %s, want %s", c.Title, c.Status, want[i].status)
+		}
+		if (c.SubmittedAt == nil) != (want[i].submittedAt == nil) || (c.SubmittedAt != nil && !c.SubmittedAt.Equal(*want[i].submittedAt)) {
+			t.Errorf("%s submitted_at = %v, want %v", c.Title, c.SubmittedAt, want[i].submittedAt)
+		}
+	}
+}
+
+func TestRepository_ListByCourse(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t, "suma", "resta")
+	ctx := context.Background()
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	ana := createUser(t, "ana", auth.RoleStudent)
+	courseID := createCourse(t, "ABCD2345", owner, ana)
+	otherCourse := createCourse(t, "EFGH6789", owner)
+	now := time.Now()
+
+	open := createAssignment(t, repo, courseID, owner, now.Add(-24*time.Hour), now.Add(24*time.Hour), ids["suma"], ids["resta"])
+	unopened := createAssignment(t, repo, courseID, owner, now.Add(24*time.Hour), now.Add(48*time.Hour), ids["suma"])
+	createAssignment(t, repo, otherCourse, owner, now.Add(-24*time.Hour), now.Add(24*time.Hour), ids["suma"])
+
+	submit(t, ana, ids["suma"], true, now.Add(-time.Hour))
+	submit(t, ana, ids["resta"], false, now.Add(-time.Hour))
+
+	tests := []struct {
+		name            string
+		includeUnopened bool
+		want            []int
+	}{
+		{name: "student view", includeUnopened: false, want: []int{open.ID}},
+		{name: "instructor view", includeUnopened: true, want: []int{open.ID, unopened.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignments, err := repo.ListByCourse(ctx, courseID, ana, tt.includeUnopened)
+			if err != nil {
+				t.Fatalf("ListByCourse() error = %v", err)
+			}
+
+			got := []int{}
+			for _, a := range assignments {
+				got = append(got, a.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("ListByCourse() = %v, want %v by due date", got, tt.want)
+			}
+			if assignments[0].Total != 2 || assignments[0].Completed != 1 {
+				t.Errorf("open assignment has %d of %d completed, want 1 of 2", assignments[0].Completed, assignments[0].Total)
+			}
+		})
+	}
+}
+
+func TestRepository_ListPending(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t, "suma", "resta")
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	ana := createUser(t, "ana", auth.RoleStudent)
+	courseID := createCourse(t, "ABCD2345", owner, ana)
+	otherCourse := createCourse(t, "EFGH6789", owner)
+	now := time.Now()
+
+	submit(t, ana, ids["suma"], true, now.Add(-time.Hour))
+
+	overdue := createAssignment(t, repo, courseID, owner, now.Add(-48*time.Hour), now.Add(-24*time.Hour), ids["resta"])
+	pending := createAssignment(t, repo, courseID, owner, now.Add(-24*time.Hour), now.Add(24*time.Hour), ids["suma"], ids["resta"])
+	// Solved, not open yet, and from a course ana isn't in
+	createAssignment(t, repo, courseID, owner, now.Add(-24*time.Hour), now.Add(24*time.Hour), ids["suma"])
+	createAssignment(t, repo, courseID, owner, now.Add(24*time.Hour), now.Add(48*time.Hour), ids["resta"])
+	createAssignment(t, repo, otherCourse, owner, now.Add(-24*time.Hour), now.Add(24*time.Hour), ids["resta"])
+
+	assignments, err := repo.ListPending(context.Background(), ana)
+	if err != nil {
+		t.Fatalf("ListPending() error = %v", err)
+	}
+
+	got := []int{}
+	for _, a := range assignments {
+		got = append(got, a.ID)
+		if a.CourseName != "Fundamentos ABCD2345" {
+			t.Errorf("assignment %d course name = %q, want Fundamentos ABCD2345", a.ID, a.CourseName)
+		}
+	}
+	if want := []int{overdue.ID, pending.ID}; !slices.Equal(got, want) {
+		t.Errorf("ListPending() = %v, want %v", got, want)
+	}
+
+	// Instructors have nothing pending in the courses they teach
+	if assignments, err := repo.ListPending(context.Background(), owner); err != nil || len(assignments) != 0 {
+		t.Errorf("ListPending(owner) = %+v, %v, want none", assignments, err)
+	}
+}
+
+func TestRepository_Report(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t, "suma", "resta")
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	beto := createUser(t, "beto", auth.RoleStudent)
+	ana := createUser(t, "ana", auth.RoleStudent)
+	carla := createUser(t, "carla", auth.RoleStudent)
+	courseID := createCourse(t, "ABCD2345", owner, beto, ana)
+	createCourse(t, "EFGH6789", owner, carla)
+
+	dueAt := time.Date(2025, 3, 10, 23, 59, 0, 0, time.UTC)
+	a := createAssignment(t, repo, courseID, owner, dueAt.Add(-7*24*time.Hour), dueAt, ids["resta"], ids["suma"])
+
+	submit(t, ana, ids["suma"], true, dueAt.Add(-time.Hour))
+	submit(t, beto, ids["resta"], true, dueAt.Add(time.Hour))
+	submit(t, carla, ids["suma"], true, dueAt.Add(-time.Hour))
+	submit(t, owner, ids["suma"], true, dueAt.Add(-time.Hour))
+
+	statuses, err := repo.Report(context.Background(), a.ID, a.DueAt)
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+
+	type row struct {
+		user      int
+		challenge int
+		status    string
+	}
+	want := []row{
+		{ana, ids["resta"], StatusPending},
+		{ana, ids["suma"], StatusOnTime},
+		{beto, ids["resta"], StatusLate},
+		{beto, ids["suma"], StatusPending},
+	}
+	got := []row{}
+	for _, s := range statuses {
+		got = append(got, row{s.UserID, s.ChallengeID, s.Status})
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Report() = %+v, want %+v", got, want)
+	}
+}
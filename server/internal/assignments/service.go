@@ -0,0 +1,157 @@
+package assignments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"apschool/internal/courses"
+)
+
+var (
+	ErrAssignmentNotFound = errors.New("assignment not found")
+	ErrUnknownChallenge   = errors.New("unknown challenge")
+)
+
+// Service checks course permissions through the courses service: instructors
+// manage a course's assignments, students see them once they open.
+type Service struct {
+	repo    *Repository
+	courses *courses.Service
+}
+
+func NewService(repo *Repository, courses *courses.Service) *Service {
+	return &Service{repo: repo, courses: courses}
+}
+
+func (s *Service) CreateAssignment(ctx context.Context, a *Assignment, role string) error {
+	if err := s.courses.RequireInstructor(ctx, a.CourseID, a.CreatedBy, role); err != nil {
+		return err
+	}
+
+	if err := s.repo.Insert(ctx, a); err != nil {
+		return err
+	}
+
+	a.Total = len(a.ChallengeIDs)
+	return nil
+}
+
+func (s *Service) ListForCourse(ctx context.Context, courseID, userID int, role string) ([]Assignment, error) {
+	course, err := s.courses.GetCourse(ctx, courseID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.repo.ListByCourse(ctx, courseID, userID, course.MemberRole == courses.MemberInstructor)
+	if err != nil {
+		return nil, err
+	}
+
+	markOverdue(assignments, time.Now())
+	return assignments, nil
+}
+
+func (s *Service) ListPending(ctx context.Context, userID int) ([]Assignment, error) {
+	assignments, err := s.repo.ListPending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	markOverdue(assignments, time.Now())
+	return assignments, nil
+}
+
+// GetAssignment returns the assignment with the caller's status on each
+// challenge. Students can't see an assignment before it opens.
+func (s *Service) GetAssignment(ctx context.Context, id, userID int, role string) (*Assignment, error) {
+	assignment, err := s.getAssignment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	memberRole, err := s.courses.MemberRole(ctx, assignment.CourseID, userID, role)
+	if err != nil {
+		if errors.Is(err, courses.ErrNotCourseMember) {
+			return nil, ErrAssignmentNotFound
+		}
+		return nil, err
+	}
+	if memberRole != courses.MemberInstructor && time.Now().Before(assignment.OpensAt) {
+		return nil, ErrAssignmentNotFound
+	}
+
+	assignment.Challenges, err = s.repo.GetChallenges(ctx, id, userID, assignment.DueAt)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment.Total = len(assignment.Challenges)
+	for _, c := range assignment.Challenges {
+		if c.Status != StatusPending {
+			assignment.Completed++
+		}
+	}
+	assignment.Overdue = time.Now().After(assignment.DueAt) && assignment.Completed < assignment.Total
+
+	return assignment, nil
+}
+
+func (s *Service) DeleteAssignment(ctx context.Context, id, userID int, role string) error {
+	assignment, err := s.getAssignment(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.courses.RequireInstructor(ctx, assignment.CourseID, userID, role); err != nil {
+		return err
+	}
+
+	err = s.repo.Delete(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAssignmentNotFound
+	}
+	return err
+}
+
+// Report lists every student's on-time or late status on each challenge, for
+// the course's instructors.
+func (s *Service) Report(ctx context.Context, id, userID int, role string) (*Assignment, []StudentStatus, error) {
+	assignment, err := s.getAssignment(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.courses.RequireInstructor(ctx, assignment.CourseID, userID, role); err != nil {
+		return nil, nil, err
+	}
+
+	statuses, err := s.repo.Report(ctx, id, assignment.DueAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return assignment, statuses, nil
+}
+
+// markOverdue flags assignments past their due date that still have
+// unsolved challenges.
+func markOverdue(assignments []Assignment, now time.Time) {
+	for i := range assignments {
+		a := &assignments[i]
+		a.Overdue = now.After(a.DueAt) && a.Completed < a.Total
+	}
+}
+
+func (s *Service) getAssignment(ctx context.Context, id int) (*Assignment, error) {
+	assignment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAssignmentNotFound
+		}
+		return nil, err
+	}
+
+	return assignment, nil
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"apschool/internal/config"
+)
+
+var (
+	// ErrOAuthRejected means the provider refused the authorization code,
+	// for instance because it expired or was already used.
+	ErrOAuthRejected = errors.New("oauth provider rejected the authorization code")
+	// ErrNoVerifiedEmail means the account has no primary email the provider
+	// has verified, so it can't be matched to a user.
+	ErrNoVerifiedEmail = errors.New("no primary verified email found")
+)
+
+// OAuthProvider is the identity provider behind the OAuth login. GitHubClient
+// talks to GitHub; tests use it against the fake in githubtest.
+type OAuthProvider interface {
+	// AuthCodeURL is where the browser is sent to approve the login.
+	AuthCodeURL(state, codeChallenge string) string
+	// Exchange trades the code from the callback for an access token.
+	Exchange(ctx context.Context, code, codeVerifier string) (string, error)
+	// Identity returns the account that approved the login.
+	Identity(ctx context.Context, accessToken string) (*OAuthIdentity, error)
+}
+
+type OAuthIdentity struct {
+	ProviderID int
+	Username   string
+	Email      string
+	AvatarURL  string
+}
+
+// ProviderError is an unexpected response from the provider.
+type ProviderError struct {
+	Endpoint string
+	Status   int
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s answered with status %d", e.Endpoint, e.Status)
+}
+
+type githubAccessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Error       string `json:"error"`
+}
+
+type githubUser struct {
+	ID        int    `json:"id"`
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type GitHubClient struct {
+	clientID     string
+	clientSecret string
+	redirectURI  string
+	authURL      string
+	apiURL       string
+	http         *http.Client
+}
+
+// NewGitHubClient creates the GitHub provider. The base URLs come from the
+// config so GitHub Enterprise, or a fake, can stand in for github.com.
+func NewGitHubClient(cfg config.GitHub, client *http.Client) *GitHubClient {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &GitHubClient{
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURI:  cfg.RedirectURI,
+		authURL:      strings.TrimSuffix(cfg.AuthURL, "/"),
+		apiURL:       strings.TrimSuffix(cfg.APIURL, "/"),
+		http:         client,
+	}
+}
+
+func (c *GitHubClient) AuthCodeURL(state, codeChallenge string) string {
+	params := url.Values{}
+	params.Set("client_id", c.clientID)
+	params.Set("redirect_uri", c.redirectURI)
+	params.Set("scope", "user:email")
+	params.Set("state", state)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	return c.authURL + "/login/oauth/authorize?" + params.Encode()
+}
+
+func (c *GitHubClient) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	body, err := json.Marshal(map[string]string{
+		"client_id":     c.clientID,
+		"client_secret": c.clientSecret,
+		"code":          code,
+		"code_verifier": codeVerifier,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.authURL+"/login/oauth/access_token", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	var tokenResp githubAccessTokenResponse
+	if err := c.do(req, "github token endpoint", &tokenResp); err != nil {
+		return "", err
+	}
+
+	// GitHub reports a bad code or verifier with a 200 and an error field
+	if tokenResp.Error != "" {
+		return "", fmt.Errorf("%w: %s", ErrOAuthRejected, tokenResp.Error)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", errors.New("github token endpoint returned no access token")
+	}
+
+	return tokenResp.AccessToken, nil
+}
+
+func (c *GitHubClient) Identity(ctx context.Context, accessToken string) (*OAuthIdentity, error) {
+	var user githubUser
+	if err := c.get(ctx, "/user", accessToken, &user); err != nil {
+		return nil, err
+	}
+
+	var emails []GithubEmail
+	if err := c.get(ctx, "/user/emails", accessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	for _, email := range emails {
+		if email.Primary && email.Verified {
+			return &OAuthIdentity{
+				ProviderID: user.ID,
+				Username:   user.Login,
+				Email:      email.Email,
+				AvatarURL:  user.AvatarURL,
+			}, nil
+		}
+	}
+
+	return nil, ErrNoVerifiedEmail
+}
+
+func (c *GitHubClient) get(ctx context.Context, path, accessToken string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	return c.do(req, "github "+path, dst)
+}
+
+// do sends the request and decodes a successful JSON response into dst.
+func (c *GitHubClient) do(req *http.Request, endpoint string, dst any) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Drain so the connection can be reused
+		io.Copy(io.Discard, resp.Body)
+		return &ProviderError{Endpoint: endpoint, Status: resp.StatusCode}
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
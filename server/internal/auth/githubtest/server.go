@@ -0,0 +1,265 @@
+// Package githubtest is an in-process stand-in for GitHub's OAuth and REST
+// endpoints, so the login flow can be tested without the network.
+package githubtest
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"apschool/internal/config"
+)
+
+type Email struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// Account is the GitHub user who approves the next logins.
+type Account struct {
+	ID        int
+	Login     string
+	AvatarURL string
+	Emails    []Email
+}
+
+// Server answers the endpoints GitHubClient calls, both the OAuth ones
+// normally on github.com and the API ones on api.github.com:
+//
+//	GET  /login/oauth/authorize
+//	POST /login/oauth/access_token
+//	GET  /user
+//	GET  /user/emails
+//
+// Codes are single use and checked against the PKCE challenge, like GitHub
+// does.
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	mu       sync.Mutex
+	account  Account
+	deny     bool
+	failures map[string]int
+	grants   map[string]string // code to PKCE challenge
+	tokens   map[string]Account
+}
+
+// NewServer starts a fake GitHub that accepts the given OAuth app
+// credentials. Callers must Close it.
+func NewServer(clientID, clientSecret string) *Server {
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		failures:     make(map[string]int),
+		grants:       make(map[string]string),
+		tokens:       make(map[string]Account),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /login/oauth/authorize", s.authorize)
+	mux.HandleFunc("POST /login/oauth/access_token", s.accessToken)
+	mux.HandleFunc("GET /user", s.user)
+	mux.HandleFunc("GET /user/emails", s.emails)
+
+	s.Server = httptest.NewServer(s.failing(mux))
+	return s
+}
+
+// Config returns GitHub settings pointing at the fake.
+func (s *Server) Config(redirectURI string) config.GitHub {
+	return config.GitHub{
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		RedirectURI:  redirectURI,
+		AuthURL:      s.URL,
+		APIURL:       s.URL,
+	}
+}
+
+// SignInAs sets the account that approves the following authorizations.
+func (s *Server) SignInAs(account Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.account = account
+}
+
+// Deny makes the user turn down the following authorizations, which
+// redirects back with error=access_denied.
+func (s *Server) Deny() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deny = true
+}
+
+// FailWith makes every request to path answer with status, as GitHub does
+// when it is down or the token was revoked.
+func (s *Server) FailWith(path string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = status
+}
+
+func (s *Server) failing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		status, ok := s.failures[r.URL.Path]
+		s.mu.Unlock()
+
+		if ok {
+			writeJSON(w, status, map[string]string{"message": http.StatusText(status)})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "redirect_uri is invalid", http.StatusBadRequest)
+		return
+	}
+	if query.Get("client_id") != s.ClientID {
+		http.Error(w, "unknown client_id", http.StatusNotFound)
+		return
+	}
+	if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "code_challenge with method S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	params := url.Values{"state": {query.Get("state")}}
+
+	s.mu.Lock()
+	if s.deny {
+		params.Set("error", "access_denied")
+	} else {
+		code := randomString()
+		s.grants[code] = query.Get("code_challenge")
+		params.Set("code", code)
+	}
+	s.mu.Unlock()
+
+	redirectURI.RawQuery = params.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (s *Server) accessToken(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		Code         string `json:"code"`
+		CodeVerifier string `json:"code_verifier"`
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		input.ClientID = r.PostForm.Get("client_id")
+		input.ClientSecret = r.PostForm.Get("client_secret")
+		input.Code = r.PostForm.Get("code")
+		input.CodeVerifier = r.PostForm.Get("code_verifier")
+	}
+
+	// GitHub reports these with a 200 and an error field
+	if input.ClientID != s.ClientID || input.ClientSecret != s.ClientSecret {
+		writeJSON(w, http.StatusOK, map[string]string{"error": "incorrect_client_credentials"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	challenge, ok := s.grants[input.Code]
+	delete(s.grants, input.Code)
+	if !ok || !verifyChallenge(input.CodeVerifier, challenge) {
+		writeJSON(w, http.StatusOK, map[string]string{"error": "bad_verification_code"})
+		return
+	}
+
+	token := randomString()
+	s.tokens[token] = s.account
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": token,
+		"token_type":   "bearer",
+		"scope":        "user:email",
+	})
+}
+
+func (s *Server) user(w http.ResponseWriter, r *http.Request) {
+	account, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":         account.ID,
+		"login":      account.Login,
+		"avatar_url": account.AvatarURL,
+	})
+}
+
+func (s *Server) emails(w http.ResponseWriter, r *http.Request) {
+	account, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	emails := account.Emails
+	if emails == nil {
+		emails = []Email{}
+	}
+	writeJSON(w, http.StatusOK, emails)
+}
+
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (Account, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	account, known := s.tokens[token]
+	s.mu.Unlock()
+
+	if !ok || !known {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+		return Account{}, false
+	}
+	return account, true
+}
+
+func verifyChallenge(verifier, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	want := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(want), []byte(challenge)) == 1
+}
+
+func randomString() string {
+	b := make([]byte, 20)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
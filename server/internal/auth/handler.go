@@ -1,198 +1,471 @@
 package auth
 
 import (
+	"apschool/internal/config"
 	"apschool/internal/ctxkeys"
 	"apschool/internal/response"
-	"bytes"
-	"context"
-	"encoding/json"
+	"apschool/internal/validator"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
-)
 
-var (
-	httpClient = &http.Client{Timeout: 10 * time.Second}
+	"github.com/go-chi/chi/v5"
 )
 
 type Handler struct {
 	service *Service
+	tokens  *TokenService
+	github  OAuthProvider
+	config  *config.Config
 	logger  *slog.Logger
 }
 
-func NewHandler(service *Service, logger *slog.Logger) *Handler {
-	return &Handler{service: service, logger: logger}
-}
-
-type githubAccessTokenResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-}
-
-type githubUser struct {
-	ID        int    `json:"id"`
-	Login     string `json:"login"`
-	AvatarURL string `json:"avatar_url"`
+// NewHandler creates the auth handler. github may be nil when GitHub login
+// is not configured, its endpoints then answer 404.
+func NewHandler(service *Service, tokens *TokenService, github OAuthProvider, cfg *config.Config, logger *slog.Logger) *Handler {
+	return &Handler{service: service, tokens: tokens, github: github, config: cfg, logger: logger}
 }
 
 func (h *Handler) GithubLogin(w http.ResponseWriter, r *http.Request) {
-	clientID := os.Getenv("GITHUB_CLIENT_ID")
-	redirectURI := os.Getenv("GITHUB_REDIRECT_URI")
+	if h.github == nil {
+		response.NotFound(w)
+		return
+	}
 
-	url := fmt.Sprintf(
-		"https://github.com/login/oauth/authorize?client_id=%s&redirect_uri=%s&scope=user:email",
-		clientID,
-		redirectURI,
-	)
+	state, err := newOAuthState()
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
 
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+	cookieValue, err := state.encode([]byte(h.config.JWTSecret))
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	// SameSite=Lax so the cookie survives the top-level redirect back from GitHub
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    cookieValue,
+		Path:     "/api/auth/github",
+		MaxAge:   int(oauthStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.config.GitHub.RedirectURI, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, h.github.AuthCodeURL(state.State, pkceChallenge(state.Verifier)), http.StatusTemporaryRedirect)
 }
 
 func (h *Handler) GithubCallback(w http.ResponseWriter, r *http.Request) {
-	code := r.URL.Query().Get("code")
+	if h.github == nil {
+		response.NotFound(w)
+		return
+	}
+
+	// The state cookie is single use
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    "",
+		Path:     "/api/auth/github",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		response.BadRequest(w, fmt.Sprintf("github authorization failed: %s", errCode))
+		return
+	}
+
+	code := query.Get("code")
 	if code == "" {
 		response.BadRequest(w, "code not found")
 		return
 	}
 
-	accessToken, err := exchangeCodeForToken(r.Context(), code)
+	stateParam := query.Get("state")
+	if stateParam == "" {
+		response.BadRequest(w, "state parameter is missing")
+		return
+	}
+
+	cookie, err := r.Cookie(oauthStateCookie)
+	if err != nil {
+		response.BadRequest(w, "oauth state cookie is missing, please start the login again")
+		return
+	}
+
+	state, err := decodeOAuthState(cookie.Value, []byte(h.config.JWTSecret), time.Now())
+	if err != nil {
+		response.BadRequest(w, "oauth state is invalid or expired, please start the login again")
+		return
+	}
+
+	if err := state.verify(stateParam); err != nil {
+		response.BadRequest(w, "oauth state does not match")
+		return
+	}
+
+	accessToken, err := h.github.Exchange(r.Context(), code, state.Verifier)
+	if err != nil {
+		h.providerError(w, r, err)
+		return
+	}
+
+	identity, err := h.github.Identity(r.Context(), accessToken)
 	if err != nil {
+		h.providerError(w, r, err)
+		return
+	}
+
+	user, err := h.service.CreateUserByGithub(r.Context(), identity.ProviderID, identity.Username, identity.Email, identity.AvatarURL)
+	if err != nil {
+		if errors.Is(err, ErrDuplicateEmail) {
+			response.ErrorResponse(w, http.StatusConflict, "an account with your github email already exists, please log in with your password")
+			return
+		}
 		response.ServerError(w, r, h.logger, err)
 		return
 	}
 
-	ghUser, err := getGithubUser(r.Context(), accessToken)
+	authCode, err := h.tokens.IssueAuthCode(r.Context(), user.ID)
 	if err != nil {
 		response.ServerError(w, r, h.logger, err)
 		return
 	}
 
-	email, err := getGithubEmail(r.Context(), accessToken)
+	// The SPA trades the code for tokens with POST /api/auth/exchange
+	http.Redirect(w, r, fmt.Sprintf("%s/auth/callback?code=%s", h.config.FrontendURL, url.QueryEscape(authCode)), http.StatusTemporaryRedirect)
+}
+
+// providerError answers for a failed call to GitHub: the user's fault is a
+// 4xx, GitHub misbehaving is a 502.
+func (h *Handler) providerError(w http.ResponseWriter, r *http.Request, err error) {
+	var providerErr *ProviderError
+	switch {
+	case errors.Is(err, ErrOAuthRejected):
+		response.BadRequest(w, "github rejected the authorization code, please start the login again")
+	case errors.Is(err, ErrNoVerifiedEmail):
+		response.ErrorResponse(w, http.StatusForbidden, "your github account needs a verified primary email")
+	case errors.As(err, &providerErr):
+		h.logger.Error("github request failed", "endpoint", providerErr.Endpoint, "status", providerErr.Status)
+		response.ErrorResponse(w, http.StatusBadGateway, "github is not available, please try again later")
+	default:
+		response.ServerError(w, r, h.logger, err)
+	}
+}
+
+func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+
+	user, err := h.service.GetUserByID(r.Context(), userID)
 	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			response.NotFound(w)
+			return
+		}
 		response.ServerError(w, r, h.logger, err)
 		return
 	}
 
-	user, err := h.service.CreateUserByGithub(r.Context(), ghUser.ID, ghUser.Login, email, ghUser.AvatarURL)
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"user": user}, nil)
+}
+
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
+	input.Username = strings.TrimSpace(input.Username)
+
+	v := validator.New()
+	v.Check(validator.NotBlank(input.Username), "username", "is required")
+	v.Check(validator.MaxChars(input.Username, 50), "username", "must not be more than 50 characters long")
+	validateEmail(v, input.Email)
+	validatePassword(v, input.Password)
+
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	user, err := h.service.RegisterByEmail(r.Context(), input.Username, input.Email, input.Password)
 	if err != nil {
+		if errors.Is(err, ErrDuplicateEmail) {
+			v.AddError("email", "a user with this email address already exists")
+			response.ValidationError(w, v.Errors)
+			return
+		}
 		response.ServerError(w, r, h.logger, err)
 		return
 	}
 
-	token, err := GenerateJWT(user.ID)
+	tokens, err := h.tokens.Issue(r.Context(), user.ID)
 	if err != nil {
 		response.ServerError(w, r, h.logger, err)
 		return
 	}
 
-	frontendURL := os.Getenv("FRONTEND_URL")
-	http.Redirect(w, r, fmt.Sprintf("%s/auth/callback?token=%s", frontendURL, token), http.StatusTemporaryRedirect)
+	response.WriteJSON(w, http.StatusCreated, response.Envelope{"tokens": tokens, "user": user}, nil)
 }
 
-func exchangeCodeForToken(ctx context.Context, code string) (string, error) {
-	clientID := os.Getenv("GITHUB_CLIENT_ID")
-	clientSecret := os.Getenv("GITHUB_CLIENT_SECRET")
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
 
-	body := map[string]string{
-		"client_id":     clientID,
-		"client_secret": clientSecret,
-		"code":          code,
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
 	}
 
-	jsonBody, _ := json.Marshal(body)
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://github.com/login/oauth/access_token", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", err
+	v := validator.New()
+	validateEmail(v, input.Email)
+	v.Check(validator.NotBlank(input.Password), "password", "is required")
+
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := httpClient.Do(req)
+	user, err := h.service.LoginByEmail(r.Context(), input.Email, input.Password)
 	if err != nil {
-		return "", err
+		if errors.Is(err, ErrInvalidCredentials) {
+			response.InvalidCredentials(w)
+			return
+		}
+		response.ServerError(w, r, h.logger, err)
+		return
 	}
-	defer resp.Body.Close()
 
-	var tokenResp githubAccessTokenResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return "", err
+	tokens, err := h.tokens.Issue(r.Context(), user.ID)
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
 	}
 
-	return tokenResp.AccessToken, nil
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"tokens": tokens, "user": user}, nil)
 }
 
-func getGithubUser(ctx context.Context, accessToken string) (*githubUser, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.github.com/user", nil)
-	if err != nil {
-		return nil, err
+func (h *Handler) Exchange(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Code string `json:"code"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
 	}
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
+	v := validator.New()
+	v.Check(validator.NotBlank(input.Code), "code", "is required")
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
 	}
 
-	defer resp.Body.Close()
+	userID, tokens, err := h.tokens.ExchangeAuthCode(r.Context(), input.Code)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAuthCode) {
+			response.BadRequest(w, "invalid or expired authorization code")
+			return
+		}
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
 
-	var user githubUser
-	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return nil, err
+	user, err := h.service.GetUserByID(r.Context(), userID)
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
 	}
 
-	return &user, nil
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"tokens": tokens, "user": user}, nil)
 }
 
-func getGithubEmail(ctx context.Context, accessToken string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.github.com/user/emails", nil)
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	v := validator.New()
+	v.Check(validator.NotBlank(input.RefreshToken), "refresh_token", "is required")
+
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	tokens, err := h.tokens.Refresh(r.Context(), input.RefreshToken)
 	if err != nil {
-		return "", err
+		if errors.Is(err, ErrRefreshTokenReuse) {
+			h.logger.Warn("refresh token reuse detected, token family revoked", "remote_addr", r.RemoteAddr)
+			response.InvalidAuthenticationToken(w)
+			return
+		}
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			response.InvalidAuthenticationToken(w)
+			return
+		}
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"tokens": tokens}, nil)
+}
+
+// StreamTicket issues a ticket for one streaming endpoint, to be passed as
+// ?ticket= by clients that can't set the Authorization header.
+func (h *Handler) StreamTicket(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+	role, _ := ctxkeys.GetRole(r.Context())
+
+	var input struct {
+		Path string `json:"path"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
 	}
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
+	v := validator.New()
+	v.Check(strings.HasPrefix(input.Path, "/api/"), "path", "must be an API path")
+	v.Check(validator.MaxChars(input.Path, 200), "path", "must not be more than 200 characters long")
 
-	resp, err := httpClient.Do(req)
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	ticket, expiresAt, err := h.tokens.IssueStreamTicket(userID, role, input.Path)
 	if err != nil {
-		return "", err
+		response.ServerError(w, r, h.logger, err)
+		return
 	}
-	defer resp.Body.Close()
 
-	var emails []GithubEmail
-	if err := json.NewDecoder(resp.Body).Decode(&emails); err != nil {
-		return "", err
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"ticket": ticket, "expires_at": expiresAt}, nil)
+}
+
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+	tokenID, ok := ctxkeys.GetTokenID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
 	}
 
-	for _, email := range emails {
-		if email.Primary && email.Verified {
-			return email.Email, nil
+	// The refresh token is optional so a client that lost it can still log out
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if r.ContentLength != 0 {
+		if err := response.ReadJSON(w, r, &input); err != nil {
+			response.BadRequest(w, err.Error())
+			return
 		}
 	}
 
-	return "", fmt.Errorf("no primary verified email found")
+	err := h.tokens.Logout(r.Context(), userID, tokenID, input.RefreshToken)
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
 
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"message": "logged out"}, nil)
 }
 
-func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
-	userID, ok := ctxkeys.GetUserID(r.Context())
+func (h *Handler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := ctxkeys.GetUserID(r.Context())
 	if !ok {
 		response.Unauthorized(w)
 		return
 	}
 
-	user, err := h.service.GetUserByID(r.Context(), userID)
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		if errors.Is(err, ErrUserNotFound) {
+		response.BadRequest(w, "invalid id")
+		return
+	}
+
+	var input struct {
+		Role string `json:"role"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	v := validator.New()
+	v.Check(validator.PermittedValue(input.Role, Roles...), "role", "must be student, instructor or admin")
+
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	user, err := h.service.UpdateUserRole(r.Context(), actorID, userID, input.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound):
 			response.NotFound(w)
-			return
+		case errors.Is(err, ErrChangeOwnRole):
+			response.BadRequest(w, "you cannot change your own role")
+		default:
+			response.ServerError(w, r, h.logger, err)
 		}
-		response.ServerError(w, r, h.logger, err)
 		return
 	}
 
 	response.WriteJSON(w, http.StatusOK, response.Envelope{"user": user}, nil)
 }
+
+func validateEmail(v *validator.Validator, email string) {
+	v.Check(validator.NotBlank(email), "email", "is required")
+	v.Check(validator.Matches(email, validator.EmailRegex), "email", "must be a valid email address")
+}
+
+// bcrypt ignores everything after 72 bytes
+func validatePassword(v *validator.Validator, password string) {
+	v.Check(validator.NotBlank(password), "password", "is required")
+	v.Check(validator.MinChars(password, 8), "password", "must be at least 8 characters long")
+	v.Check(len(password) <= 72, "password", "must not be more than 72 bytes long")
+}
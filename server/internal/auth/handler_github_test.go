@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"apschool/internal/auth/githubtest"
+	"apschool/internal/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const frontendURL = "http://frontend.test"
+
+type githubLoginTest struct {
+	api     *httptest.Server
+	github  *githubtest.Server
+	client  *http.Client
+	users   map[int]*User
+	created []int // GitHub ids linked to new users
+}
+
+// newGithubLoginTest serves the auth endpoints against a fake GitHub.
+// modify can change the configuration the handler sees.
+func newGithubLoginTest(t *testing.T, modify func(cfg *config.Config)) *githubLoginTest {
+	t.Helper()
+
+	lt := &githubLoginTest{github: githubtest.NewServer("client-id", "client-secret")}
+	t.Cleanup(lt.github.Close)
+
+	router := chi.NewRouter()
+	lt.api = httptest.NewServer(router)
+	t.Cleanup(lt.api.Close)
+
+	cfg := &config.Config{
+		JWTSecret:   string(testSecret),
+		FrontendURL: frontendURL,
+		GitHub:      lt.github.Config(lt.api.URL + "/api/auth/github/callback"),
+	}
+	if modify != nil {
+		modify(cfg)
+	}
+
+	lt.users = make(map[int]*User)
+	users := lt.users
+	repo := &mockRepository{
+		getUserByGithubIDFunc: func(ctx context.Context, githubID int) (*User, error) {
+			return nil, sql.ErrNoRows
+		},
+		createUserFunc: func(ctx context.Context, username, email, avatarURL string) (*User, error) {
+			// Like users_email_key, compares the stored bytes
+			for _, user := range users {
+				if user.Email == email {
+					return nil, ErrDuplicateEmail
+				}
+			}
+			user := &User{ID: len(users) + 1, Username: username, Email: email, AvatarURL: avatarURL, Role: RoleStudent, CreatedAt: time.Now()}
+			users[user.ID] = user
+			return user, nil
+		},
+		createGithubAuthFunc: func(ctx context.Context, userID, githubID int) error {
+			lt.created = append(lt.created, githubID)
+			return nil
+		},
+		getUserByIDFunc: func(ctx context.Context, id int) (*User, error) {
+			if user, ok := users[id]; ok {
+				return user, nil
+			}
+			return nil, sql.ErrNoRows
+		},
+	}
+
+	var github OAuthProvider
+	if cfg.GitHub.Enabled() {
+		github = NewGitHubClient(cfg.GitHub, nil)
+	}
+
+	h := NewHandler(
+		NewService(repo),
+		NewTokenService(newMemoryTokenRepository(), testSecret),
+		github,
+		cfg,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	router.Get("/api/auth/github/login", h.GithubLogin)
+	router.Get("/api/auth/github/callback", h.GithubCallback)
+	router.Post("/api/auth/exchange", h.Exchange)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lt.client = &http.Client{
+		Jar: jar,
+		// Stop once the login lands on the web app
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Host == "frontend.test" {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+
+	return lt
+}
+
+// login follows the whole flow from the login button and returns the last
+// response, the redirect to the web app when it succeeds.
+func (lt *githubLoginTest) login(t *testing.T) *http.Response {
+	t.Helper()
+
+	resp, err := lt.client.Get(lt.api.URL + "/api/auth/github/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func TestGithubCallback(t *testing.T) {
+	lt := newGithubLoginTest(t, nil)
+	lt.github.SignInAs(githubtest.Account{
+		ID:        42,
+		Login:     "octocat",
+		AvatarURL: "https://avatars.example.com/42",
+		Emails: []githubtest.Email{
+			{Email: "old@example.com", Verified: true},
+			{Email: "octocat@example.com", Primary: true, Verified: true},
+		},
+	})
+
+	resp := lt.login(t)
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("status = %d, want %d; body %s", resp.StatusCode, http.StatusTemporaryRedirect, body)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != frontendURL+"/auth/callback" {
+		t.Errorf("redirected to %q, want %q", got, frontendURL+"/auth/callback")
+	}
+	if len(lt.created) != 1 || lt.created[0] != 42 {
+		t.Errorf("linked GitHub ids = %v, want [42]", lt.created)
+	}
+
+	body, _ := json.Marshal(map[string]string{"code": location.Query().Get("code")})
+	exchange, err := lt.client.Post(lt.api.URL+"/api/auth/exchange", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exchange.Body.Close()
+
+	if exchange.StatusCode != http.StatusOK {
+		t.Fatalf("exchange status = %d, want %d", exchange.StatusCode, http.StatusOK)
+	}
+
+	var got struct {
+		Tokens TokenPair `json:"tokens"`
+		User   User      `json:"user"`
+	}
+	if err := json.NewDecoder(exchange.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.User.Username != "octocat" || got.User.Email != "octocat@example.com" {
+		t.Errorf("user = %s <%s>, want octocat <octocat@example.com>", got.User.Username, got.User.Email)
+	}
+	if got.Tokens.AccessToken == "" {
+		t.Error("exchange returned no access token")
+	}
+}
+
+func TestGithubCallback_Errors(t *testing.T) {
+	verified := []githubtest.Email{{Email: "octocat@example.com", Primary: true, Verified: true}}
+
+	tests := []struct {
+		name       string
+		config     func(cfg *config.Config)
+		setup      func(gh *githubtest.Server)
+		wantStatus int
+	}{
+		{
+			name:       "user denies access",
+			setup:      func(gh *githubtest.Server) { gh.Deny() },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "code rejected",
+			config:     func(cfg *config.Config) { cfg.GitHub.ClientSecret = "wrong" },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "token endpoint down",
+			setup:      func(gh *githubtest.Server) { gh.FailWith("/login/oauth/access_token", http.StatusInternalServerError) },
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:       "user endpoint unauthorized",
+			setup:      func(gh *githubtest.Server) { gh.FailWith("/user", http.StatusUnauthorized) },
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:       "emails endpoint unavailable",
+			setup:      func(gh *githubtest.Server) { gh.FailWith("/user/emails", http.StatusServiceUnavailable) },
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name: "no emails",
+			setup: func(gh *githubtest.Server) {
+				gh.SignInAs(githubtest.Account{ID: 42, Login: "octocat"})
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "primary email not verified",
+			setup: func(gh *githubtest.Server) {
+				gh.SignInAs(githubtest.Account{ID: 42, Login: "octocat", Emails: []githubtest.Email{
+					{Email: "octocat@example.com", Primary: true},
+					{Email: "other@example.com", Verified: true},
+				}})
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "github disabled",
+			config:     func(cfg *config.Config) { cfg.GitHub = config.GitHub{} },
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lt := newGithubLoginTest(t, tt.config)
+			lt.github.SignInAs(githubtest.Account{ID: 42, Login: "octocat", Emails: verified})
+			if tt.setup != nil {
+				tt.setup(lt.github)
+			}
+
+			resp := lt.login(t)
+			if resp.StatusCode != tt.wantStatus {
+				body, _ := io.ReadAll(resp.Body)
+				t.Errorf("status = %d, want %d; body %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if len(lt.created) != 0 {
+				t.Errorf("linked GitHub ids = %v, want none", lt.created)
+			}
+		})
+	}
+}
+
+func TestGithubCallback_EmailTaken(t *testing.T) {
+	lt := newGithubLoginTest(t, nil)
+	lt.users[1] = &User{ID: 1, Username: "octocat", Email: "octocat@example.com", Role: RoleStudent}
+	lt.github.SignInAs(githubtest.Account{ID: 42, Login: "octocat", Emails: []githubtest.Email{
+		{Email: " OctoCat@Example.com", Primary: true, Verified: true},
+	}})
+
+	resp := lt.login(t)
+	if resp.StatusCode != http.StatusConflict {
+		body, _ := io.ReadAll(resp.Body)
+		t.Errorf("status = %d, want %d; body %s", resp.StatusCode, http.StatusConflict, body)
+	}
+	if len(lt.users) != 1 || len(lt.created) != 0 {
+		t.Errorf("%d users and GitHub ids %v, want the password account alone", len(lt.users), lt.created)
+	}
+}
+
+func TestGithubCallback_StateMismatch(t *testing.T) {
+	lt := newGithubLoginTest(t, nil)
+	lt.github.SignInAs(githubtest.Account{ID: 42, Login: "octocat"})
+
+	// Stop at GitHub's redirect back and tamper with the state
+	lt.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Path == "/api/auth/github/callback" {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+	resp := lt.login(t)
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := callback.Query()
+	query.Set("state", "forged")
+	callback.RawQuery = query.Encode()
+
+	lt.client.CheckRedirect = nil
+	resp, err = lt.client.Get(callback.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
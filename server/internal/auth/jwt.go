@@ -1,47 +1,93 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func GenerateJWT(userID int) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
+// Access tokens are short-lived; clients renew them with a refresh token.
+const AccessTokenTTL = 15 * time.Minute
+
+// ErrMissingSecret guards against signing with an empty key, which would
+// make every token forgeable.
+var ErrMissingSecret = errors.New("jwt secret is not set")
+
+type Claims struct {
+	UserID    int
+	Role      string
+	ID        string
+	ExpiresAt time.Time
+}
+
+func GenerateJWT(secret []byte, userID int, role string) (string, error) {
+	if len(secret) == 0 {
+		return "", ErrMissingSecret
+	}
+
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+		"role":    role,
+		"jti":     uuid.NewString(),
+		"exp":     time.Now().Add(AccessTokenTTL).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return token.SignedString(secret)
 }
 
-func ValidateJWT(tokenString string) (int, error) {
-	secret := os.Getenv("JWT_SECRET")
+func ValidateJWT(secret []byte, tokenString string) (int, error) {
+	claims, err := ParseJWT(secret, tokenString)
+	if err != nil {
+		return 0, err
+	}
+
+	return claims.UserID, nil
+}
+
+func ParseJWT(secret []byte, tokenString string) (*Claims, error) {
+	if len(secret) == 0 {
+		return nil, ErrMissingSecret
+	}
 
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(secret), nil
+		return secret, nil
 	})
 
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return 0, fmt.Errorf("invalid token")
+		return nil, fmt.Errorf("invalid token")
 	}
 
 	userID, ok := claims["user_id"].(float64)
 	if !ok {
-		return 0, fmt.Errorf("invalid user_id in token")
+		return nil, fmt.Errorf("invalid user_id in token")
 	}
 
-	return int(userID), nil
+	role, ok := claims["role"].(string)
+	if !ok || role == "" {
+		return nil, fmt.Errorf("invalid role in token")
+	}
+
+	// Without a jti the token could never be revoked
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return nil, fmt.Errorf("invalid jti in token")
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil, fmt.Errorf("invalid exp in token")
+	}
 
+	return &Claims{UserID: int(userID), Role: role, ID: jti, ExpiresAt: exp.Time}, nil
 }
@@ -1,26 +1,25 @@
 package auth
 
 import (
-	"os"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func generateJWTWithExpiration(userID int, exp time.Time) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
+var testSecret = []byte("test-secret")
+
+func generateJWTWithExpiration(secret []byte, userID int, exp time.Time) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"exp":     exp.Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return token.SignedString(secret)
 }
 
 func TestGenerateJWT(t *testing.T) {
-	t.Setenv("JWT_SECRET", "test-secret")
-
 	tests := []struct {
 		name   string
 		userID int
@@ -32,7 +31,7 @@ func TestGenerateJWT(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token, err := GenerateJWT(tt.userID)
+			token, err := GenerateJWT(testSecret, tt.userID, RoleStudent)
 			if err != nil {
 				t.Errorf("GenerateJWT() error = %v, want nil", err)
 				return
@@ -43,7 +42,7 @@ func TestGenerateJWT(t *testing.T) {
 			}
 
 			// Verify the token can be validated and contains correct userID
-			gotUserID, err := ValidateJWT(token)
+			gotUserID, err := ValidateJWT(testSecret, token)
 			if err != nil {
 				t.Errorf("ValidateJWT() error = %v, want nil", err)
 				return
@@ -51,23 +50,28 @@ func TestGenerateJWT(t *testing.T) {
 			if gotUserID != tt.userID {
 				t.Errorf("ValidateJWT() = %v, want %v", gotUserID, tt.userID)
 			}
+
+			claims, err := ParseJWT(testSecret, token)
+			if err != nil {
+				t.Errorf("ParseJWT() error = %v, want nil", err)
+				return
+			}
+			if claims.Role != RoleStudent {
+				t.Errorf("ParseJWT() role = %v, want %v", claims.Role, RoleStudent)
+			}
 		})
 	}
 }
 
 func TestValidateJWT(t *testing.T) {
-	t.Setenv("JWT_SECRET", "test-secret")
-
 	// Generate valid token for tests
-	validToken, _ := GenerateJWT(123)
+	validToken, _ := GenerateJWT(testSecret, 123, RoleStudent)
 
 	// Generate expired token
-	expiredToken, _ := generateJWTWithExpiration(123, time.Now().Add(-1*time.Hour))
+	expiredToken, _ := generateJWTWithExpiration(testSecret, 123, time.Now().Add(-1*time.Hour))
 
 	// Generate token with different secret
-	t.Setenv("JWT_SECRET", "different-secret")
-	wrongSecretToken, _ := GenerateJWT(123)
-	t.Setenv("JWT_SECRET", "test-secret")
+	wrongSecretToken, _ := GenerateJWT([]byte("different-secret"), 123, RoleStudent)
 
 	tests := []struct {
 		name       string
@@ -109,7 +113,7 @@ func TestValidateJWT(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUserID, err := ValidateJWT(tt.token)
+			gotUserID, err := ValidateJWT(testSecret, tt.token)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
@@ -121,3 +125,14 @@ func TestValidateJWT(t *testing.T) {
 		})
 	}
 }
+
+func TestJWT_MissingSecret(t *testing.T) {
+	if _, err := GenerateJWT(nil, 1, RoleStudent); !errors.Is(err, ErrMissingSecret) {
+		t.Errorf("GenerateJWT() error = %v, want %v", err, ErrMissingSecret)
+	}
+
+	token, _ := GenerateJWT(testSecret, 1, RoleStudent)
+	if _, err := ParseJWT(nil, token); !errors.Is(err, ErrMissingSecret) {
+		t.Errorf("ParseJWT() error = %v, want %v", err, ErrMissingSecret)
+	}
+}
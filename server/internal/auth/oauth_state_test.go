@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOAuthState_RoundTrip(t *testing.T) {
+	secret := []byte("test-secret")
+
+	state, err := newOAuthState()
+	if err != nil {
+		t.Fatalf("newOAuthState() error = %v", err)
+	}
+
+	encoded, err := state.encode(secret)
+	if err != nil {
+		t.Fatalf("encode() error = %v", err)
+	}
+
+	otherSecret, _ := state.encode([]byte("different-secret"))
+	payload, _, _ := strings.Cut(encoded, ".")
+	_, otherSignature, _ := strings.Cut(otherSecret, ".")
+
+	// The same state signed with the secret for another purpose
+	asTicket, _ := encodeSigned(purposeStreamTicket, state, secret)
+
+	tests := []struct {
+		name    string
+		value   string
+		now     time.Time
+		wantErr error
+	}{
+		{"valid cookie", encoded, time.Now(), nil},
+		{"expired cookie", encoded, time.Now().Add(oauthStateTTL + time.Minute), ErrInvalidOAuthState},
+		{"signed with another secret", otherSecret, time.Now(), ErrInvalidOAuthState},
+		{"tampered signature", payload + "." + otherSignature, time.Now(), ErrInvalidOAuthState},
+		{"missing signature", payload, time.Now(), ErrInvalidOAuthState},
+		{"signed as a stream ticket", asTicket, time.Now(), ErrInvalidOAuthState},
+		{"garbage", "not-a-cookie", time.Now(), ErrInvalidOAuthState},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeOAuthState(tt.value, secret, tt.now)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("decodeOAuthState() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil {
+				if got.State != state.State || got.Verifier != state.Verifier {
+					t.Errorf("decodeOAuthState() = %+v, want %+v", got, state)
+				}
+			}
+		})
+	}
+}
+
+func TestOAuthState_Verify(t *testing.T) {
+	state := &oauthState{State: "expected"}
+
+	if err := state.verify("expected"); err != nil {
+		t.Errorf("verify() error = %v, want nil", err)
+	}
+	if err := state.verify("attacker"); !errors.Is(err, ErrOAuthStateMismatch) {
+		t.Errorf("verify() error = %v, want %v", err, ErrOAuthStateMismatch)
+	}
+	if err := state.verify(""); !errors.Is(err, ErrOAuthStateMismatch) {
+		t.Errorf("verify() with empty state error = %v, want %v", err, ErrOAuthStateMismatch)
+	}
+}
+
+func TestPKCEChallenge(t *testing.T) {
+	got := pkceChallenge("dBjftJeZ4CVP-mJ92IgLqV8XS8y8mzOGnZSJSVCyGXo")
+	want := "h-6riNUfdNbX1QR5FDnt-UtX82p9Kr_0Ivv5HkqC9Lw"
+
+	if got != want {
+		t.Errorf("pkceChallenge() = %v, want %v", got, want)
+	}
+}
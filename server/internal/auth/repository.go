@@ -3,6 +3,9 @@ package auth
 import (
 	"context"
 	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type RepositoryInterface interface {
@@ -10,6 +13,10 @@ type RepositoryInterface interface {
 	GetUserByID(ctx context.Context, id int) (*User, error)
 	CreateUser(ctx context.Context, username, email, avatarURL string) (*User, error)
 	CreateGithubAuth(ctx context.Context, userID, githubID int) error
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	CreateEmailUser(ctx context.Context, username, email, passwordHash string) (*User, error)
+	GetPasswordHash(ctx context.Context, userID int) (string, error)
+	UpdateUserRole(ctx context.Context, userID int, role string) (*User, error)
 }
 
 type Repository struct {
@@ -25,11 +32,11 @@ func (r *Repository) CreateUser(ctx context.Context, username, email, avatarURL
 	var user User
 	query := `INSERT INTO users (username, email, avatar_url)
 	VALUES ($1, $2, $3)
-	RETURNING id, username, email, avatar_url, created_at, updated_at`
+	RETURNING id, username, email, avatar_url, role, created_at, updated_at`
 
-	err := r.db.QueryRowContext(ctx, query, username, email, avatarURL).Scan(&user.ID, &user.Username, &user.Email, &user.AvatarURL, &user.CreatedAt, &user.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, query, username, email, avatarURL).Scan(&user.ID, &user.Username, &user.Email, &user.AvatarURL, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 
 	}
 
@@ -40,10 +47,10 @@ func (r *Repository) CreateUser(ctx context.Context, username, email, avatarURL
 func (r *Repository) GetUserByID(ctx context.Context, id int) (*User, error) {
 	var user User
 
-	query := `SELECT id, username, email, avatar_url, created_at, updated_at FROM users
+	query := `SELECT id, username, email, avatar_url, role, created_at, updated_at FROM users
 	WHERE id = $1`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Email, &user.AvatarURL, &user.CreatedAt, &user.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Email, &user.AvatarURL, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -55,12 +62,12 @@ func (r *Repository) GetUserByGithubID(ctx context.Context, githubID int) (*User
 
 	var user User
 
-	query := `SELECT u.id, u.username, u.email, u.avatar_url, u.created_at, u.updated_at
+	query := `SELECT u.id, u.username, u.email, u.avatar_url, u.role, u.created_at, u.updated_at
 	FROM users u
 	JOIN user_auth_github g ON u.id = g.user_id
 	WHERE g.github_id = $1`
 
-	err := r.db.QueryRowContext(ctx, query, githubID).Scan(&user.ID, &user.Username, &user.Email, &user.AvatarURL, &user.CreatedAt, &user.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, query, githubID).Scan(&user.ID, &user.Username, &user.Email, &user.AvatarURL, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -81,7 +88,88 @@ func (r *Repository) CreateGithubAuth(ctx context.Context, userID, githubID int)
 }
 
 func (r *Repository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
-	// TODO: implement this method
+	var user User
+
+	query := `SELECT id, username, email, avatar_url, role, created_at, updated_at FROM users
+	WHERE email = $1`
+
+	err := r.db.QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.AvatarURL, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// CreateEmailUser creates a user who signs in with a password. The user and
+// the password are inserted together so a failure can't leave an account
+// nobody can log into.
+func (r *Repository) CreateEmailUser(ctx context.Context, username, email, passwordHash string) (*User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var user User
+	query := `INSERT INTO users (username, email, avatar_url)
+	VALUES ($1, $2, '')
+	RETURNING id, username, email, avatar_url, role, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, username, email).Scan(&user.ID, &user.Username, &user.Email, &user.AvatarURL, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	query = `INSERT INTO user_auth_email (user_id, password_hash)
+	VALUES ($1, $2)`
+
+	if _, err := tx.ExecContext(ctx, query, user.ID, passwordHash); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
 
-	return nil, nil
+func (r *Repository) GetPasswordHash(ctx context.Context, userID int) (string, error) {
+	var passwordHash string
+
+	query := `SELECT password_hash FROM user_auth_email
+	WHERE user_id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&passwordHash)
+	if err != nil {
+		return "", err
+	}
+
+	return passwordHash, nil
+}
+
+func (r *Repository) UpdateUserRole(ctx context.Context, userID int, role string) (*User, error) {
+	var user User
+
+	query := `UPDATE users SET role = $2, updated_at = NOW()
+	WHERE id = $1
+	RETURNING id, username, email, avatar_url, role, created_at, updated_at`
+
+	err := r.db.QueryRowContext(ctx, query, userID, role).Scan(&user.ID, &user.Username, &user.Email, &user.AvatarURL, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// translateError maps the email constraint to ErrDuplicateEmail, which catches
+// a sign-up that raced another one past the service's lookup.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "users_email_key" {
+		return ErrDuplicateEmail
+	}
+	return err
 }
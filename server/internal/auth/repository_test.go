@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"os"
 	"testing"
@@ -73,6 +74,9 @@ func TestRepository_CreateUser(t *testing.T) {
 				t.Errorf("CreateUser() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.wantErr && !errors.Is(err, ErrDuplicateEmail) {
+				t.Errorf("CreateUser() error = %v, want %v", err, ErrDuplicateEmail)
+			}
 
 			if !tt.wantErr {
 				if user.ID == 0 {
@@ -240,3 +244,63 @@ func TestRepository_GetUserByGithubID(t *testing.T) {
 		})
 	}
 }
+
+func TestRepository_EmailAuth(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	testDB.TruncateTables(t)
+	repo := NewRepository(testDB.DB)
+
+	// Setup: crear usuario con password
+	user, err := repo.CreateEmailUser(context.Background(), "testuser", "test@example.com", "hash")
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	gotUser, err := repo.GetUserByEmail(context.Background(), "test@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail() error = %v", err)
+	}
+	if gotUser.ID != user.ID {
+		t.Errorf("GetUserByEmail() user ID = %v, want %v", gotUser.ID, user.ID)
+	}
+
+	if _, err := repo.GetUserByEmail(context.Background(), "missing@example.com"); err == nil {
+		t.Error("GetUserByEmail() error = nil, want error for unknown email")
+	}
+
+	hash, err := repo.GetPasswordHash(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("GetPasswordHash() error = %v", err)
+	}
+	if hash != "hash" {
+		t.Errorf("GetPasswordHash() = %v, want %v", hash, "hash")
+	}
+}
+
+func TestRepository_CreateEmailUser_Duplicate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	testDB.TruncateTables(t)
+	repo := NewRepository(testDB.DB)
+
+	if _, err := repo.CreateEmailUser(context.Background(), "testuser", "test@example.com", "hash"); err != nil {
+		t.Fatalf("CreateEmailUser() error = %v", err)
+	}
+	if _, err := repo.CreateEmailUser(context.Background(), "other", "test@example.com", "other-hash"); !errors.Is(err, ErrDuplicateEmail) {
+		t.Fatalf("CreateEmailUser() with a taken email error = %v, want %v", err, ErrDuplicateEmail)
+	}
+
+	var users, passwords int
+	err := testDB.DB.QueryRow(`SELECT (SELECT count(*) FROM users), (SELECT count(*) FROM user_auth_email)`).Scan(&users, &passwords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users != 1 || passwords != 1 {
+		t.Errorf("%d users and %d passwords stored, want one of each", users, passwords)
+	}
+}
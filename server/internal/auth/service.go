@@ -2,10 +2,17 @@ package auth
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"strings"
 )
 
-var ErrUserNotFound = errors.New("user not found")
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrDuplicateEmail     = errors.New("duplicate email")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrChangeOwnRole      = errors.New("cannot change own role")
+)
 
 type Service struct {
 	repo RepositoryInterface
@@ -23,7 +30,12 @@ func (s *Service) CreateUserByGithub(ctx context.Context, githubID int, username
 		return user, nil
 	}
 
-	// If not, then create user
+	// Emails are stored lowercase, as the email sign-up stores them
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	// If not, then create user. An address taken by a password account
+	// fails with ErrDuplicateEmail rather than being linked: the password
+	// sign-up never proved it owned the address.
 	user, err = s.repo.CreateUser(ctx, username, email, avatarURL)
 	if err != nil {
 		return nil, err
@@ -46,3 +58,68 @@ func (s *Service) GetUserByID(ctx context.Context, id int) (*User, error) {
 
 	return user, nil
 }
+
+func (s *Service) RegisterByEmail(ctx context.Context, username, email, password string) (*User, error) {
+
+	// Emails are unique across every auth method
+	_, err := s.repo.GetUserByEmail(ctx, email)
+	if err == nil {
+		return nil, ErrDuplicateEmail
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	passwordHash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.CreateEmailUser(ctx, username, email, passwordHash)
+}
+
+func (s *Service) LoginByEmail(ctx context.Context, email, password string) (*User, error) {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// GitHub-only accounts have no password
+	passwordHash, err := s.repo.GetPasswordHash(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	match, err := CheckPassword(passwordHash, password)
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+func (s *Service) UpdateUserRole(ctx context.Context, actorID, userID int, role string) (*User, error) {
+	// Keeps the last admin from locking everyone out by accident
+	if actorID == userID {
+		return nil, ErrChangeOwnRole
+	}
+
+	user, err := s.repo.UpdateUserRole(ctx, userID, role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
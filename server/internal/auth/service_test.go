@@ -2,7 +2,9 @@ package auth
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -12,6 +14,10 @@ type mockRepository struct {
 	getUserByIDFunc       func(ctx context.Context, id int) (*User, error)
 	createUserFunc        func(ctx context.Context, username, email, avatarURL string) (*User, error)
 	createGithubAuthFunc  func(ctx context.Context, userID, githubID int) error
+	getUserByEmailFunc    func(ctx context.Context, email string) (*User, error)
+	createEmailUserFunc   func(ctx context.Context, username, email, passwordHash string) (*User, error)
+	getPasswordHashFunc   func(ctx context.Context, userID int) (string, error)
+	updateUserRoleFunc    func(ctx context.Context, userID int, role string) (*User, error)
 }
 
 func (m *mockRepository) GetUserByGithubID(ctx context.Context, githubID int) (*User, error) {
@@ -30,6 +36,22 @@ func (m *mockRepository) CreateGithubAuth(ctx context.Context, userID, githubID
 	return m.createGithubAuthFunc(ctx, userID, githubID)
 }
 
+func (m *mockRepository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	return m.getUserByEmailFunc(ctx, email)
+}
+
+func (m *mockRepository) CreateEmailUser(ctx context.Context, username, email, passwordHash string) (*User, error) {
+	return m.createEmailUserFunc(ctx, username, email, passwordHash)
+}
+
+func (m *mockRepository) GetPasswordHash(ctx context.Context, userID int) (string, error) {
+	return m.getPasswordHashFunc(ctx, userID)
+}
+
+func (m *mockRepository) UpdateUserRole(ctx context.Context, userID int, role string) (*User, error) {
+	return m.updateUserRoleFunc(ctx, userID, role)
+}
+
 func TestCreateUserByGithub(t *testing.T) {
 	now := time.Now()
 
@@ -95,6 +117,46 @@ func TestCreateUserByGithub(t *testing.T) {
 			want:    newUser,
 			wantErr: false,
 		},
+		{
+			name:      "email is normalized",
+			githubID:  67890,
+			username:  "newuser",
+			email:     " New@Example.COM ",
+			avatarURL: "https://example.com/new-avatar.png",
+			mock: &mockRepository{
+				getUserByGithubIDFunc: func(ctx context.Context, githubID int) (*User, error) {
+					return nil, sql.ErrNoRows
+				},
+				createUserFunc: func(ctx context.Context, username, email, avatarURL string) (*User, error) {
+					if email != "new@example.com" {
+						return nil, fmt.Errorf("email stored as %q", email)
+					}
+					return newUser, nil
+				},
+				createGithubAuthFunc: func(ctx context.Context, userID, githubID int) error {
+					return nil
+				},
+			},
+			want:    newUser,
+			wantErr: false,
+		},
+		{
+			name:      "email belongs to another account",
+			githubID:  67890,
+			username:  "newuser",
+			email:     "existing@example.com",
+			avatarURL: "https://example.com/new-avatar.png",
+			mock: &mockRepository{
+				getUserByGithubIDFunc: func(ctx context.Context, githubID int) (*User, error) {
+					return nil, sql.ErrNoRows
+				},
+				createUserFunc: func(ctx context.Context, username, email, avatarURL string) (*User, error) {
+					return nil, ErrDuplicateEmail
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name:      "error creating user",
 			githubID:  11111,
@@ -238,3 +300,223 @@ func TestGetUserByID(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterByEmail(t *testing.T) {
+	now := time.Now()
+
+	newUser := &User{
+		ID:        3,
+		Username:  "student",
+		Email:     "student@espol.edu.ec",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	tests := []struct {
+		name    string
+		mock    *mockRepository
+		want    *User
+		wantErr error
+	}{
+		{
+			name: "new user registered successfully",
+			mock: &mockRepository{
+				getUserByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+					return nil, sql.ErrNoRows
+				},
+				createEmailUserFunc: func(ctx context.Context, username, email, passwordHash string) (*User, error) {
+					if passwordHash == "pa55word!" {
+						return nil, errors.New("password stored in plaintext")
+					}
+					return newUser, nil
+				},
+			},
+			want:    newUser,
+			wantErr: nil,
+		},
+		{
+			name: "email already taken",
+			mock: &mockRepository{
+				getUserByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+					return newUser, nil
+				},
+			},
+			want:    nil,
+			wantErr: ErrDuplicateEmail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(tt.mock)
+			got, err := service.RegisterByEmail(context.Background(), "student", "student@espol.edu.ec", "pa55word!")
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("RegisterByEmail() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("RegisterByEmail() error = %v, wantErr nil", err)
+				return
+			}
+
+			if got.ID != tt.want.ID {
+				t.Errorf("RegisterByEmail() ID = %v, want %v", got.ID, tt.want.ID)
+			}
+		})
+	}
+}
+
+func TestLoginByEmail(t *testing.T) {
+	user := &User{ID: 4, Username: "student", Email: "student@espol.edu.ec"}
+
+	passwordHash, err := HashPassword("pa55word!")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		mock     *mockRepository
+		wantErr  error
+	}{
+		{
+			name:     "valid credentials",
+			password: "pa55word!",
+			mock: &mockRepository{
+				getUserByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+					return user, nil
+				},
+				getPasswordHashFunc: func(ctx context.Context, userID int) (string, error) {
+					return passwordHash, nil
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name:     "wrong password",
+			password: "wrong-password",
+			mock: &mockRepository{
+				getUserByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+					return user, nil
+				},
+				getPasswordHashFunc: func(ctx context.Context, userID int) (string, error) {
+					return passwordHash, nil
+				},
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:     "unknown email",
+			password: "pa55word!",
+			mock: &mockRepository{
+				getUserByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+					return nil, sql.ErrNoRows
+				},
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:     "github-only account",
+			password: "pa55word!",
+			mock: &mockRepository{
+				getUserByEmailFunc: func(ctx context.Context, email string) (*User, error) {
+					return user, nil
+				},
+				getPasswordHashFunc: func(ctx context.Context, userID int) (string, error) {
+					return "", sql.ErrNoRows
+				},
+			},
+			wantErr: ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(tt.mock)
+			got, err := service.LoginByEmail(context.Background(), "student@espol.edu.ec", tt.password)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("LoginByEmail() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("LoginByEmail() error = %v, wantErr nil", err)
+				return
+			}
+
+			if got.ID != user.ID {
+				t.Errorf("LoginByEmail() ID = %v, want %v", got.ID, user.ID)
+			}
+		})
+	}
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		actorID int
+		userID  int
+		mock    *mockRepository
+		wantErr error
+	}{
+		{
+			name:    "promote another user",
+			actorID: 1,
+			userID:  2,
+			mock: &mockRepository{
+				updateUserRoleFunc: func(ctx context.Context, userID int, role string) (*User, error) {
+					return &User{ID: userID, Role: role}, nil
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "change own role",
+			actorID: 1,
+			userID:  1,
+			mock:    &mockRepository{},
+			wantErr: ErrChangeOwnRole,
+		},
+		{
+			name:    "user not found",
+			actorID: 1,
+			userID:  999,
+			mock: &mockRepository{
+				updateUserRoleFunc: func(ctx context.Context, userID int, role string) (*User, error) {
+					return nil, sql.ErrNoRows
+				},
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(tt.mock)
+			got, err := service.UpdateUserRole(context.Background(), tt.actorID, tt.userID, RoleInstructor)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("UpdateUserRole() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("UpdateUserRole() error = %v, wantErr nil", err)
+				return
+			}
+			if got.Role != RoleInstructor {
+				t.Errorf("UpdateUserRole() role = %v, want %v", got.Role, RoleInstructor)
+			}
+		})
+	}
+}
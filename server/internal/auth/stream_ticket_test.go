@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamTicket_RoundTrip(t *testing.T) {
+	secret := []byte("test-secret")
+	path := "/api/submissions/7/events"
+	now := time.Now()
+
+	encoded, err := NewStreamTicket(42, RoleStudent, path, now).Encode(secret)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	otherSecret, _ := NewStreamTicket(42, RoleStudent, path, now).Encode([]byte("different-secret"))
+	forged, _ := NewStreamTicket(42, RoleAdmin, path, now).Encode([]byte("different-secret"))
+	payload, _, _ := strings.Cut(forged, ".")
+	_, signature, _ := strings.Cut(encoded, ".")
+
+	// The same ticket signed with the secret for another purpose
+	asState, _ := encodeSigned(purposeOAuthState, NewStreamTicket(42, RoleStudent, path, now), secret)
+
+	tests := []struct {
+		name    string
+		value   string
+		path    string
+		now     time.Time
+		wantErr error
+	}{
+		{"valid ticket", encoded, path, now, nil},
+		{"expired ticket", encoded, path, now.Add(StreamTicketTTL + time.Second), ErrInvalidStreamTicket},
+		{"other path", encoded, "/api/submissions/8/events", now, ErrInvalidStreamTicket},
+		{"signed with another secret", otherSecret, path, now, ErrInvalidStreamTicket},
+		{"tampered payload", payload + "." + signature, path, now, ErrInvalidStreamTicket},
+		{"signed as an oauth state", asState, path, now, ErrInvalidStreamTicket},
+		{"garbage", "not-a-ticket", path, now, ErrInvalidStreamTicket},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStreamTicket(tt.value, tt.path, secret, tt.now)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseStreamTicket() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && (got.UserID != 42 || got.Role != RoleStudent) {
+				t.Errorf("ParseStreamTicket() = %+v, want user 42 with role %s", got, RoleStudent)
+			}
+		})
+	}
+}
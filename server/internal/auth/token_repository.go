@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type TokenRepositoryInterface interface {
+	CreateRefreshToken(ctx context.Context, token *RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id int) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpiredTokens(ctx context.Context) (int64, error)
+	CreateAuthCode(ctx context.Context, codeHash string, userID int, expiresAt time.Time) error
+	ConsumeAuthCode(ctx context.Context, codeHash string) (int, error)
+	GetUserRole(ctx context.Context, userID int) (string, error)
+}
+
+type TokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+func (r *TokenRepository) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+
+	query := `INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at`
+
+	return r.db.QueryRowContext(ctx, query, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *TokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	var token RefreshToken
+
+	query := `SELECT id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at
+	FROM refresh_tokens
+	WHERE token_hash = $1`
+
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed reports false when the token was already used or
+// revoked, which is how concurrent reuse is detected.
+func (r *TokenRepository) MarkRefreshTokenUsed(ctx context.Context, id int) (bool, error) {
+
+	query := `UPDATE refresh_tokens SET used_at = NOW()
+	WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+
+	query := `UPDATE refresh_tokens SET revoked_at = NOW()
+	WHERE family_id = $1 AND revoked_at IS NULL`
+
+	_, err := r.db.ExecContext(ctx, query, familyID)
+	return err
+}
+
+func (r *TokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+
+	query := `INSERT INTO revoked_access_tokens (jti, expires_at)
+	VALUES ($1, $2)
+	ON CONFLICT (jti) DO NOTHING`
+
+	_, err := r.db.ExecContext(ctx, query, jti, expiresAt)
+	return err
+}
+
+func (r *TokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_access_tokens WHERE jti = $1)`
+
+	err := r.db.QueryRowContext(ctx, query, jti).Scan(&revoked)
+	return revoked, err
+}
+
+func (r *TokenRepository) CreateAuthCode(ctx context.Context, codeHash string, userID int, expiresAt time.Time) error {
+
+	query := `INSERT INTO auth_codes (code_hash, user_id, expires_at)
+	VALUES ($1, $2, $3)`
+
+	_, err := r.db.ExecContext(ctx, query, codeHash, userID, expiresAt)
+	return err
+}
+
+// ConsumeAuthCode deletes the code as it reads it, so it can only be
+// exchanged once.
+func (r *TokenRepository) ConsumeAuthCode(ctx context.Context, codeHash string) (int, error) {
+	var userID int
+
+	query := `DELETE FROM auth_codes
+	WHERE code_hash = $1 AND expires_at > NOW()
+	RETURNING user_id`
+
+	err := r.db.QueryRowContext(ctx, query, codeHash).Scan(&userID)
+	return userID, err
+}
+
+func (r *TokenRepository) GetUserRole(ctx context.Context, userID int) (string, error) {
+	var role string
+
+	query := `SELECT role FROM users WHERE id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&role)
+	return role, err
+}
+
+// DeleteExpiredTokens removes rows that can no longer be presented anyway.
+func (r *TokenRepository) DeleteExpiredTokens(ctx context.Context) (int64, error) {
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	refreshRows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err = r.db.ExecContext(ctx, `DELETE FROM revoked_access_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	accessRows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err = r.db.ExecContext(ctx, `DELETE FROM auth_codes WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	codeRows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return refreshRows + accessRows + codeRows, nil
+}
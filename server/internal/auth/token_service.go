@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RefreshTokenTTL = 30 * 24 * time.Hour
+	AuthCodeTTL     = time.Minute
+)
+
+// CleanupInterval is how often expired tokens are deleted. They are rejected
+// on expiry regardless, so this only keeps the tables small.
+const CleanupInterval = time.Hour
+
+// KindCleanupTokens is the periodic job that deletes expired tokens.
+const KindCleanupTokens = "auth.cleanup_tokens"
+
+var (
+	ErrInvalidAuthCode     = errors.New("invalid authorization code")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReuse   = errors.New("refresh token reuse detected")
+)
+
+// TokenService issues access/refresh token pairs. Refresh tokens rotate on
+// every use and belong to a family that starts at login; presenting a token
+// that was already rotated revokes the whole family.
+type TokenService struct {
+	repo   TokenRepositoryInterface
+	secret []byte
+}
+
+// NewTokenService creates the token service. secret signs access tokens and
+// stream tickets.
+func NewTokenService(repo TokenRepositoryInterface, secret []byte) *TokenService {
+	return &TokenService{repo: repo, secret: secret}
+}
+
+// ParseAccessToken validates an access token's signature and expiry. It does
+// not check for revocation, see IsRevoked.
+func (s *TokenService) ParseAccessToken(token string) (*Claims, error) {
+	return ParseJWT(s.secret, token)
+}
+
+// IssueStreamTicket returns a signed ticket for path and its expiry.
+func (s *TokenService) IssueStreamTicket(userID int, role, path string) (string, time.Time, error) {
+	ticket := NewStreamTicket(userID, role, path, time.Now())
+	encoded, err := ticket.Encode(s.secret)
+	return encoded, ticket.ExpiresAt, err
+}
+
+func (s *TokenService) ParseStreamTicket(value, path string) (*StreamTicket, error) {
+	return ParseStreamTicket(value, path, s.secret, time.Now())
+}
+
+func (s *TokenService) Issue(ctx context.Context, userID int) (*TokenPair, error) {
+	return s.issue(ctx, userID, uuid.NewString())
+}
+
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	token, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if token.UsedAt != nil || token.RevokedAt != nil {
+		if err := s.repo.RevokeFamily(ctx, token.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReuse
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Another request may have rotated the same token in the meantime
+	ok, err := s.repo.MarkRefreshTokenUsed(ctx, token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.repo.RevokeFamily(ctx, token.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReuse
+	}
+
+	return s.issue(ctx, token.UserID, token.FamilyID)
+}
+
+// IssueAuthCode creates an opaque one-time code that the frontend trades for
+// a token pair, so tokens never show up in redirect URLs.
+func (s *TokenService) IssueAuthCode(ctx context.Context, userID int) (string, error) {
+	code, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.repo.CreateAuthCode(ctx, hashToken(code), userID, time.Now().Add(AuthCodeTTL))
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+func (s *TokenService) ExchangeAuthCode(ctx context.Context, code string) (int, *TokenPair, error) {
+	userID, err := s.repo.ConsumeAuthCode(ctx, hashToken(code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, ErrInvalidAuthCode
+		}
+		return 0, nil, err
+	}
+
+	tokens, err := s.Issue(ctx, userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return userID, tokens, nil
+}
+
+// Logout revokes the current access token and, when given, the refresh token
+// family it was issued with.
+func (s *TokenService) Logout(ctx context.Context, userID int, jti string, refreshToken string) error {
+	if refreshToken != "" {
+		token, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if err == nil && token.UserID == userID {
+			if err := s.repo.RevokeFamily(ctx, token.FamilyID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.repo.RevokeAccessToken(ctx, jti, time.Now().Add(AccessTokenTTL))
+}
+
+func (s *TokenService) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.repo.IsAccessTokenRevoked(ctx, jti)
+}
+
+func (s *TokenService) DeleteExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpiredTokens(ctx)
+}
+
+// CleanupJob is the jobs.HandlerFunc for KindCleanupTokens.
+func (s *TokenService) CleanupJob(ctx context.Context, _ json.RawMessage) error {
+	_, err := s.DeleteExpired(ctx)
+	return err
+}
+
+func (s *TokenService) issue(ctx context.Context, userID int, familyID string) (*TokenPair, error) {
+	// Read the role on every issue so promotions and demotions apply at the
+	// next refresh
+	role, err := s.repo.GetUserRole(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := GenerateJWT(s.secret, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := generateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.CreateRefreshToken(ctx, &RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    time.Now().Add(AccessTokenTTL),
+	}, nil
+}
+
+func generateRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Only the hash is stored, so a database leak doesn't leak usable tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// memoryTokenRepository keeps tokens in memory so rotation and reuse
+// detection can be tested without a database.
+type memoryTokenRepository struct {
+	tokens    []*RefreshToken
+	revoked   map[string]time.Time
+	authCodes map[string]int
+}
+
+func newMemoryTokenRepository() *memoryTokenRepository {
+	return &memoryTokenRepository{revoked: make(map[string]time.Time), authCodes: make(map[string]int)}
+}
+
+func (m *memoryTokenRepository) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	token.ID = len(m.tokens) + 1
+	token.CreatedAt = time.Now()
+	m.tokens = append(m.tokens, token)
+	return nil
+}
+
+func (m *memoryTokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	for _, t := range m.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *memoryTokenRepository) MarkRefreshTokenUsed(ctx context.Context, id int) (bool, error) {
+	t := m.tokens[id-1]
+	if t.UsedAt != nil || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return true, nil
+}
+
+func (m *memoryTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	for _, t := range m.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *memoryTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.revoked[jti] = expiresAt
+	return nil
+}
+
+func (m *memoryTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	_, ok := m.revoked[jti]
+	return ok, nil
+}
+
+func (m *memoryTokenRepository) DeleteExpiredTokens(ctx context.Context) (int64, error) {
+	var deleted int64
+	for jti, expiresAt := range m.revoked {
+		if expiresAt.Before(time.Now()) {
+			delete(m.revoked, jti)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *memoryTokenRepository) CreateAuthCode(ctx context.Context, codeHash string, userID int, expiresAt time.Time) error {
+	m.authCodes[codeHash] = userID
+	return nil
+}
+
+func (m *memoryTokenRepository) ConsumeAuthCode(ctx context.Context, codeHash string) (int, error) {
+	userID, ok := m.authCodes[codeHash]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	delete(m.authCodes, codeHash)
+	return userID, nil
+}
+
+func (m *memoryTokenRepository) GetUserRole(ctx context.Context, userID int) (string, error) {
+	return RoleStudent, nil
+}
+
+func TestTokenService_Refresh(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rotates the refresh token", func(t *testing.T) {
+		service := NewTokenService(newMemoryTokenRepository(), testSecret)
+
+		first, err := service.Issue(ctx, 1)
+		if err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+
+		second, err := service.Refresh(ctx, first.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+		if second.RefreshToken == first.RefreshToken {
+			t.Error("Refresh() returned the same refresh token")
+		}
+
+		userID, err := ValidateJWT(testSecret, second.AccessToken)
+		if err != nil || userID != 1 {
+			t.Errorf("Refresh() access token user = %v, err = %v, want 1", userID, err)
+		}
+	})
+
+	t.Run("reuse revokes the whole family", func(t *testing.T) {
+		service := NewTokenService(newMemoryTokenRepository(), testSecret)
+
+		first, _ := service.Issue(ctx, 1)
+		second, err := service.Refresh(ctx, first.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+
+		// Replaying the rotated token is treated as theft
+		_, err = service.Refresh(ctx, first.RefreshToken)
+		if !errors.Is(err, ErrRefreshTokenReuse) {
+			t.Fatalf("Refresh() with reused token error = %v, want %v", err, ErrRefreshTokenReuse)
+		}
+
+		// The legitimate latest token is now dead too
+		_, err = service.Refresh(ctx, second.RefreshToken)
+		if !errors.Is(err, ErrRefreshTokenReuse) {
+			t.Errorf("Refresh() after family revocation error = %v, want %v", err, ErrRefreshTokenReuse)
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		service := NewTokenService(newMemoryTokenRepository(), testSecret)
+
+		_, err := service.Refresh(ctx, "does-not-exist")
+		if !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("Refresh() error = %v, want %v", err, ErrInvalidRefreshToken)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		repo := newMemoryTokenRepository()
+		service := NewTokenService(repo, testSecret)
+
+		pair, _ := service.Issue(ctx, 1)
+		repo.tokens[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+		_, err := service.Refresh(ctx, pair.RefreshToken)
+		if !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("Refresh() error = %v, want %v", err, ErrInvalidRefreshToken)
+		}
+	})
+}
+
+func TestTokenService_Logout(t *testing.T) {
+	ctx := context.Background()
+
+	repo := newMemoryTokenRepository()
+	service := NewTokenService(repo, testSecret)
+
+	pair, _ := service.Issue(ctx, 1)
+	claims, err := ParseJWT(testSecret, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ParseJWT() error = %v", err)
+	}
+
+	if err := service.Logout(ctx, 1, claims.ID, pair.RefreshToken); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+
+	revoked, _ := service.IsRevoked(ctx, claims.ID)
+	if !revoked {
+		t.Error("IsRevoked() = false after logout, want true")
+	}
+
+	if _, err := service.Refresh(ctx, pair.RefreshToken); err == nil {
+		t.Error("Refresh() after logout error = nil, want error")
+	}
+}
+
+func TestTokenService_ExchangeAuthCode(t *testing.T) {
+	ctx := context.Background()
+
+	service := NewTokenService(newMemoryTokenRepository(), testSecret)
+
+	code, err := service.IssueAuthCode(ctx, 7)
+	if err != nil {
+		t.Fatalf("IssueAuthCode() error = %v", err)
+	}
+
+	userID, tokens, err := service.ExchangeAuthCode(ctx, code)
+	if err != nil {
+		t.Fatalf("ExchangeAuthCode() error = %v", err)
+	}
+	if userID != 7 || tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Errorf("ExchangeAuthCode() = %v, %+v, want user 7 with tokens", userID, tokens)
+	}
+
+	// Codes are single use
+	_, _, err = service.ExchangeAuthCode(ctx, code)
+	if !errors.Is(err, ErrInvalidAuthCode) {
+		t.Errorf("ExchangeAuthCode() second use error = %v, want %v", err, ErrInvalidAuthCode)
+	}
+}
+
+func TestTokenService_CleanupJob(t *testing.T) {
+	ctx := context.Background()
+
+	repo := newMemoryTokenRepository()
+	repo.revoked["expired"] = time.Now().Add(-time.Minute)
+	repo.revoked["live"] = time.Now().Add(time.Minute)
+
+	if err := NewTokenService(repo, testSecret).CleanupJob(ctx, nil); err != nil {
+		t.Fatalf("CleanupJob() error = %v", err)
+	}
+
+	if _, ok := repo.revoked["expired"]; ok {
+		t.Error("expired revocation was kept")
+	}
+	if _, ok := repo.revoked["live"]; !ok {
+		t.Error("live revocation was deleted")
+	}
+}
@@ -0,0 +1,27 @@
+package categories
+
+import (
+	"log/slog"
+	"net/http"
+
+	"apschool/internal/response"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+func (h *Handler) ListCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.service.ListCategories(r.Context())
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"categories": categories}, nil)
+}
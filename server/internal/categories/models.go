@@ -0,0 +1,15 @@
+package categories
+
+import "time"
+
+type Category struct {
+	ID             int       `json:"id"`
+	Slug           string    `json:"slug"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description"`
+	DisplayOrder   int       `json:"display_order"`
+	IsVisible      bool      `json:"-"`
+	ChallengeCount int       `json:"challenge_count"`
+	CreatedAt      time.Time `json:"-"`
+	UpdatedAt      time.Time `json:"-"`
+}
@@ -0,0 +1,49 @@
+package categories
+
+import (
+	"context"
+	"database/sql"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// ListVisible returns the visible categories in display order, each with the
+// number of active challenges it holds.
+func (r *Repository) ListVisible(ctx context.Context) ([]Category, error) {
+
+	query := `SELECT c.id, c.slug, c.title, c.description, c.display_order, c.is_visible,
+		count(ch.id) AS challenge_count
+	FROM categories c
+	LEFT JOIN challenges ch ON ch.category = c.slug AND ch.is_active = true
+	WHERE c.is_visible = true
+	GROUP BY c.id
+	ORDER BY c.display_order, c.slug`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []Category{}
+	for rows.Next() {
+		var c Category
+		err := rows.Scan(&c.ID, &c.Slug, &c.Title, &c.Description, &c.DisplayOrder, &c.IsVisible, &c.ChallengeCount)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
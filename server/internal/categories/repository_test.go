@@ -0,0 +1,87 @@
+package categories
+
+import (
+	"context"
+	"flag"
+	"os"
+	"slices"
+	"testing"
+
+	"apschool/internal/testutil"
+)
+
+var testDB *testutil.TestDB
+
+func TestMain(m *testing.M) {
+	// Parse flags first so we can check -short
+	flag.Parse()
+
+	// Skip container setup entirely in short mode (for CI without Docker)
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	ctx := context.Background()
+
+	var err error
+	testDB, err = testutil.SetupTestDB(ctx)
+	if err != nil {
+		panic("failed to setup test db: " + err.Error())
+	}
+
+	code := m.Run()
+
+	testDB.Teardown(ctx)
+	os.Exit(code)
+}
+
+func TestRepository_ListVisible(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	testDB.TruncateTables(t)
+	if _, err := testDB.DB.Exec("TRUNCATE challenges, categories CASCADE"); err != nil {
+		t.Fatalf("failed to truncate challenges: %v", err)
+	}
+
+	_, err := testDB.DB.Exec(`INSERT INTO categories (slug, title, display_order, is_visible) VALUES
+		('unit-b', 'B', 1, true),
+		('unit-a', 'A', 1, true),
+		('intro', 'Intro', 0, true),
+		('draft', 'Draft', 0, false)`)
+	if err != nil {
+		t.Fatalf("failed to create categories: %v", err)
+	}
+
+	_, err = testDB.DB.Exec(`INSERT INTO challenges (slug, category, title, description, template, test_code, is_active) VALUES
+		('suma', 'intro', 'Suma', '', '', 'assert True', true),
+		('resta', 'intro', 'Resta', '', '', 'assert True', true),
+		('viejo', 'intro', 'Viejo', '', '', 'assert True', false),
+		('lista', 'unit-b', 'Lista', '', '', 'assert True', true),
+		('borrador', 'draft', 'Borrador', '', '', 'assert True', true)`)
+	if err != nil {
+		t.Fatalf("failed to create challenges: %v", err)
+	}
+
+	categories, err := NewRepository(testDB.DB).ListVisible(context.Background())
+	if err != nil {
+		t.Fatalf("ListVisible() error = %v", err)
+	}
+
+	type listed struct {
+		slug  string
+		count int
+	}
+	got := []listed{}
+	for _, c := range categories {
+		got = append(got, listed{c.Slug, c.ChallengeCount})
+	}
+
+	// Ties on display order fall back to the slug; inactive challenges and
+	// hidden categories aren't counted
+	want := []listed{{"intro", 2}, {"unit-a", 0}, {"unit-b", 1}}
+	if !slices.Equal(got, want) {
+		t.Errorf("ListVisible() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,282 @@
+package challenges
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"apschool/internal/grader"
+	"apschool/internal/pagination"
+	"apschool/internal/response"
+	"apschool/internal/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handler) AdminListChallengesHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	filters := pagination.FromQuery(r.URL.Query(), v)
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	challenges, metadata, err := h.service.ListAllChallenges(r.Context(), filters)
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"challenges": challenges, "metadata": metadata}, nil)
+}
+
+func (h *Handler) AdminGetChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid id")
+		return
+	}
+
+	challenge, err := h.service.GetAnyChallengeByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrChallengeNotFound) {
+			response.NotFound(w)
+			return
+		}
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"challenge": challenge}, nil)
+}
+
+func (h *Handler) CreateChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Slug           string        `json:"slug"`
+		Category       string        `json:"category"`
+		Title          string        `json:"title"`
+		Description    string        `json:"description"`
+		Template       string        `json:"template"`
+		TestCode       string        `json:"test_code"`
+		HiddenTestCode string        `json:"hidden_test_code"`
+		TestCases      []grader.Case `json:"test_cases"`
+		Solution       string        `json:"solution"`
+		Hints          string        `json:"hints"`
+		Difficulty     string        `json:"difficulty"`
+		Tags           []string      `json:"tags"`
+		IsActive       *bool         `json:"is_active"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	challenge := &Challenge{
+		Slug:           input.Slug,
+		Category:       input.Category,
+		Title:          input.Title,
+		Description:    input.Description,
+		Template:       input.Template,
+		TestCode:       input.TestCode,
+		HiddenTestCode: input.HiddenTestCode,
+		TestCases:      input.TestCases,
+		Solution:       input.Solution,
+		Hints:          input.Hints,
+		Difficulty:     input.Difficulty,
+		Tags:           input.Tags,
+		IsActive:       true,
+	}
+	if challenge.Tags == nil {
+		challenge.Tags = []string{}
+	}
+	if challenge.Difficulty == "" {
+		challenge.Difficulty = DifficultyEasy
+	}
+	if input.IsActive != nil {
+		challenge.IsActive = *input.IsActive
+	}
+
+	v := validator.New()
+	if ValidateChallenge(v, challenge); !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	err := h.service.CreateChallenge(r.Context(), challenge)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDuplicateSlug):
+			v.AddError("slug", "a challenge with this slug already exists")
+			response.ValidationError(w, v.Errors)
+		case errors.Is(err, ErrUnknownCategory):
+			v.AddError("category", "does not exist")
+			response.ValidationError(w, v.Errors)
+		default:
+			response.ServerError(w, r, h.logger, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", "/api/admin/challenges/"+strconv.Itoa(challenge.ID))
+
+	response.WriteJSON(w, http.StatusCreated, response.Envelope{"challenge": challenge}, headers)
+}
+
+// ReplaceChallengeHandler handles PUT and UpdateChallengeHandler handles PATCH;
+// both funnel into the same partial update so PUT simply requires every field.
+func (h *Handler) ReplaceChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	h.updateChallenge(w, r, true)
+}
+
+func (h *Handler) UpdateChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	h.updateChallenge(w, r, false)
+}
+
+func (h *Handler) updateChallenge(w http.ResponseWriter, r *http.Request, replace bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid id")
+		return
+	}
+
+	challenge, err := h.service.GetAnyChallengeByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrChallengeNotFound) {
+			response.NotFound(w)
+			return
+		}
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	var input struct {
+		Slug           *string        `json:"slug"`
+		Category       *string        `json:"category"`
+		Title          *string        `json:"title"`
+		Description    *string        `json:"description"`
+		Template       *string        `json:"template"`
+		TestCode       *string        `json:"test_code"`
+		HiddenTestCode *string        `json:"hidden_test_code"`
+		TestCases      *[]grader.Case `json:"test_cases"`
+		Solution       *string        `json:"solution"`
+		Hints          *string        `json:"hints"`
+		Difficulty     *string        `json:"difficulty"`
+		Tags           *[]string      `json:"tags"`
+		IsActive       *bool          `json:"is_active"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	v := validator.New()
+
+	if replace {
+		v.Check(input.Slug != nil, "slug", "is required")
+		v.Check(input.Category != nil, "category", "is required")
+		v.Check(input.Title != nil, "title", "is required")
+		v.Check(input.Description != nil, "description", "is required")
+		v.Check(input.Template != nil, "template", "is required")
+		v.Check(input.TestCode != nil, "test_code", "is required")
+		v.Check(input.HiddenTestCode != nil, "hidden_test_code", "is required")
+		v.Check(input.TestCases != nil, "test_cases", "is required")
+		v.Check(input.Solution != nil, "solution", "is required")
+		v.Check(input.Hints != nil, "hints", "is required")
+		v.Check(input.Difficulty != nil, "difficulty", "is required")
+		v.Check(input.Tags != nil, "tags", "is required")
+		v.Check(input.IsActive != nil, "is_active", "is required")
+
+		if !v.Valid() {
+			response.ValidationError(w, v.Errors)
+			return
+		}
+	}
+
+	if input.Slug != nil {
+		challenge.Slug = *input.Slug
+	}
+	if input.Category != nil {
+		challenge.Category = *input.Category
+	}
+	if input.Title != nil {
+		challenge.Title = *input.Title
+	}
+	if input.Description != nil {
+		challenge.Description = *input.Description
+	}
+	if input.Template != nil {
+		challenge.Template = *input.Template
+	}
+	if input.TestCode != nil {
+		challenge.TestCode = *input.TestCode
+	}
+	if input.HiddenTestCode != nil {
+		challenge.HiddenTestCode = *input.HiddenTestCode
+	}
+	if input.TestCases != nil {
+		challenge.TestCases = *input.TestCases
+	}
+	if input.Solution != nil {
+		challenge.Solution = *input.Solution
+	}
+	if input.Hints != nil {
+		challenge.Hints = *input.Hints
+	}
+	if input.Difficulty != nil {
+		challenge.Difficulty = *input.Difficulty
+	}
+	if input.Tags != nil && *input.Tags != nil {
+		challenge.Tags = *input.Tags
+	}
+	if input.IsActive != nil {
+		challenge.IsActive = *input.IsActive
+	}
+
+	if ValidateChallenge(v, challenge); !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	err = h.service.UpdateChallenge(r.Context(), challenge)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrDuplicateSlug):
+			v.AddError("slug", "a challenge with this slug already exists")
+			response.ValidationError(w, v.Errors)
+		case errors.Is(err, ErrUnknownCategory):
+			v.AddError("category", "does not exist")
+			response.ValidationError(w, v.Errors)
+		case errors.Is(err, ErrChallengeNotFound):
+			response.NotFound(w)
+		default:
+			response.ServerError(w, r, h.logger, err)
+		}
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"challenge": challenge}, nil)
+}
+
+// DeleteChallengeHandler deactivates the challenge instead of removing the row.
+func (h *Handler) DeleteChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid id")
+		return
+	}
+
+	err = h.service.SetChallengeActive(r.Context(), id, false)
+	if err != nil {
+		if errors.Is(err, ErrChallengeNotFound) {
+			response.NotFound(w)
+			return
+		}
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"message": "challenge deactivated"}, nil)
+}
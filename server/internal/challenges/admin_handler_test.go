@@ -0,0 +1,418 @@
+package challenges
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"apschool/internal/pagination"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// memoryRepository enforces the slug and category constraints the database
+// does, so the handlers' error paths can be tested without Postgres.
+type memoryRepository struct {
+	challenges map[int]*Challenge
+	categories []string
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		challenges: map[int]*Challenge{
+			1: {ID: 1, Slug: "hello-world", Category: "basics", Title: "Hello", Description: "Print it", TestCode: "assert True", Solution: "print('hi')", Difficulty: DifficultyEasy, Tags: []string{}, IsActive: true},
+			2: {ID: 2, Slug: "retired", Category: "basics", Title: "Retired", Description: "Gone", TestCode: "assert True", Difficulty: DifficultyHard, Tags: []string{}},
+		},
+		categories: []string{"basics", "loops"},
+	}
+}
+
+func (m *memoryRepository) checkConstraints(c *Challenge) error {
+	for id, other := range m.challenges {
+		if id != c.ID && other.Slug == c.Slug {
+			return ErrDuplicateSlug
+		}
+	}
+	if !slices.Contains(m.categories, c.Category) {
+		return ErrUnknownCategory
+	}
+	return nil
+}
+
+func (m *memoryRepository) Search(ctx context.Context, f SearchFilters, userID int) ([]Challenge, pagination.Metadata, error) {
+	return nil, pagination.Metadata{}, nil
+}
+
+func (m *memoryRepository) GetByID(ctx context.Context, id int) (*Challenge, error) {
+	c, err := m.GetAnyByID(ctx, id)
+	if err == nil && !c.IsActive {
+		return nil, sql.ErrNoRows
+	}
+	return c, err
+}
+
+func (m *memoryRepository) GetAnyByID(ctx context.Context, id int) (*Challenge, error) {
+	c, ok := m.challenges[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	found := *c
+	return &found, nil
+}
+
+func (m *memoryRepository) ListAll(ctx context.Context, filters pagination.Filters) ([]Challenge, pagination.Metadata, error) {
+	challenges := []Challenge{}
+	for id := range len(m.challenges) {
+		challenges = append(challenges, *m.challenges[id+1])
+	}
+	return challenges, pagination.NewMetadata(len(challenges), filters), nil
+}
+
+func (m *memoryRepository) Insert(ctx context.Context, c *Challenge) error {
+	if err := m.checkConstraints(c); err != nil {
+		return err
+	}
+	c.ID = len(m.challenges) + 1
+	saved := *c
+	m.challenges[c.ID] = &saved
+	return nil
+}
+
+func (m *memoryRepository) Update(ctx context.Context, c *Challenge) error {
+	if _, ok := m.challenges[c.ID]; !ok {
+		return sql.ErrNoRows
+	}
+	if err := m.checkConstraints(c); err != nil {
+		return err
+	}
+	saved := *c
+	m.challenges[c.ID] = &saved
+	return nil
+}
+
+func (m *memoryRepository) SetActive(ctx context.Context, id int, active bool) error {
+	c, ok := m.challenges[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	c.IsActive = active
+	return nil
+}
+
+func (m *memoryRepository) RevealedHintCount(ctx context.Context, userID, challengeID int) (int, error) {
+	return 0, nil
+}
+
+func (m *memoryRepository) InsertHintReveal(ctx context.Context, userID, challengeID, number int) error {
+	return nil
+}
+
+// newAdminRouter mounts the admin challenge handlers the way routes.go does,
+// minus the auth middleware.
+func newAdminRouter(repo RepositoryInterface) http.Handler {
+	h := NewHandler(NewService(repo), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r := chi.NewRouter()
+	r.Get("/api/admin/challenges", h.AdminListChallengesHandler)
+	r.Post("/api/admin/challenges", h.CreateChallengeHandler)
+	r.Get("/api/admin/challenges/{id}", h.AdminGetChallengeHandler)
+	r.Put("/api/admin/challenges/{id}", h.ReplaceChallengeHandler)
+	r.Patch("/api/admin/challenges/{id}", h.UpdateChallengeHandler)
+	r.Delete("/api/admin/challenges/{id}", h.DeleteChallengeHandler)
+	return r
+}
+
+func serve(t *testing.T, router http.Handler, method, target, body string) (*httptest.ResponseRecorder, map[string]json.RawMessage) {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decoding %s: %v", rr.Body, err)
+	}
+	return rr, envelope
+}
+
+// fieldErrors decodes the error map of a validation failure.
+func fieldErrors(t *testing.T, envelope map[string]json.RawMessage) map[string]string {
+	t.Helper()
+
+	var errs map[string]string
+	if err := json.Unmarshal(envelope["error"], &errs); err != nil {
+		t.Fatalf("error is not a field map: %s", envelope["error"])
+	}
+	return errs
+}
+
+const validChallenge = `{
+	"slug": "sum-two",
+	"category": "basics",
+	"title": "Sum two numbers",
+	"description": "Add a and b",
+	"test_code": "assert add(1, 2) == 3",
+	"test_cases": [{"name": "small", "call": "add", "args": [1, 2], "expected": 3}],
+	"tags": ["math"]
+}`
+
+func TestCreateChallengeHandler(t *testing.T) {
+	repo := newMemoryRepository()
+	rr, envelope := serve(t, newAdminRouter(repo), http.MethodPost, "/api/admin/challenges", validChallenge)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusCreated, rr.Body)
+	}
+	if got := rr.Header().Get("Location"); got != "/api/admin/challenges/3" {
+		t.Errorf("Location = %q, want /api/admin/challenges/3", got)
+	}
+
+	var got Challenge
+	if err := json.Unmarshal(envelope["challenge"], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Difficulty != DifficultyEasy || !got.IsActive {
+		t.Errorf("defaults = %s active %v, want easy and active", got.Difficulty, got.IsActive)
+	}
+	if stored := repo.challenges[3]; stored == nil || stored.Slug != "sum-two" || len(stored.TestCases) != 1 {
+		t.Errorf("stored challenge = %+v", stored)
+	}
+}
+
+func TestCreateChallengeHandler_Invalid(t *testing.T) {
+	// with replaces top-level fields of validChallenge
+	with := func(fields string) string {
+		var base, changes map[string]any
+		json.Unmarshal([]byte(validChallenge), &base)
+		json.Unmarshal([]byte(fields), &changes)
+		for k, v := range changes {
+			base[k] = v
+		}
+		js, _ := json.Marshal(base)
+		return string(js)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantField  string
+	}{
+		{name: "malformed json", body: `{"slug": `, wantStatus: http.StatusBadRequest},
+		{name: "unknown field", body: with(`{"owner": "me"}`), wantStatus: http.StatusBadRequest},
+		{name: "missing title", body: with(`{"title": ""}`), wantStatus: http.StatusUnprocessableEntity, wantField: "title"},
+		{name: "no tests", body: with(`{"test_code": " ", "test_cases": []}`), wantStatus: http.StatusUnprocessableEntity, wantField: "test_code"},
+		{name: "slug with spaces", body: with(`{"slug": "Sum Two"}`), wantStatus: http.StatusUnprocessableEntity, wantField: "slug"},
+		{name: "slug too long", body: with(`{"slug": "` + strings.Repeat("a", 101) + `"}`), wantStatus: http.StatusUnprocessableEntity, wantField: "slug"},
+		{name: "unknown difficulty", body: with(`{"difficulty": "extreme"}`), wantStatus: http.StatusUnprocessableEntity, wantField: "difficulty"},
+		{name: "duplicate tags", body: with(`{"tags": ["math", "math"]}`), wantStatus: http.StatusUnprocessableEntity, wantField: "tags"},
+		{name: "call is not a function name", body: with(`{"test_cases": [{"name": "a", "call": "add()", "expected": 3}]}`), wantStatus: http.StatusUnprocessableEntity, wantField: "test_cases"},
+		{name: "expected without call", body: with(`{"test_cases": [{"name": "a", "expected": 3}]}`), wantStatus: http.StatusUnprocessableEntity, wantField: "test_cases"},
+		{name: "duplicate case names", body: with(`{"test_cases": [{"name": "a", "expected_stdout": "1"}, {"name": "a", "expected_stdout": "2"}]}`), wantStatus: http.StatusUnprocessableEntity, wantField: "test_cases"},
+		{name: "slug taken", body: with(`{"slug": "hello-world"}`), wantStatus: http.StatusUnprocessableEntity, wantField: "slug"},
+		{name: "slug taken by an inactive challenge", body: with(`{"slug": "retired"}`), wantStatus: http.StatusUnprocessableEntity, wantField: "slug"},
+		{name: "unknown category", body: with(`{"category": "recursion"}`), wantStatus: http.StatusUnprocessableEntity, wantField: "category"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryRepository()
+			rr, envelope := serve(t, newAdminRouter(repo), http.MethodPost, "/api/admin/challenges", tt.body)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if tt.wantField != "" {
+				if errs := fieldErrors(t, envelope); errs[tt.wantField] == "" {
+					t.Errorf("errors = %v, want one for %s", errs, tt.wantField)
+				}
+			}
+			if len(repo.challenges) != 2 {
+				t.Errorf("stored %d challenges, want the 2 seeded", len(repo.challenges))
+			}
+		})
+	}
+}
+
+func TestUpdateChallengeHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantField  string
+		check      func(t *testing.T, c *Challenge)
+	}{
+		{
+			name:       "patch keeps the other fields",
+			method:     http.MethodPatch,
+			body:       `{"title": "Hello again", "tags": ["intro"]}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, c *Challenge) {
+				if c.Title != "Hello again" || c.Slug != "hello-world" || c.Solution != "print('hi')" || !slices.Equal(c.Tags, []string{"intro"}) {
+					t.Errorf("challenge = %+v", c)
+				}
+			},
+		},
+		{
+			name:       "patch keeping its own slug",
+			method:     http.MethodPatch,
+			body:       `{"slug": "hello-world"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "patch to a taken slug",
+			method:     http.MethodPatch,
+			body:       `{"slug": "retired"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantField:  "slug",
+		},
+		{
+			name:       "patch to an unknown category",
+			method:     http.MethodPatch,
+			body:       `{"category": "recursion"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantField:  "category",
+		},
+		{
+			name:       "patch breaking validation",
+			method:     http.MethodPatch,
+			body:       `{"difficulty": "extreme"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantField:  "difficulty",
+		},
+		{
+			name:       "put requires every field",
+			method:     http.MethodPut,
+			body:       `{"title": "Hello again"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantField:  "hidden_test_code",
+		},
+		{
+			name:   "put replaces everything",
+			method: http.MethodPut,
+			body: `{"slug": "hello", "category": "loops", "title": "Hello", "description": "Print it", "template": "",
+				"test_code": "assert True", "hidden_test_code": "", "test_cases": [], "solution": "", "hints": "",
+				"difficulty": "medium", "tags": [], "is_active": false}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, c *Challenge) {
+				if c.Slug != "hello" || c.Category != "loops" || c.Solution != "" || c.IsActive {
+					t.Errorf("challenge = %+v", c)
+				}
+			},
+		},
+		{
+			name:       "unknown challenge",
+			method:     http.MethodPatch,
+			target:     "/api/admin/challenges/99",
+			body:       `{"title": "Hello again"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid id",
+			method:     http.MethodPatch,
+			target:     "/api/admin/challenges/one",
+			body:       `{"title": "Hello again"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryRepository()
+			target := tt.target
+			if target == "" {
+				target = "/api/admin/challenges/1"
+			}
+
+			rr, envelope := serve(t, newAdminRouter(repo), tt.method, target, tt.body)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if tt.wantField != "" {
+				if errs := fieldErrors(t, envelope); errs[tt.wantField] == "" {
+					t.Errorf("errors = %v, want one for %s", errs, tt.wantField)
+				}
+				if repo.challenges[1].Slug != "hello-world" || repo.challenges[1].Category != "basics" {
+					t.Errorf("rejected update was stored: %+v", repo.challenges[1])
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, repo.challenges[1])
+			}
+		})
+	}
+}
+
+func TestAdminGetChallengeHandler(t *testing.T) {
+	router := newAdminRouter(newMemoryRepository())
+
+	// Unlike the public endpoint, inactive challenges and solutions are shown
+	rr, envelope := serve(t, router, http.MethodGet, "/api/admin/challenges/2", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var got Challenge
+	if err := json.Unmarshal(envelope["challenge"], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Slug != "retired" || got.IsActive {
+		t.Errorf("challenge = %+v, want the inactive one", got)
+	}
+
+	rr, envelope = serve(t, router, http.MethodGet, "/api/admin/challenges/1", "")
+	if rr.Code != http.StatusOK || !strings.Contains(string(envelope["challenge"]), `"solution"`) {
+		t.Errorf("status = %d, challenge = %s, want the solution included", rr.Code, envelope["challenge"])
+	}
+
+	if rr, _ := serve(t, router, http.MethodGet, "/api/admin/challenges/99", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown challenge status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestAdminListChallengesHandler(t *testing.T) {
+	router := newAdminRouter(newMemoryRepository())
+
+	rr, envelope := serve(t, router, http.MethodGet, "/api/admin/challenges", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var got []Challenge
+	if err := json.Unmarshal(envelope["challenges"], &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("listed %d challenges, want both, inactive included", len(got))
+	}
+
+	rr, envelope = serve(t, router, http.MethodGet, "/api/admin/challenges?page_size=500", "")
+	if rr.Code != http.StatusUnprocessableEntity || fieldErrors(t, envelope)["page_size"] == "" {
+		t.Errorf("status = %d, body %s, want a page_size error", rr.Code, rr.Body)
+	}
+}
+
+func TestDeleteChallengeHandler(t *testing.T) {
+	repo := newMemoryRepository()
+	router := newAdminRouter(repo)
+
+	rr, _ := serve(t, router, http.MethodDelete, "/api/admin/challenges/1", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if c := repo.challenges[1]; c == nil || c.IsActive {
+		t.Errorf("challenge = %+v, want it kept but deactivated", c)
+	}
+
+	if rr, _ := serve(t, router, http.MethodDelete, "/api/admin/challenges/99", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("unknown challenge status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
@@ -1,11 +1,15 @@
 package challenges
 
 import (
+	"apschool/internal/ctxkeys"
+	"apschool/internal/pagination"
 	"apschool/internal/response"
+	"apschool/internal/validator"
 	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -20,21 +24,40 @@ func NewHandler(service *Service, logger *slog.Logger) *Handler {
 }
 
 func (h *Handler) ListChallengesHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	v := validator.New()
 
-	category := r.URL.Query().Get("category")
-	if category == "" {
-		response.BadRequest(w, "category is required")
+	filters := SearchFilters{
+		Category:   qs.Get("category"),
+		Query:      strings.TrimSpace(qs.Get("q")),
+		Tag:        qs.Get("tag"),
+		Difficulty: qs.Get("difficulty"),
+		Filters:    pagination.FromQuery(qs, v),
+	}
+
+	v.Check(validator.MaxChars(filters.Query, 200), "q", "must not be more than 200 characters long")
+	if filters.Tag != "" {
+		v.Check(validator.Matches(filters.Tag, validator.SlugRegex), "tag", "must contain only lowercase letters, digits and dashes")
+	}
+	if filters.Difficulty != "" {
+		v.Check(validator.PermittedValue(filters.Difficulty, DifficultyEasy, DifficultyMedium, DifficultyHard), "difficulty", "must be easy, medium or hard")
+	}
+
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
 		return
 	}
 
-	challenges, err := h.service.GetChallenges(r.Context(), category)
+	// Signed-in callers also get a solved flag on each challenge
+	userID, _ := ctxkeys.GetUserID(r.Context())
+
+	challenges, metadata, err := h.service.SearchChallenges(r.Context(), filters, userID)
 	if err != nil {
 		response.ServerError(w, r, h.logger, err)
 		return
 	}
 
-	response.WriteJSON(w, http.StatusOK, response.Envelope{"challenges": challenges}, nil)
-
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"challenges": challenges, "metadata": metadata}, nil)
 }
 
 func (h *Handler) GetChallengeHandler(w http.ResponseWriter, r *http.Request) {
@@ -45,7 +68,10 @@ func (h *Handler) GetChallengeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	challenge, err := h.service.GetChallengeByID(r.Context(), id)
+	// Signed-in callers also get back the hints they already revealed
+	userID, _ := ctxkeys.GetUserID(r.Context())
+
+	challenge, err := h.service.GetChallengeForUser(r.Context(), id, userID)
 	if err != nil {
 		if errors.Is(err, ErrChallengeNotFound) {
 			response.NotFound(w)
@@ -57,3 +83,38 @@ func (h *Handler) GetChallengeHandler(w http.ResponseWriter, r *http.Request) {
 
 	response.WriteJSON(w, http.StatusOK, response.Envelope{"challenge": challenge}, nil)
 }
+
+func (h *Handler) RevealHintHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid id")
+		return
+	}
+
+	n, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil {
+		response.BadRequest(w, "invalid hint number")
+		return
+	}
+
+	hint, err := h.service.RevealHint(r.Context(), id, userID, n)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrChallengeNotFound), errors.Is(err, ErrHintNotFound):
+			response.NotFound(w)
+		case errors.Is(err, ErrHintLocked):
+			response.ErrorResponse(w, http.StatusConflict, "the previous hints must be revealed first")
+		default:
+			response.ServerError(w, r, h.logger, err)
+		}
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"hint": hint}, nil)
+}
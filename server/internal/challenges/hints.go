@@ -0,0 +1,44 @@
+package challenges
+
+import (
+	"regexp"
+	"strings"
+)
+
+// hintHeadingRegex matches the "## Hint N" headings that open each hint in
+// hints.md.
+var hintHeadingRegex = regexp.MustCompile(`(?i)^##\s+hint\s+\d+\s*$`)
+
+// Hint is one section of a challenge's hints. Numbers start at 1 and follow
+// the order of the headings, not the number written in them.
+type Hint struct {
+	Number  int    `json:"number"`
+	Content string `json:"content"`
+}
+
+// SplitHints breaks hints.md into its hints. Anything before the first
+// heading, such as a "# Hints" title, is dropped.
+func SplitHints(markdown string) []Hint {
+	hints := []Hint{}
+	var current *strings.Builder
+
+	flush := func() {
+		if current != nil {
+			hints = append(hints, Hint{Number: len(hints) + 1, Content: strings.TrimSpace(current.String())})
+		}
+	}
+
+	for line := range strings.Lines(markdown) {
+		if hintHeadingRegex.MatchString(strings.TrimRight(line, "\r\n")) {
+			flush()
+			current = &strings.Builder{}
+			continue
+		}
+		if current != nil {
+			current.WriteString(line)
+		}
+	}
+	flush()
+
+	return hints
+}
@@ -0,0 +1,43 @@
+package challenges
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitHints(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []Hint
+	}{
+		{"empty", "", []Hint{}},
+		{"no headings", "# Hints\n\nJust read the docs.\n", []Hint{}},
+		{
+			"title and hints",
+			"# Hints\n\n## Hint 1\nUse `print()`.\n\n## Hint 2\nStrings go in quotes.\n",
+			[]Hint{{1, "Use `print()`."}, {2, "Strings go in quotes."}},
+		},
+		{
+			"numbers follow heading order",
+			"## Hint 3\nfirst\n## hint 7\nsecond\n",
+			[]Hint{{1, "first"}, {2, "second"}},
+		},
+		{
+			"multi-line content and CRLF",
+			"## Hint 1\r\nline one\r\n\r\n```py\r\nx = 1\r\n```\r\n",
+			[]Hint{{1, "line one\r\n\r\n```py\r\nx = 1\r\n```"}},
+		},
+		{"empty hint", "## Hint 1\n## Hint 2\nsomething\n", []Hint{{1, ""}, {2, "something"}}},
+		{"other level-two headings are content", "## Hint 1\n## Example\ncode\n", []Hint{{1, "## Example\ncode"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitHints(tt.markdown)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitHints() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,17 +1,94 @@
 package challenges
 
-import "time"
+import (
+	"regexp"
+	"time"
+
+	"apschool/internal/grader"
+	"apschool/internal/pagination"
+	"apschool/internal/validator"
+)
+
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+// identifierRegex matches the Python function names test cases may call.
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
 
 type Challenge struct {
-	ID          int       `json:"id"`
-	Slug        string    `json:"slug,omitzero"`
-	Category    string    `json:"category,omitzero"`
-	Title       string    `json:"title"`
-	Description string    `json:"description,omitzero"`
-	Template    string    `json:"template,omitzero"`
-	TestCode    string    `json:"test_code,omitzero"`
-	Hints       string    `json:"hints,omitzero"`
-	IsActive    bool      `json:"-"`
-	CreatedAt   time.Time `json:"-"`
-	UpdatedAt   time.Time `json:"-"`
+	ID             int           `json:"id"`
+	Slug           string        `json:"slug,omitzero"`
+	Category       string        `json:"category,omitzero"`
+	Title          string        `json:"title"`
+	Description    string        `json:"description,omitzero"`
+	Template       string        `json:"template,omitzero"`
+	TestCode       string        `json:"test_code,omitzero"`
+	HiddenTestCode string        `json:"hidden_test_code,omitzero"`
+	TestCases      []grader.Case `json:"test_cases,omitempty"`
+	Solution       string        `json:"solution,omitzero"`
+	Hints          string        `json:"hints,omitzero"`
+	HintCount      int           `json:"hint_count,omitzero"`
+	RevealedHints  []Hint        `json:"revealed_hints,omitempty"`
+	Difficulty     string        `json:"difficulty,omitzero"`
+	Tags           []string      `json:"tags"`
+	IsActive       bool          `json:"is_active"`
+	Solved         *bool         `json:"solved,omitempty"`
+	CreatedAt      time.Time     `json:"-"`
+	UpdatedAt      time.Time     `json:"-"`
+}
+
+// SearchFilters narrows GET /api/challenges. Empty fields match everything.
+type SearchFilters struct {
+	Category   string
+	Query      string
+	Tag        string
+	Difficulty string
+	pagination.Filters
+}
+
+func ValidateChallenge(v *validator.Validator, c *Challenge) {
+	v.Check(validator.NotBlank(c.Slug), "slug", "is required")
+	v.Check(validator.MaxChars(c.Slug, 100), "slug", "must not be more than 100 characters long")
+	v.Check(validator.Matches(c.Slug, validator.SlugRegex), "slug", "must contain only lowercase letters, digits and dashes")
+
+	v.Check(validator.NotBlank(c.Category), "category", "is required")
+	v.Check(validator.Matches(c.Category, validator.SlugRegex), "category", "must contain only lowercase letters, digits and dashes")
+
+	v.Check(validator.NotBlank(c.Title), "title", "is required")
+	v.Check(validator.MaxChars(c.Title, 200), "title", "must not be more than 200 characters long")
+
+	v.Check(validator.NotBlank(c.Description), "description", "is required")
+	// Test cases alone are enough to grade a submission
+	v.Check(validator.NotBlank(c.TestCode) || len(c.TestCases) > 0, "test_code", "is required when there are no test cases")
+
+	v.Check(validator.PermittedValue(c.Difficulty, DifficultyEasy, DifficultyMedium, DifficultyHard), "difficulty", "must be easy, medium or hard")
+
+	ValidateTestCases(v, c.TestCases)
+
+	v.Check(len(c.Tags) <= 10, "tags", "must not contain more than 10 tags")
+	v.Check(validator.Unique(c.Tags), "tags", "must not contain duplicates")
+	for _, tag := range c.Tags {
+		v.Check(validator.Matches(tag, validator.SlugRegex), "tags", "must contain only lowercase letters, digits and dashes")
+	}
+}
+
+func ValidateTestCases(v *validator.Validator, cases []grader.Case) {
+	v.Check(len(cases) <= 50, "test_cases", "must not contain more than 50 cases")
+
+	names := make([]string, 0, len(cases))
+	for _, c := range cases {
+		names = append(names, c.Name)
+
+		v.Check(validator.NotBlank(c.Name), "test_cases", "every case must have a name")
+		if c.Call != "" {
+			v.Check(identifierRegex.MatchString(c.Call), "test_cases", "call must be a function name")
+			v.Check(c.ExpectedStdout == "", "test_cases", "function cases compare expected, not expected_stdout")
+		} else {
+			v.Check(c.Args == nil && c.Expected == nil, "test_cases", "args and expected require call")
+		}
+	}
+	v.Check(validator.Unique(names), "test_cases", "case names must be unique")
 }
@@ -0,0 +1,48 @@
+package challenges
+
+import (
+	"testing"
+
+	"apschool/internal/grader"
+	"apschool/internal/validator"
+)
+
+func TestValidateChallenge(t *testing.T) {
+	cases := []grader.Case{{Name: "small", Call: "add", Args: []any{1, 2}, Expected: 3}}
+
+	tests := []struct {
+		name      string
+		testCode  string
+		testCases []grader.Case
+		wantValid bool
+	}{
+		{"test code only", "assert add(1, 2) == 3", nil, true},
+		{"test cases only", "", cases, true},
+		{"both", "assert add(1, 2) == 3", cases, true},
+		{"neither", " ", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Challenge{
+				Slug:        "sum-two",
+				Category:    "basics",
+				Title:       "Sum two numbers",
+				Description: "Add a and b",
+				TestCode:    tt.testCode,
+				TestCases:   tt.testCases,
+				Difficulty:  DifficultyEasy,
+			}
+
+			v := validator.New()
+			ValidateChallenge(v, c)
+
+			if v.Valid() != tt.wantValid {
+				t.Errorf("ValidateChallenge() errors = %v, want valid %v", v.Errors, tt.wantValid)
+			}
+			if !tt.wantValid && v.Errors["test_code"] == "" {
+				t.Errorf("errors = %v, want one for test_code", v.Errors)
+			}
+		})
+	}
+}
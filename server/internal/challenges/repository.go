@@ -3,8 +3,28 @@ package challenges
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"apschool/internal/grader"
+	"apschool/internal/pagination"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+type RepositoryInterface interface {
+	Search(ctx context.Context, f SearchFilters, userID int) ([]Challenge, pagination.Metadata, error)
+	GetByID(ctx context.Context, id int) (*Challenge, error)
+	GetAnyByID(ctx context.Context, id int) (*Challenge, error)
+	ListAll(ctx context.Context, filters pagination.Filters) ([]Challenge, pagination.Metadata, error)
+	Insert(ctx context.Context, c *Challenge) error
+	Update(ctx context.Context, c *Challenge) error
+	SetActive(ctx context.Context, id int, active bool) error
+	RevealedHintCount(ctx context.Context, userID, challengeID int) (int, error)
+	InsertHintReveal(ctx context.Context, userID, challengeID, number int) error
+}
+
 type Repository struct {
 	db *sql.DB
 }
@@ -13,42 +33,65 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) GetByCategory(ctx context.Context, category string) ([]Challenge, error) {
+// Search lists active challenges matching every non-empty filter. With a
+// text query results are ranked by relevance, otherwise they follow unit
+// order. When userID is not 0 each challenge is flagged as solved or not.
+func (r *Repository) Search(ctx context.Context, f SearchFilters, userID int) ([]Challenge, pagination.Metadata, error) {
 
-	query := `SELECT id, title FROM challenges
-	WHERE category = $1 AND is_active = true
-	ORDER BY slug`
+	query := `SELECT count(*) OVER(), c.id, c.slug, c.category, c.title, c.difficulty, array_to_string(c.tags, ','), c.is_active,
+		EXISTS(SELECT 1 FROM submissions s WHERE s.challenge_id = c.id AND s.user_id = $5 AND s.passed = true)
+	FROM challenges c
+	LEFT JOIN categories cat ON cat.slug = c.category
+	WHERE c.is_active = true
+		AND ($1 = '' OR c.category = $1)
+		AND ($2 = '' OR c.search @@ websearch_to_tsquery('spanish', $2))
+		AND ($3 = '' OR $3 = ANY(c.tags))
+		AND ($4 = '' OR c.difficulty = $4)
+	ORDER BY
+		CASE WHEN $2 = '' THEN 0 ELSE ts_rank(c.search, websearch_to_tsquery('spanish', $2)) END DESC,
+		cat.display_order, c.category, c.slug
+	LIMIT $6 OFFSET $7`
 
-	rows, err := r.db.QueryContext(ctx, query, category)
+	rows, err := r.db.QueryContext(ctx, query, f.Category, f.Query, f.Tag, f.Difficulty, userID, f.Limit(), f.Offset())
 	if err != nil {
-		return nil, err
+		return nil, pagination.Metadata{}, err
 	}
 	defer rows.Close()
 
-	var challenges []Challenge
+	totalRecords := 0
+	challenges := []Challenge{}
 	for rows.Next() {
 		var c Challenge
-		if err := rows.Scan(&c.ID, &c.Title); err != nil {
-			return nil, err
+		var tags string
+		var solved bool
+		err := rows.Scan(&totalRecords, &c.ID, &c.Slug, &c.Category, &c.Title, &c.Difficulty, &tags, &c.IsActive, &solved)
+		if err != nil {
+			return nil, pagination.Metadata{}, err
+		}
+		c.Tags = splitTags(tags)
+		if userID != 0 {
+			c.Solved = &solved
 		}
 		challenges = append(challenges, c)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, pagination.Metadata{}, err
 	}
 
-	return challenges, nil
+	return challenges, pagination.NewMetadata(totalRecords, f.Filters), nil
 }
 
 func (r *Repository) GetByID(ctx context.Context, id int) (*Challenge, error) {
 
-	query := `SELECT id, slug, category, title, description, template, test_code, hints, is_active, created_at, updated_at
+	query := `SELECT id, slug, category, title, description, template, test_code, test_cases, hints, difficulty, array_to_string(tags, ','), is_active, created_at, updated_at
 	FROM challenges
 	WHERE id = $1 AND is_active = true
 	`
 
 	var c Challenge
+	var tags string
+	var testCases []byte
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&c.ID,
@@ -58,7 +101,10 @@ func (r *Repository) GetByID(ctx context.Context, id int) (*Challenge, error) {
 		&c.Description,
 		&c.Template,
 		&c.TestCode,
+		&testCases,
 		&c.Hints,
+		&c.Difficulty,
+		&tags,
 		&c.IsActive,
 		&c.CreatedAt,
 		&c.UpdatedAt,
@@ -67,7 +113,234 @@ func (r *Repository) GetByID(ctx context.Context, id int) (*Challenge, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.Tags = splitTags(tags)
+
+	if err := json.Unmarshal(testCases, &c.TestCases); err != nil {
+		return nil, err
+	}
 
 	return &c, nil
 
 }
+
+// GetAnyByID also returns inactive challenges, the hidden tests and the
+// reference solution; it backs the admin endpoints.
+func (r *Repository) GetAnyByID(ctx context.Context, id int) (*Challenge, error) {
+
+	query := `SELECT id, slug, category, title, description, template, test_code, hidden_test_code, test_cases, solution, hints, difficulty, array_to_string(tags, ','), is_active, created_at, updated_at
+	FROM challenges
+	WHERE id = $1
+	`
+
+	var c Challenge
+	var tags string
+	var testCases []byte
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&c.ID,
+		&c.Slug,
+		&c.Category,
+		&c.Title,
+		&c.Description,
+		&c.Template,
+		&c.TestCode,
+		&c.HiddenTestCode,
+		&testCases,
+		&c.Solution,
+		&c.Hints,
+		&c.Difficulty,
+		&tags,
+		&c.IsActive,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	c.Tags = splitTags(tags)
+
+	if err := json.Unmarshal(testCases, &c.TestCases); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *Repository) ListAll(ctx context.Context, filters pagination.Filters) ([]Challenge, pagination.Metadata, error) {
+
+	query := `SELECT count(*) OVER(), id, slug, category, title, difficulty, array_to_string(tags, ','), is_active, created_at, updated_at
+	FROM challenges
+	ORDER BY category, slug
+	LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.QueryContext(ctx, query, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	challenges := []Challenge{}
+	for rows.Next() {
+		var c Challenge
+		var tags string
+		if err := rows.Scan(&totalRecords, &c.ID, &c.Slug, &c.Category, &c.Title, &c.Difficulty, &tags, &c.IsActive, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, pagination.Metadata{}, err
+		}
+		c.Tags = splitTags(tags)
+		challenges = append(challenges, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+
+	return challenges, pagination.NewMetadata(totalRecords, filters), nil
+}
+
+func (r *Repository) Insert(ctx context.Context, c *Challenge) error {
+	testCases, err := marshalTestCases(c.TestCases)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO challenges (slug, category, title, description, template, test_code, hidden_test_code, test_cases, solution, hints, difficulty, tags, is_active)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	RETURNING id, created_at, updated_at`
+
+	err = r.db.QueryRowContext(ctx, query,
+		c.Slug,
+		c.Category,
+		c.Title,
+		c.Description,
+		c.Template,
+		c.TestCode,
+		c.HiddenTestCode,
+		testCases,
+		c.Solution,
+		c.Hints,
+		c.Difficulty,
+		c.Tags,
+		c.IsActive,
+	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+
+	return translateError(err)
+}
+
+func (r *Repository) Update(ctx context.Context, c *Challenge) error {
+	testCases, err := marshalTestCases(c.TestCases)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE challenges SET
+		slug = $2,
+		category = $3,
+		title = $4,
+		description = $5,
+		template = $6,
+		test_code = $7,
+		hidden_test_code = $8,
+		test_cases = $9,
+		solution = $10,
+		hints = $11,
+		difficulty = $12,
+		tags = $13,
+		is_active = $14,
+		updated_at = NOW()
+	WHERE id = $1
+	RETURNING updated_at`
+
+	err = r.db.QueryRowContext(ctx, query,
+		c.ID,
+		c.Slug,
+		c.Category,
+		c.Title,
+		c.Description,
+		c.Template,
+		c.TestCode,
+		c.HiddenTestCode,
+		testCases,
+		c.Solution,
+		c.Hints,
+		c.Difficulty,
+		c.Tags,
+		c.IsActive,
+	).Scan(&c.UpdatedAt)
+
+	return translateError(err)
+}
+
+func (r *Repository) SetActive(ctx context.Context, id int, active bool) error {
+
+	query := `UPDATE challenges SET is_active = $2, updated_at = NOW()
+	WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id, active)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// RevealedHintCount returns how many of the challenge's hints the user has
+// revealed. Hints are revealed in order, so they are always the first ones.
+func (r *Repository) RevealedHintCount(ctx context.Context, userID, challengeID int) (int, error) {
+	var count int
+
+	query := `SELECT count(*) FROM hint_reveals WHERE user_id = $1 AND challenge_id = $2`
+
+	err := r.db.QueryRowContext(ctx, query, userID, challengeID).Scan(&count)
+	return count, err
+}
+
+// InsertHintReveal records that the user revealed a hint. Revealing it again
+// keeps the original time.
+func (r *Repository) InsertHintReveal(ctx context.Context, userID, challengeID, number int) error {
+
+	query := `INSERT INTO hint_reveals (user_id, challenge_id, hint_number)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, challenge_id, hint_number) DO NOTHING`
+
+	_, err := r.db.ExecContext(ctx, query, userID, challengeID, number)
+	return err
+}
+
+func marshalTestCases(cases []grader.Case) ([]byte, error) {
+	if cases == nil {
+		cases = []grader.Case{}
+	}
+	return json.Marshal(cases)
+}
+
+// splitTags undoes array_to_string; tags are slugs and never contain commas.
+func splitTags(tags string) []string {
+	if tags == "" {
+		return []string{}
+	}
+	return strings.Split(tags, ",")
+}
+
+// translateError maps the slug and category constraints to service errors.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505" && pgErr.ConstraintName == "challenges_slug_key":
+			return ErrDuplicateSlug
+		case pgErr.Code == "23503" && pgErr.ConstraintName == "challenges_category_fkey":
+			return ErrUnknownCategory
+		}
+	}
+	return err
+}
@@ -0,0 +1,300 @@
+package challenges
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"slices"
+	"testing"
+
+	"apschool/internal/pagination"
+	"apschool/internal/testutil"
+)
+
+var testDB *testutil.TestDB
+
+func TestMain(m *testing.M) {
+	// Parse flags first so we can check -short
+	flag.Parse()
+
+	// Skip container setup entirely in short mode (for CI without Docker)
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	ctx := context.Background()
+
+	var err error
+	testDB, err = testutil.SetupTestDB(ctx)
+	if err != nil {
+		panic("failed to setup test db: " + err.Error())
+	}
+
+	code := m.Run()
+
+	testDB.Teardown(ctx)
+	os.Exit(code)
+}
+
+// setupRepository empties the catalog, which users don't cascade to, and
+// creates the given categories in display order.
+func setupRepository(t *testing.T, categories ...string) *Repository {
+	t.Helper()
+
+	testDB.TruncateTables(t)
+	if _, err := testDB.DB.Exec("TRUNCATE challenges, categories CASCADE"); err != nil {
+		t.Fatalf("failed to truncate challenges: %v", err)
+	}
+	for i, slug := range categories {
+		_, err := testDB.DB.Exec(`INSERT INTO categories (slug, title, display_order) VALUES ($1, $1, $2)`, slug, i)
+		if err != nil {
+			t.Fatalf("failed to create category %s: %v", slug, err)
+		}
+	}
+
+	return NewRepository(testDB.DB)
+}
+
+func newChallenge(slug, category string) *Challenge {
+	return &Challenge{
+		Slug:        slug,
+		Category:    category,
+		Title:       slug,
+		Description: "Description of " + slug,
+		TestCode:    "assert True",
+		Difficulty:  DifficultyEasy,
+		Tags:        []string{},
+		IsActive:    true,
+	}
+}
+
+func TestRepository_Insert(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t, "basics")
+	ctx := context.Background()
+
+	if err := repo.Insert(ctx, newChallenge("hello-world", "basics")); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		challenge *Challenge
+		wantErr   error
+	}{
+		{name: "duplicate slug", challenge: newChallenge("hello-world", "basics"), wantErr: ErrDuplicateSlug},
+		{name: "unknown category", challenge: newChallenge("loop-sum", "loops"), wantErr: ErrUnknownCategory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.Insert(ctx, tt.challenge); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Insert() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRepository_Update(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t, "basics")
+	ctx := context.Background()
+
+	first, second := newChallenge("hello-world", "basics"), newChallenge("goodbye", "basics")
+	for _, c := range []*Challenge{first, second} {
+		if err := repo.Insert(ctx, c); err != nil {
+			t.Fatalf("Insert() error = %v", err)
+		}
+	}
+
+	second.Slug = "hello-world"
+	if err := repo.Update(ctx, second); !errors.Is(err, ErrDuplicateSlug) {
+		t.Errorf("Update() to a taken slug error = %v, want %v", err, ErrDuplicateSlug)
+	}
+
+	second.Slug, second.Category = "goodbye", "loops"
+	if err := repo.Update(ctx, second); !errors.Is(err, ErrUnknownCategory) {
+		t.Errorf("Update() to an unknown category error = %v, want %v", err, ErrUnknownCategory)
+	}
+
+	second.Category, second.Title = "basics", "Goodbye"
+	if err := repo.Update(ctx, second); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, err := repo.GetAnyByID(ctx, second.ID)
+	if err != nil || got.Title != "Goodbye" || got.Slug != "goodbye" {
+		t.Errorf("GetAnyByID() = %+v, %v, want the updated title", got, err)
+	}
+}
+
+// seedSearch creates challenges in two categories, loops listed first, and
+// returns their ids by slug.
+func seedSearch(t *testing.T) (*Repository, map[string]int) {
+	t.Helper()
+
+	repo := setupRepository(t, "loops", "basics")
+
+	challenges := []*Challenge{
+		{Slug: "hello-world", Category: "basics", Title: "Hola mundo", Description: "Imprime un saludo", Difficulty: DifficultyEasy, Tags: []string{"strings"}},
+		{Slug: "factorial", Category: "loops", Title: "Factorial", Description: "Calcula el producto de los números hasta n", Difficulty: DifficultyMedium, Tags: []string{"math", "recursion"}},
+		{Slug: "sum-list", Category: "loops", Title: "Suma de una lista", Description: "Recorre la lista; como en factorial, acumula un resultado", Difficulty: DifficultyEasy, Tags: []string{"math"}},
+		{Slug: "retired", Category: "basics", Title: "Factorial antiguo", Description: "Ya no se usa", Difficulty: DifficultyEasy, Tags: []string{"math"}},
+	}
+
+	ids := make(map[string]int)
+	for _, c := range challenges {
+		c.TestCode = "assert True"
+		c.IsActive = c.Slug != "retired"
+		if err := repo.Insert(context.Background(), c); err != nil {
+			t.Fatalf("Insert(%s) error = %v", c.Slug, err)
+		}
+		ids[c.Slug] = c.ID
+	}
+
+	return repo, ids
+}
+
+func TestRepository_Search(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, _ := seedSearch(t)
+	page := pagination.Filters{Page: 1, PageSize: 20}
+
+	tests := []struct {
+		name    string
+		filters SearchFilters
+		want    []string
+	}{
+		{
+			// Every empty filter short-circuits, leaving unit order
+			name:    "no filters",
+			filters: SearchFilters{},
+			want:    []string{"factorial", "sum-list", "hello-world"},
+		},
+		{
+			name:    "category",
+			filters: SearchFilters{Category: "basics"},
+			want:    []string{"hello-world"},
+		},
+		{
+			name:    "tag",
+			filters: SearchFilters{Tag: "math"},
+			want:    []string{"factorial", "sum-list"},
+		},
+		{
+			name:    "tag is matched whole",
+			filters: SearchFilters{Tag: "mat"},
+			want:    []string{},
+		},
+		{
+			name:    "difficulty",
+			filters: SearchFilters{Difficulty: DifficultyEasy},
+			want:    []string{"sum-list", "hello-world"},
+		},
+		{
+			// A title match outranks a description one, whatever the unit order
+			name:    "query ranks title matches first",
+			filters: SearchFilters{Query: "factorial"},
+			want:    []string{"factorial", "sum-list"},
+		},
+		{
+			name:    "query is stemmed",
+			filters: SearchFilters{Query: "saludos"},
+			want:    []string{"hello-world"},
+		},
+		{
+			name:    "filters combine",
+			filters: SearchFilters{Query: "factorial", Difficulty: DifficultyEasy},
+			want:    []string{"sum-list"},
+		},
+		{
+			name:    "nothing matches",
+			filters: SearchFilters{Query: "grafos"},
+			want:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filters.Filters = page
+			challenges, metadata, err := repo.Search(context.Background(), tt.filters, 0)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+
+			got := []string{}
+			for _, c := range challenges {
+				got = append(got, c.Slug)
+				if c.Solved != nil {
+					t.Errorf("%s has a solved flag for an anonymous caller", c.Slug)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Search() = %v, want %v", got, tt.want)
+			}
+			if metadata.TotalRecords != len(tt.want) {
+				t.Errorf("total_records = %d, want %d", metadata.TotalRecords, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestRepository_SearchPaginates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, _ := seedSearch(t)
+
+	challenges, metadata, err := repo.Search(context.Background(), SearchFilters{Filters: pagination.Filters{Page: 2, PageSize: 2}}, 0)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(challenges) != 1 || challenges[0].Slug != "hello-world" {
+		t.Errorf("page 2 = %v, want only hello-world", challenges)
+	}
+	if metadata.TotalRecords != 3 || metadata.LastPage != 2 {
+		t.Errorf("metadata = %+v, want 3 records over 2 pages", metadata)
+	}
+}
+
+func TestRepository_SearchSolved(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := seedSearch(t)
+	ctx := context.Background()
+
+	var userID int
+	err := testDB.DB.QueryRow(`INSERT INTO users (username, email) VALUES ('ana', 'ana@example.com') RETURNING id`).Scan(&userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testDB.DB.Exec(`INSERT INTO submissions (user_id, challenge_id, code, passed, status) VALUES ($1, $2, 'x', true, 'passed'), ($1, $3, 'x', false, 'failed')`,
+		userID, ids["factorial"], ids["sum-list"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenges, _, err := repo.Search(ctx, SearchFilters{Filters: pagination.Filters{Page: 1, PageSize: 20}}, userID)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	for _, c := range challenges {
+		want := c.Slug == "factorial"
+		if c.Solved == nil || *c.Solved != want {
+			t.Errorf("%s solved = %v, want %v", c.Slug, c.Solved, want)
+		}
+	}
+}
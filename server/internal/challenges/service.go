@@ -4,22 +4,31 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+
+	"apschool/internal/grader"
+	"apschool/internal/pagination"
 )
 
 var (
 	ErrChallengeNotFound = errors.New("challenge not found")
+	ErrDuplicateSlug     = errors.New("duplicate slug")
+	ErrUnknownCategory   = errors.New("unknown category")
+	ErrHintNotFound      = errors.New("hint not found")
+	ErrHintLocked        = errors.New("previous hints not revealed")
 )
 
 type Service struct {
-	repo *Repository
+	repo RepositoryInterface
 }
 
-func NewService(repo *Repository) *Service {
+func NewService(repo RepositoryInterface) *Service {
 	return &Service{repo: repo}
 }
 
-func (s *Service) GetChallenges(ctx context.Context, category string) ([]Challenge, error) {
-	return s.repo.GetByCategory(ctx, category)
+// SearchChallenges lists active challenges. userID is 0 for anonymous
+// callers, who get no solved flags.
+func (s *Service) SearchChallenges(ctx context.Context, filters SearchFilters, userID int) ([]Challenge, pagination.Metadata, error) {
+	return s.repo.Search(ctx, filters, userID)
 }
 
 func (s *Service) GetChallengeByID(ctx context.Context, id int) (*Challenge, error) {
@@ -33,3 +42,95 @@ func (s *Service) GetChallengeByID(ctx context.Context, id int) (*Challenge, err
 
 	return challenge, nil
 }
+
+// GetChallengeForUser returns the challenge as students see it: the hints
+// are replaced by their count plus the ones the user already revealed, and
+// only public test cases are included. userID is 0 for anonymous callers.
+func (s *Service) GetChallengeForUser(ctx context.Context, id, userID int) (*Challenge, error) {
+	challenge, err := s.GetChallengeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge.TestCases = grader.PublicCases(challenge.TestCases)
+
+	hints := SplitHints(challenge.Hints)
+	challenge.Hints = ""
+	challenge.HintCount = len(hints)
+
+	if userID != 0 && len(hints) > 0 {
+		revealed, err := s.repo.RevealedHintCount(ctx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		challenge.RevealedHints = hints[:min(revealed, len(hints))]
+	}
+
+	return challenge, nil
+}
+
+// RevealHint returns hint number n and records that the user saw it. Hints
+// must be revealed in order; asking for one again is allowed.
+func (s *Service) RevealHint(ctx context.Context, challengeID, userID, n int) (*Hint, error) {
+	challenge, err := s.GetChallengeByID(ctx, challengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	hints := SplitHints(challenge.Hints)
+	if n < 1 || n > len(hints) {
+		return nil, ErrHintNotFound
+	}
+
+	revealed, err := s.repo.RevealedHintCount(ctx, userID, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	if n > revealed+1 {
+		return nil, ErrHintLocked
+	}
+
+	if err := s.repo.InsertHintReveal(ctx, userID, challengeID, n); err != nil {
+		return nil, err
+	}
+
+	return &hints[n-1], nil
+}
+
+func (s *Service) GetAnyChallengeByID(ctx context.Context, id int) (*Challenge, error) {
+	challenge, err := s.repo.GetAnyByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrChallengeNotFound
+		}
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+func (s *Service) ListAllChallenges(ctx context.Context, filters pagination.Filters) ([]Challenge, pagination.Metadata, error) {
+	return s.repo.ListAll(ctx, filters)
+}
+
+func (s *Service) CreateChallenge(ctx context.Context, challenge *Challenge) error {
+	return s.repo.Insert(ctx, challenge)
+}
+
+func (s *Service) UpdateChallenge(ctx context.Context, challenge *Challenge) error {
+	err := s.repo.Update(ctx, challenge)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrChallengeNotFound
+	}
+	return err
+}
+
+// SetChallengeActive toggles visibility. Challenges are never hard-deleted so
+// existing submissions keep pointing at them.
+func (s *Service) SetChallengeActive(ctx context.Context, id int, active bool) error {
+	err := s.repo.SetActive(ctx, id, active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrChallengeNotFound
+	}
+	return err
+}
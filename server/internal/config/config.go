@@ -0,0 +1,283 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"apschool/internal/validator"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// MinSecretLength is the shortest JWT_SECRET accepted, the size of an HS256
+// key.
+const MinSecretLength = 32
+
+const (
+	GraderServer = "server"
+	GraderClient = "client"
+)
+
+// Config holds every setting of the server and the command line tools. It is
+// read once at startup by Load and then passed to whatever needs it.
+type Config struct {
+	Port        int      `yaml:"port"`
+	DB          Database `yaml:"database"`
+	JWTSecret   string   `yaml:"jwt_secret"`
+	FrontendURL string   `yaml:"frontend_url"`
+	GitHub      GitHub   `yaml:"github"`
+	Grader      Grader   `yaml:"grader"`
+	JobWorkers  int      `yaml:"job_workers"`
+}
+
+type Database struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Name     string `yaml:"name"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Schema   string `yaml:"schema"`
+}
+
+// GitHub configures the OAuth login. AuthURL and APIURL only need changing
+// for GitHub Enterprise or a fake server.
+type GitHub struct {
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	RedirectURI  string `yaml:"redirect_uri"`
+	AuthURL      string `yaml:"auth_url"`
+	APIURL       string `yaml:"api_url"`
+}
+
+// Grader selects how submissions are verified. Mode client trusts the
+// browser's verdict and needs no Python on the server.
+type Grader struct {
+	Mode   string `yaml:"mode"`
+	Python string `yaml:"python"`
+}
+
+func defaults() *Config {
+	return &Config{
+		Port:       8080,
+		DB:         Database{Port: 5432, Schema: "public"},
+		GitHub:     GitHub{AuthURL: "https://github.com", APIURL: "https://api.github.com"},
+		Grader:     Grader{Mode: GraderServer, Python: "python3"},
+		JobWorkers: 2,
+	}
+}
+
+// setting is one value that can come from the environment and, unless flag
+// is empty, from the command line. Secrets have no flag so they stay out of
+// the process list.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	value any // *string or *int
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"PORT", "port", "port the API listens on", &c.Port},
+		{"APSCHOOL_DB_HOST", "db-host", "database host", &c.DB.Host},
+		{"APSCHOOL_DB_PORT", "db-port", "database port", &c.DB.Port},
+		{"APSCHOOL_DB_DATABASE", "db-name", "database name", &c.DB.Name},
+		{"APSCHOOL_DB_USERNAME", "db-user", "database user", &c.DB.Username},
+		{"APSCHOOL_DB_PASSWORD", "", "", &c.DB.Password},
+		{"APSCHOOL_DB_SCHEMA", "db-schema", "database schema", &c.DB.Schema},
+		{"JWT_SECRET", "", "", &c.JWTSecret},
+		{"FRONTEND_URL", "frontend-url", "URL of the web app, where logins end up", &c.FrontendURL},
+		{"GITHUB_CLIENT_ID", "github-client-id", "GitHub OAuth app client id", &c.GitHub.ClientID},
+		{"GITHUB_CLIENT_SECRET", "", "", &c.GitHub.ClientSecret},
+		{"GITHUB_REDIRECT_URI", "github-redirect-uri", "GitHub OAuth callback URL", &c.GitHub.RedirectURI},
+		{"GITHUB_AUTH_URL", "github-auth-url", "base URL of GitHub's OAuth endpoints", &c.GitHub.AuthURL},
+		{"GITHUB_API_URL", "github-api-url", "base URL of GitHub's REST API", &c.GitHub.APIURL},
+		{"GRADER_MODE", "grader-mode", "server or client", &c.Grader.Mode},
+		{"GRADER_PYTHON", "python", "python interpreter used to grade", &c.Grader.Python},
+		{"JOB_WORKERS", "job-workers", "jobs the API runs at once, 0 leaves them to cmd/worker", &c.JobWorkers},
+	}
+}
+
+// Load reads the configuration, each source overriding the previous one:
+// defaults, the YAML file given with -config or APSCHOOL_CONFIG, .env, the
+// environment and finally the flags. It registers its flags on flags and
+// parses args, so callers define their own flags on the set first.
+func Load(flags *flag.FlagSet, args []string) (*Config, error) {
+	cfg := defaults()
+
+	configPath := flags.String("config", "", "path to a YAML config file (default $APSCHOOL_CONFIG)")
+
+	// Flags win over everything else, so they are applied last
+	var fromFlags []func() error
+	for _, s := range cfg.settings() {
+		if s.flag == "" {
+			continue
+		}
+		flags.Func(s.flag, s.usage+" ($"+s.env+")", func(raw string) error {
+			if err := check(s, raw); err != nil {
+				return err
+			}
+			fromFlags = append(fromFlags, func() error { return set(s, raw) })
+			return nil
+		})
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// Variables already in the environment take precedence over .env
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading .env: %w", err)
+	}
+
+	if path := cmp.Or(*configPath, os.Getenv("APSCHOOL_CONFIG")); path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range cfg.settings() {
+		if raw := os.Getenv(s.env); raw != "" {
+			if err := set(s, raw); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, apply := range fromFlags {
+		if err := apply(); err != nil {
+			return nil, err
+		}
+	}
+
+	v := validator.New()
+	if ValidateConfig(v, cfg); !v.Valid() {
+		return nil, validationError(v)
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ValidateConfig checks the settings every command needs.
+func ValidateConfig(v *validator.Validator, c *Config) {
+	v.Check(c.Port > 0 && c.Port <= 65535, "PORT", "must be a valid port number")
+
+	v.Check(validator.NotBlank(c.DB.Host), "APSCHOOL_DB_HOST", "is required")
+	v.Check(c.DB.Port > 0 && c.DB.Port <= 65535, "APSCHOOL_DB_PORT", "must be a valid port number")
+	v.Check(validator.NotBlank(c.DB.Name), "APSCHOOL_DB_DATABASE", "is required")
+	v.Check(validator.NotBlank(c.DB.Username), "APSCHOOL_DB_USERNAME", "is required")
+	v.Check(validator.NotBlank(c.DB.Schema), "APSCHOOL_DB_SCHEMA", "is required")
+
+	v.Check(validator.PermittedValue(c.Grader.Mode, GraderServer, GraderClient), "GRADER_MODE", "must be server or client")
+	v.Check(validator.NotBlank(c.Grader.Python), "GRADER_PYTHON", "is required")
+	v.Check(c.JobWorkers >= 0, "JOB_WORKERS", "must not be negative")
+}
+
+// ValidateServer adds the checks for the settings only the API uses. An empty
+// JWT_SECRET would sign tokens anyone can forge. GitHub login is optional
+// but needs all of its settings once any of them is set.
+func ValidateServer(v *validator.Validator, c *Config) {
+	v.Check(c.JWTSecret != "", "JWT_SECRET", "is required")
+	v.Check(c.JWTSecret == "" || len(c.JWTSecret) >= MinSecretLength, "JWT_SECRET", fmt.Sprintf("must be at least %d bytes long", MinSecretLength))
+
+	if c.GitHub.Enabled() || c.GitHub.ClientSecret != "" || c.GitHub.RedirectURI != "" {
+		v.Check(c.GitHub.ClientID != "", "GITHUB_CLIENT_ID", "is required when GitHub login is configured")
+		v.Check(c.GitHub.ClientSecret != "", "GITHUB_CLIENT_SECRET", "is required when GitHub login is configured")
+		v.Check(isURL(c.GitHub.RedirectURI), "GITHUB_REDIRECT_URI", "must be an absolute URL")
+		v.Check(isURL(c.GitHub.AuthURL), "GITHUB_AUTH_URL", "must be an absolute URL")
+		v.Check(isURL(c.GitHub.APIURL), "GITHUB_API_URL", "must be an absolute URL")
+		v.Check(isURL(c.FrontendURL), "FRONTEND_URL", "must be an absolute URL")
+	}
+}
+
+// CheckServer checks the API's own settings on top of those checked by Load.
+func (c *Config) CheckServer() error {
+	v := validator.New()
+	if ValidateServer(v, c); !v.Valid() {
+		return validationError(v)
+	}
+	return nil
+}
+
+// Enabled reports whether GitHub login is configured.
+func (g GitHub) Enabled() bool {
+	return g.ClientID != ""
+}
+
+// URL is the connection string for the pgx driver.
+func (d Database) URL() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(d.Username, d.Password),
+		Host:     d.Host + ":" + strconv.Itoa(d.Port),
+		Path:     d.Name,
+		RawQuery: url.Values{"sslmode": {"disable"}, "search_path": {d.Schema}}.Encode(),
+	}
+	return u.String()
+}
+
+func check(s setting, raw string) error {
+	if _, ok := s.value.(*int); ok {
+		if _, err := strconv.Atoi(raw); err != nil {
+			return fmt.Errorf("must be an integer, got %q", raw)
+		}
+	}
+	return nil
+}
+
+func set(s setting, raw string) error {
+	switch p := s.value.(type) {
+	case *string:
+		*p = raw
+	case *int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer, got %q", s.env, raw)
+		}
+		*p = n
+	}
+	return nil
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// validationError lists every problem at once so a bad deployment can be
+// fixed in one go.
+func validationError(v *validator.Validator) error {
+	problems := make([]string, 0, len(v.Errors))
+	for _, key := range slices.Sorted(maps.Keys(v.Errors)) {
+		problems = append(problems, key+" "+v.Errors[key])
+	}
+	return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+}
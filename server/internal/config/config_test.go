@@ -0,0 +1,189 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearEnv blanks every variable Load reads, so the tests don't depend on
+// the machine they run on.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, s := range defaults().settings() {
+		t.Setenv(s.env, "")
+	}
+	t.Setenv("APSCHOOL_CONFIG", "")
+}
+
+func setDBEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("APSCHOOL_DB_HOST", "localhost")
+	t.Setenv("APSCHOOL_DB_DATABASE", "apschool")
+	t.Setenv("APSCHOOL_DB_USERNAME", "apschool")
+}
+
+func load(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return Load(flags, args)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	clearEnv(t)
+	setDBEnv(t)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := "port: 9000\njob_workers: 7\ndatabase:\n  schema: from_file\ngrader:\n  python: /usr/bin/python3.12\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PORT", "9100")
+	t.Setenv("APSCHOOL_DB_SCHEMA", "from_env")
+
+	cfg, err := load(t, "-config", path, "-port", "9200")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"flag beats env and file", cfg.Port, 9200},
+		{"env beats file", cfg.DB.Schema, "from_env"},
+		{"file beats default", cfg.JobWorkers, 7},
+		{"file only", cfg.Grader.Python, "/usr/bin/python3.12"},
+		{"default", cfg.DB.Port, 5432},
+		{"env only", cfg.DB.Host, "localhost"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		file    string
+		wantErr string
+	}{
+		{
+			name:    "missing database settings",
+			env:     map[string]string{"APSCHOOL_DB_HOST": ""},
+			wantErr: "APSCHOOL_DB_HOST is required",
+		},
+		{
+			name:    "non numeric env",
+			env:     map[string]string{"APSCHOOL_DB_PORT": "postgres"},
+			wantErr: "APSCHOOL_DB_PORT must be an integer",
+		},
+		{
+			name:    "non numeric flag",
+			args:    []string{"-job-workers", "many"},
+			wantErr: "must be an integer",
+		},
+		{
+			name:    "unknown grader mode",
+			args:    []string{"-grader-mode", "browser"},
+			wantErr: "GRADER_MODE must be server or client",
+		},
+		{
+			name:    "unknown key in file",
+			file:    "jwt_secrt: typo\n",
+			wantErr: "field jwt_secrt not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			setDBEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			args := tt.args
+			if tt.file != "" {
+				path := filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, "-config", path)
+			}
+
+			_, err := load(t, args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckServer(t *testing.T) {
+	secret := strings.Repeat("s", MinSecretLength)
+
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{"valid without github", func(c *Config) {}, ""},
+		{"missing secret", func(c *Config) { c.JWTSecret = "" }, "JWT_SECRET is required"},
+		{"short secret", func(c *Config) { c.JWTSecret = "secret" }, "JWT_SECRET must be at least"},
+		{"valid with github", func(c *Config) {
+			c.GitHub.ClientID, c.GitHub.ClientSecret, c.GitHub.RedirectURI = "id", "shh", "http://localhost:8080/api/auth/github/callback"
+			c.FrontendURL = "http://localhost:4200"
+		}, ""},
+		{"partial github", func(c *Config) { c.GitHub.ClientID = "id" }, "GITHUB_CLIENT_SECRET is required"},
+		{"github without frontend", func(c *Config) {
+			c.GitHub.ClientID, c.GitHub.ClientSecret, c.GitHub.RedirectURI = "id", "shh", "http://localhost:8080/api/auth/github/callback"
+		}, "FRONTEND_URL must be an absolute URL"},
+		{"github with bad api url", func(c *Config) {
+			c.GitHub.ClientID, c.GitHub.ClientSecret, c.GitHub.RedirectURI = "id", "shh", "http://localhost:8080/api/auth/github/callback"
+			c.GitHub.APIURL = "api.github.com"
+			c.FrontendURL = "http://localhost:4200"
+		}, "GITHUB_API_URL must be an absolute URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaults()
+			cfg.JWTSecret = secret
+			tt.modify(cfg)
+
+			err := cfg.CheckServer()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckServer() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckServer() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatabase_URL(t *testing.T) {
+	db := Database{Host: "db", Port: 5433, Name: "apschool", Username: "app", Password: "p@ss/word", Schema: "public"}
+
+	want := "postgres://app:p%40ss%2Fword@db:5433/apschool?search_path=public&sslmode=disable"
+	if got := db.URL(); got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,269 @@
+package courses
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"apschool/internal/ctxkeys"
+	"apschool/internal/pagination"
+	"apschool/internal/response"
+	"apschool/internal/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+func (h *Handler) CreateCourseHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+
+	var input struct {
+		Name  string   `json:"name"`
+		Term  string   `json:"term"`
+		Units []string `json:"units"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	course := &Course{
+		Name:    input.Name,
+		Term:    input.Term,
+		OwnerID: userID,
+		Units:   input.Units,
+	}
+	if course.Units == nil {
+		course.Units = []string{}
+	}
+
+	v := validator.New()
+	if ValidateCourse(v, course); !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	err := h.service.CreateCourse(r.Context(), course)
+	if err != nil {
+		if errors.Is(err, ErrUnknownUnit) {
+			v.AddError("units", "contains a unit that does not exist")
+			response.ValidationError(w, v.Errors)
+			return
+		}
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusCreated, response.Envelope{"course": course}, nil)
+}
+
+func (h *Handler) ListCoursesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+
+	courses, err := h.service.ListCourses(r.Context(), userID)
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"courses": courses}, nil)
+}
+
+func (h *Handler) GetCourseHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, courseID, ok := h.courseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	course, err := h.service.GetCourse(r.Context(), courseID, userID, role)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"course": course}, nil)
+}
+
+func (h *Handler) JoinCourseHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+
+	var input struct {
+		Code string `json:"code"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	v := validator.New()
+	v.Check(validator.NotBlank(input.Code), "code", "is required")
+
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	course, err := h.service.JoinCourse(r.Context(), userID, input.Code)
+	if err != nil {
+		if errors.Is(err, ErrInvalidJoinCode) {
+			v.AddError("code", "is not a valid join code")
+			response.ValidationError(w, v.Errors)
+			return
+		}
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"course": course}, nil)
+}
+
+func (h *Handler) SetUnitsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, courseID, ok := h.courseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Units []string `json:"units"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.Units != nil, "units", "is required")
+	if ValidateUnits(v, input.Units); !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	course, err := h.service.SetUnits(r.Context(), courseID, userID, role, input.Units)
+	if err != nil {
+		if errors.Is(err, ErrUnknownUnit) {
+			v.AddError("units", "contains a unit that does not exist")
+			response.ValidationError(w, v.Errors)
+			return
+		}
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"course": course}, nil)
+}
+
+func (h *Handler) RegenerateJoinCodeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, courseID, ok := h.courseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	code, err := h.service.RegenerateJoinCode(r.Context(), courseID, userID, role)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"join_code": code}, nil)
+}
+
+func (h *Handler) ListMembersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, courseID, ok := h.courseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := h.service.ListMembers(r.Context(), courseID, userID, role)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"members": members}, nil)
+}
+
+func (h *Handler) ListSubmissionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, courseID, ok := h.courseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	qs := r.URL.Query()
+	v := validator.New()
+	filters := pagination.FromQuery(qs, v)
+
+	studentID := 0
+	if s := qs.Get("user_id"); s != "" {
+		id, err := strconv.Atoi(s)
+		v.Check(err == nil && id > 0, "user_id", "must be a positive integer")
+		studentID = id
+	}
+
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	submissions, metadata, err := h.service.ListSubmissions(r.Context(), courseID, userID, role, studentID, filters)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"submissions": submissions, "metadata": metadata}, nil)
+}
+
+// courseRequest reads the caller and the {id} URL param shared by the
+// per-course endpoints. It writes the error response itself when it fails.
+func (h *Handler) courseRequest(w http.ResponseWriter, r *http.Request) (userID int, role string, courseID int, ok bool) {
+	userID, ok = ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return 0, "", 0, false
+	}
+	role, _ = ctxkeys.GetRole(r.Context())
+
+	courseID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid id")
+		return 0, "", 0, false
+	}
+
+	return userID, role, courseID, true
+}
+
+func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrCourseNotFound), errors.Is(err, ErrNotCourseMember):
+		// Non-members can't tell a private course from a missing one
+		response.NotFound(w)
+	case errors.Is(err, ErrNotCourseInstructor):
+		response.Forbidden(w)
+	default:
+		response.ServerError(w, r, h.logger, err)
+	}
+}
@@ -0,0 +1,148 @@
+package courses
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"apschool/internal/auth"
+	"apschool/internal/ctxkeys"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newCourseRouter mounts the course handlers the way routes.go does, minus
+// the auth middleware.
+func newCourseRouter(repo *Repository) http.Handler {
+	h := NewHandler(NewService(repo), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r := chi.NewRouter()
+	r.Post("/api/courses/join", h.JoinCourseHandler)
+	r.Get("/api/courses/{id}", h.GetCourseHandler)
+	r.Put("/api/courses/{id}/units", h.SetUnitsHandler)
+	r.Post("/api/courses/{id}/join-code", h.RegenerateJoinCodeHandler)
+	r.Get("/api/courses/{id}/members", h.ListMembersHandler)
+	r.Get("/api/courses/{id}/submissions", h.ListSubmissionsHandler)
+	return r
+}
+
+// serveAs sends the request as the given user, the way RequireAuth leaves
+// the context.
+func serveAs(router http.Handler, userID int, role, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx := context.WithValue(req.Context(), ctxkeys.UserIDKey, userID)
+	ctx = context.WithValue(ctx, ctxkeys.RoleKey, role)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req.WithContext(ctx))
+	return rr
+}
+
+func TestCourseHandlers_Permissions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t, "unit-1", "unit-2")
+	router := newCourseRouter(repo)
+
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	otherInstructor := createUser(t, "otro", auth.RoleInstructor)
+	student := createUser(t, "ana", auth.RoleStudent)
+	outsider := createUser(t, "beto", auth.RoleStudent)
+	admin := createUser(t, "admin", auth.RoleAdmin)
+
+	course := createCourse(t, repo, owner, "ABCD2345", "unit-1")
+	createCourse(t, repo, otherInstructor, "EFGH6789", "unit-1")
+	if err := repo.AddMember(context.Background(), course.ID, student, MemberStudent); err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/api/courses/" + strconv.Itoa(course.ID)
+	units := `{"units": ["unit-1", "unit-2"]}`
+
+	tests := []struct {
+		name       string
+		userID     int
+		role       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{"student reads the course", student, auth.RoleStudent, http.MethodGet, path, "", http.StatusOK},
+		{"student sets units", student, auth.RoleStudent, http.MethodPut, path + "/units", units, http.StatusForbidden},
+		{"student regenerates the join code", student, auth.RoleStudent, http.MethodPost, path + "/join-code", "", http.StatusForbidden},
+		{"student lists members", student, auth.RoleStudent, http.MethodGet, path + "/members", "", http.StatusForbidden},
+		{"student lists submissions", student, auth.RoleStudent, http.MethodGet, path + "/submissions", "", http.StatusForbidden},
+		{"non-member reads the course", outsider, auth.RoleStudent, http.MethodGet, path, "", http.StatusNotFound},
+		{"instructor of another course reads the course", otherInstructor, auth.RoleInstructor, http.MethodGet, path, "", http.StatusNotFound},
+		{"instructor of another course sets units", otherInstructor, auth.RoleInstructor, http.MethodPut, path + "/units", units, http.StatusForbidden},
+		{"instructor of another course lists members", otherInstructor, auth.RoleInstructor, http.MethodGet, path + "/members", "", http.StatusForbidden},
+		{"instructor of another course lists submissions", otherInstructor, auth.RoleInstructor, http.MethodGet, path + "/submissions", "", http.StatusForbidden},
+		{"owner lists members", owner, auth.RoleInstructor, http.MethodGet, path + "/members", "", http.StatusOK},
+		{"owner lists submissions", owner, auth.RoleInstructor, http.MethodGet, path + "/submissions", "", http.StatusOK},
+		{"owner sets units", owner, auth.RoleInstructor, http.MethodPut, path + "/units", units, http.StatusOK},
+		{"admin lists members without joining", admin, auth.RoleAdmin, http.MethodGet, path + "/members", "", http.StatusOK},
+		{"unknown course", owner, auth.RoleInstructor, http.MethodGet, "/api/courses/999999/members", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serveAs(router, tt.userID, tt.role, tt.method, tt.target, tt.body)
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+		})
+	}
+}
+
+func TestCourseHandlers_JoinCode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t, "unit-1")
+	router := newCourseRouter(repo)
+
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	student := createUser(t, "ana", auth.RoleStudent)
+	course := createCourse(t, repo, owner, "ABCD2345", "unit-1")
+	path := "/api/courses/" + strconv.Itoa(course.ID)
+
+	joinCode := func(t *testing.T, rr *httptest.ResponseRecorder) (string, string) {
+		t.Helper()
+
+		var envelope struct {
+			Course Course `json:"course"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("decoding %s: %v", rr.Body, err)
+		}
+		return envelope.Course.JoinCode, envelope.Course.MemberRole
+	}
+
+	rr := serveAs(router, student, auth.RoleStudent, http.MethodPost, "/api/courses/join", `{"code": "abcd-2345"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("join status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+	}
+	if code, role := joinCode(t, rr); code != "" || role != MemberStudent {
+		t.Errorf("joined course has join code %q and role %q, want no code and %s", code, role, MemberStudent)
+	}
+
+	rr = serveAs(router, student, auth.RoleStudent, http.MethodPost, "/api/courses/join", `{"code": "ZZZZ9999"}`)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("join with a wrong code status = %d, want %d", rr.Code, http.StatusUnprocessableEntity)
+	}
+
+	rr = serveAs(router, owner, auth.RoleInstructor, http.MethodGet, path, "")
+	if code, role := joinCode(t, rr); code != "ABCD2345" || role != MemberInstructor {
+		t.Errorf("owner sees join code %q and role %q, want ABCD2345 and %s", code, role, MemberInstructor)
+	}
+}
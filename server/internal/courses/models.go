@@ -0,0 +1,65 @@
+package courses
+
+import (
+	"time"
+
+	"apschool/internal/validator"
+)
+
+// Roles a user can hold inside a course. They are independent of the global
+// user role: an instructor account joins other sections as a student.
+const (
+	MemberStudent    = "student"
+	MemberInstructor = "instructor"
+)
+
+type Course struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Term       string    `json:"term"`
+	JoinCode   string    `json:"join_code,omitzero"`
+	OwnerID    int       `json:"owner_id"`
+	Units      []string  `json:"units"`
+	MemberRole string    `json:"member_role,omitzero"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"-"`
+}
+
+type Member struct {
+	UserID    int       `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	AvatarURL string    `json:"avatar_url"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+// CourseSubmission is an attempt by a course member on one of the course's
+// units.
+type CourseSubmission struct {
+	ID             int       `json:"id"`
+	UserID         int       `json:"user_id"`
+	Username       string    `json:"username"`
+	ChallengeID    int       `json:"challenge_id"`
+	ChallengeTitle string    `json:"challenge_title"`
+	Category       string    `json:"category"`
+	Passed         bool      `json:"passed"`
+	CodeSize       int       `json:"code_size"`
+	HintsUsed      int       `json:"hints_used"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func ValidateCourse(v *validator.Validator, c *Course) {
+	v.Check(validator.NotBlank(c.Name), "name", "is required")
+	v.Check(validator.MaxChars(c.Name, 200), "name", "must not be more than 200 characters long")
+	v.Check(validator.MaxChars(c.Term, 50), "term", "must not be more than 50 characters long")
+
+	ValidateUnits(v, c.Units)
+}
+
+func ValidateUnits(v *validator.Validator, units []string) {
+	v.Check(validator.Unique(units), "units", "must not contain duplicates")
+	for _, unit := range units {
+		v.Check(validator.Matches(unit, validator.SlugRegex), "units", "must contain only unit slugs")
+	}
+}
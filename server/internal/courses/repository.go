@@ -0,0 +1,328 @@
+package courses
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"apschool/internal/pagination"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Insert creates the course, makes its owner an instructor member and assigns
+// the units, all in one transaction.
+func (r *Repository) Insert(ctx context.Context, c *Course) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO courses (name, term, join_code, owner_id)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, c.Name, c.Term, c.JoinCode, c.OwnerID).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return translateError(err)
+	}
+
+	query = `INSERT INTO course_members (course_id, user_id, role) VALUES ($1, $2, $3)`
+
+	if _, err := tx.ExecContext(ctx, query, c.ID, c.OwnerID, MemberInstructor); err != nil {
+		return err
+	}
+
+	if err := insertUnits(ctx, tx, c.ID, c.Units); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*Course, error) {
+
+	query := `SELECT id, name, term, join_code, owner_id, created_at, updated_at
+	FROM courses
+	WHERE id = $1`
+
+	var c Course
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&c.ID, &c.Name, &c.Term, &c.JoinCode, &c.OwnerID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Units, err = r.GetUnits(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (r *Repository) GetByJoinCode(ctx context.Context, code string) (*Course, error) {
+
+	query := `SELECT id, name, term, join_code, owner_id, created_at, updated_at
+	FROM courses
+	WHERE join_code = $1`
+
+	var c Course
+
+	err := r.db.QueryRowContext(ctx, query, code).Scan(&c.ID, &c.Name, &c.Term, &c.JoinCode, &c.OwnerID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Units, err = r.GetUnits(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// ListForUser returns the courses the user is a member of, with the role they
+// hold in each one.
+func (r *Repository) ListForUser(ctx context.Context, userID int) ([]Course, error) {
+
+	query := `SELECT c.id, c.name, c.term, c.join_code, c.owner_id, c.created_at, c.updated_at, m.role,
+		coalesce(string_agg(u.category, ',' ORDER BY u.position), '')
+	FROM courses c
+	JOIN course_members m ON m.course_id = c.id AND m.user_id = $1
+	LEFT JOIN course_units u ON u.course_id = c.id
+	GROUP BY c.id, m.role
+	ORDER BY c.created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	courses := []Course{}
+	for rows.Next() {
+		var c Course
+		var units string
+		err := rows.Scan(&c.ID, &c.Name, &c.Term, &c.JoinCode, &c.OwnerID, &c.CreatedAt, &c.UpdatedAt, &c.MemberRole, &units)
+		if err != nil {
+			return nil, err
+		}
+		// Unit slugs never contain commas
+		c.Units = []string{}
+		if units != "" {
+			c.Units = strings.Split(units, ",")
+		}
+		courses = append(courses, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return courses, nil
+}
+
+func (r *Repository) GetUnits(ctx context.Context, courseID int) ([]string, error) {
+
+	query := `SELECT category FROM course_units
+	WHERE course_id = $1
+	ORDER BY position`
+
+	rows, err := r.db.QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []string{}
+	for rows.Next() {
+		var unit string
+		if err := rows.Scan(&unit); err != nil {
+			return nil, err
+		}
+		units = append(units, unit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return units, nil
+}
+
+// SetUnits replaces the course's units; their order is the display order.
+func (r *Repository) SetUnits(ctx context.Context, courseID int, units []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM course_units WHERE course_id = $1`, courseID); err != nil {
+		return err
+	}
+
+	if err := insertUnits(ctx, tx, courseID, units); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE courses SET updated_at = NOW() WHERE id = $1`, courseID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertUnits(ctx context.Context, tx *sql.Tx, courseID int, units []string) error {
+
+	query := `INSERT INTO course_units (course_id, category, position) VALUES ($1, $2, $3)`
+
+	for i, unit := range units {
+		if _, err := tx.ExecContext(ctx, query, courseID, unit, i); err != nil {
+			return translateError(err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdateJoinCode(ctx context.Context, courseID int, code string) error {
+
+	query := `UPDATE courses SET join_code = $2, updated_at = NOW()
+	WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, courseID, code)
+	return translateError(err)
+}
+
+// AddMember is a no-op when the user already belongs to the course, so their
+// existing role is kept.
+func (r *Repository) AddMember(ctx context.Context, courseID, userID int, role string) error {
+
+	query := `INSERT INTO course_members (course_id, user_id, role)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (course_id, user_id) DO NOTHING`
+
+	_, err := r.db.ExecContext(ctx, query, courseID, userID, role)
+	return err
+}
+
+func (r *Repository) GetMemberRole(ctx context.Context, courseID, userID int) (string, error) {
+	var role string
+
+	query := `SELECT role FROM course_members WHERE course_id = $1 AND user_id = $2`
+
+	err := r.db.QueryRowContext(ctx, query, courseID, userID).Scan(&role)
+	return role, err
+}
+
+func (r *Repository) ListMembers(ctx context.Context, courseID int) ([]Member, error) {
+
+	query := `SELECT u.id, u.username, u.email, u.avatar_url, m.role, m.joined_at
+	FROM course_members m
+	JOIN users u ON u.id = m.user_id
+	WHERE m.course_id = $1
+	ORDER BY m.role DESC, u.username`
+
+	rows, err := r.db.QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []Member{}
+	for rows.Next() {
+		var m Member
+		if err := rows.Scan(&m.UserID, &m.Username, &m.Email, &m.AvatarURL, &m.Role, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// ListSubmissions returns the attempts course students made on challenges from
+// the course's units, newest first, with how many hints the student revealed
+// on that challenge. A userID of 0 means every student.
+func (r *Repository) ListSubmissions(ctx context.Context, courseID, userID int, filters pagination.Filters) ([]CourseSubmission, pagination.Metadata, error) {
+
+	query := `SELECT count(*) OVER(), s.id, s.user_id, usr.username, s.challenge_id, ch.title, ch.category,
+		s.passed, s.code_size,
+		(SELECT count(*) FROM hint_reveals hr WHERE hr.user_id = s.user_id AND hr.challenge_id = s.challenge_id),
+		s.created_at
+	FROM submissions s
+	JOIN course_members m ON m.user_id = s.user_id AND m.course_id = $1 AND m.role = 'student'
+	JOIN challenges ch ON ch.id = s.challenge_id
+	JOIN course_units cu ON cu.category = ch.category AND cu.course_id = $1
+	JOIN users usr ON usr.id = s.user_id
+	WHERE ($2 = 0 OR s.user_id = $2)
+	ORDER BY s.created_at DESC, s.id DESC
+	LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.QueryContext(ctx, query, courseID, userID, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	submissions := []CourseSubmission{}
+	for rows.Next() {
+		var s CourseSubmission
+		err := rows.Scan(
+			&totalRecords,
+			&s.ID,
+			&s.UserID,
+			&s.Username,
+			&s.ChallengeID,
+			&s.ChallengeTitle,
+			&s.Category,
+			&s.Passed,
+			&s.CodeSize,
+			&s.HintsUsed,
+			&s.CreatedAt,
+		)
+		if err != nil {
+			return nil, pagination.Metadata{}, err
+		}
+		submissions = append(submissions, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+
+	return submissions, pagination.NewMetadata(totalRecords, filters), nil
+}
+
+// translateError maps join code collisions and unknown units to service
+// errors.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505" && pgErr.ConstraintName == "courses_join_code_key":
+			return errJoinCodeTaken
+		case pgErr.Code == "23503" && pgErr.ConstraintName == "course_units_category_fkey":
+			return ErrUnknownUnit
+		}
+	}
+	return err
+}
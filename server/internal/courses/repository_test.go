@@ -0,0 +1,310 @@
+package courses
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"apschool/internal/auth"
+	"apschool/internal/pagination"
+	"apschool/internal/testutil"
+)
+
+var testDB *testutil.TestDB
+
+func TestMain(m *testing.M) {
+	// Parse flags first so we can check -short
+	flag.Parse()
+
+	// Skip container setup entirely in short mode (for CI without Docker)
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	ctx := context.Background()
+
+	var err error
+	testDB, err = testutil.SetupTestDB(ctx)
+	if err != nil {
+		panic("failed to setup test db: " + err.Error())
+	}
+
+	code := m.Run()
+
+	testDB.Teardown(ctx)
+	os.Exit(code)
+}
+
+// setupRepository empties users, which courses cascade from, and the catalog,
+// then creates the given units in display order.
+func setupRepository(t *testing.T, units ...string) *Repository {
+	t.Helper()
+
+	testDB.TruncateTables(t)
+	if _, err := testDB.DB.Exec("TRUNCATE challenges, categories CASCADE"); err != nil {
+		t.Fatalf("failed to truncate challenges: %v", err)
+	}
+	for i, slug := range units {
+		_, err := testDB.DB.Exec(`INSERT INTO categories (slug, title, display_order) VALUES ($1, $1, $2)`, slug, i)
+		if err != nil {
+			t.Fatalf("failed to create unit %s: %v", slug, err)
+		}
+	}
+
+	return NewRepository(testDB.DB)
+}
+
+// createUser inserts a user with the given global role and returns their id.
+func createUser(t *testing.T, username, role string) int {
+	t.Helper()
+
+	var id int
+	err := testDB.DB.QueryRow(`INSERT INTO users (username, email, role) VALUES ($1, $2, $3) RETURNING id`,
+		username, username+"@example.com", role).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create user %s: %v", username, err)
+	}
+	return id
+}
+
+func createChallenge(t *testing.T, slug, unit string) int {
+	t.Helper()
+
+	var id int
+	err := testDB.DB.QueryRow(`INSERT INTO challenges (slug, category, title, description, template, test_code)
+	VALUES ($1, $2, $3, '', '', 'assert True') RETURNING id`, slug, unit, slug).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create challenge %s: %v", slug, err)
+	}
+	return id
+}
+
+func createCourse(t *testing.T, repo *Repository, ownerID int, code string, units ...string) *Course {
+	t.Helper()
+
+	course := &Course{Name: "Fundamentos " + code, Term: "2025-1S", JoinCode: code, OwnerID: ownerID, Units: units}
+	if err := repo.Insert(context.Background(), course); err != nil {
+		t.Fatalf("failed to create course %s: %v", code, err)
+	}
+	return course
+}
+
+func submit(t *testing.T, userID, challengeID int, passed bool, at time.Time) {
+	t.Helper()
+
+	status := "failed"
+	if passed {
+		status = "passed"
+	}
+	_, err := testDB.DB.Exec(`INSERT INTO submissions (user_id, challenge_id, code, passed, status, created_at) VALUES ($1, $2, 'x', $3, $4, $5)`,
+		userID, challengeID, passed, status, at)
+	if err != nil {
+		t.Fatalf("failed to create submission: %v", err)
+	}
+}
+
+func TestRepository_Insert(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t, "unit-1", "unit-2")
+	ctx := context.Background()
+	owner := createUser(t, "profe", auth.RoleInstructor)
+
+	course := createCourse(t, repo, owner, "ABCD2345", "unit-2", "unit-1")
+
+	if role, err := repo.GetMemberRole(ctx, course.ID, owner); err != nil || role != MemberInstructor {
+		t.Errorf("owner's role = %q, %v, want %s", role, err, MemberInstructor)
+	}
+	got, err := repo.GetByID(ctx, course.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !slices.Equal(got.Units, []string{"unit-2", "unit-1"}) {
+		t.Errorf("units = %v, want them in the given order", got.Units)
+	}
+
+	tests := []struct {
+		name    string
+		course  *Course
+		wantErr error
+	}{
+		{
+			name:    "join code taken",
+			course:  &Course{Name: "Otro", JoinCode: "ABCD2345", OwnerID: owner, Units: []string{}},
+			wantErr: errJoinCodeTaken,
+		},
+		{
+			name:    "unknown unit",
+			course:  &Course{Name: "Otro", JoinCode: "EFGH6789", OwnerID: owner, Units: []string{"unit-1", "unit-9"}},
+			wantErr: ErrUnknownUnit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.Insert(ctx, tt.course); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Insert() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A failed insert leaves neither the course nor its owner's membership
+	var courses, members int
+	err = testDB.DB.QueryRow(`SELECT (SELECT count(*) FROM courses), (SELECT count(*) FROM course_members)`).Scan(&courses, &members)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if courses != 1 || members != 1 {
+		t.Errorf("%d courses and %d members stored, want only the first course", courses, members)
+	}
+}
+
+func TestRepository_ListForUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t, "unit-1", "unit-2")
+	ctx := context.Background()
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	student := createUser(t, "ana", auth.RoleStudent)
+
+	joined := createCourse(t, repo, owner, "ABCD2345", "unit-2", "unit-1")
+	empty := createCourse(t, repo, owner, "EFGH6789")
+	createCourse(t, repo, createUser(t, "otro", auth.RoleInstructor), "JKMN2345", "unit-1")
+
+	if err := repo.AddMember(ctx, joined.ID, student, MemberStudent); err != nil {
+		t.Fatalf("AddMember() error = %v", err)
+	}
+
+	got, err := repo.ListForUser(ctx, student)
+	if err != nil {
+		t.Fatalf("ListForUser() error = %v", err)
+	}
+	if len(got) != 1 || got[0].ID != joined.ID || got[0].MemberRole != MemberStudent {
+		t.Fatalf("ListForUser(student) = %+v, want only the joined course as a student", got)
+	}
+	if !slices.Equal(got[0].Units, []string{"unit-2", "unit-1"}) {
+		t.Errorf("units = %v, want [unit-2 unit-1]", got[0].Units)
+	}
+
+	// Newest first, and a course without units has an empty list
+	got, err = repo.ListForUser(ctx, owner)
+	if err != nil {
+		t.Fatalf("ListForUser() error = %v", err)
+	}
+	if len(got) != 2 || got[0].ID != empty.ID || got[1].ID != joined.ID {
+		t.Fatalf("ListForUser(owner) = %+v, want both of their courses, newest first", got)
+	}
+	if got[0].Units == nil || len(got[0].Units) != 0 || got[0].MemberRole != MemberInstructor {
+		t.Errorf("course without units = %+v, want no units and the instructor role", got[0])
+	}
+}
+
+func TestRepository_AddMember(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t)
+	ctx := context.Background()
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	course := createCourse(t, repo, owner, "ABCD2345")
+
+	// Joining with the code again doesn't demote the instructor
+	if err := repo.AddMember(ctx, course.ID, owner, MemberStudent); err != nil {
+		t.Fatalf("AddMember() error = %v", err)
+	}
+	if role, err := repo.GetMemberRole(ctx, course.ID, owner); err != nil || role != MemberInstructor {
+		t.Errorf("owner's role = %q, %v, want %s", role, err, MemberInstructor)
+	}
+}
+
+func TestRepository_ListSubmissions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t, "unit-1", "unit-2")
+	ctx := context.Background()
+	base := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	ana := createUser(t, "ana", auth.RoleStudent)
+	beto := createUser(t, "beto", auth.RoleStudent)
+	carla := createUser(t, "carla", auth.RoleStudent)
+
+	course := createCourse(t, repo, owner, "ABCD2345", "unit-1")
+	other := createCourse(t, repo, createUser(t, "otro", auth.RoleInstructor), "EFGH6789", "unit-1")
+	for _, id := range []int{ana, beto} {
+		if err := repo.AddMember(ctx, course.ID, id, MemberStudent); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.AddMember(ctx, other.ID, carla, MemberStudent); err != nil {
+		t.Fatal(err)
+	}
+
+	inUnit := createChallenge(t, "suma", "unit-1")
+	outsideUnit := createChallenge(t, "bucles", "unit-2")
+
+	submit(t, ana, inUnit, false, base)
+	submit(t, ana, inUnit, true, base.Add(time.Hour))
+	submit(t, beto, inUnit, true, base.Add(2*time.Hour))
+	// None of these belong in the course's list
+	submit(t, ana, outsideUnit, true, base.Add(3*time.Hour))
+	submit(t, carla, inUnit, true, base.Add(4*time.Hour))
+	submit(t, owner, inUnit, true, base.Add(5*time.Hour))
+
+	_, err := testDB.DB.Exec(`INSERT INTO hint_reveals (user_id, challenge_id, hint_number) VALUES ($1, $2, 1), ($1, $2, 2)`, ana, inUnit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		studentID int
+		filters   pagination.Filters
+		want      []int // user ids, newest attempt first
+		wantTotal int
+	}{
+		{name: "every student", filters: pagination.Filters{Page: 1, PageSize: 20}, want: []int{beto, ana, ana}, wantTotal: 3},
+		{name: "one student", studentID: ana, filters: pagination.Filters{Page: 1, PageSize: 20}, want: []int{ana, ana}, wantTotal: 2},
+		{name: "paginated", filters: pagination.Filters{Page: 2, PageSize: 2}, want: []int{ana}, wantTotal: 3},
+		{name: "student of another course", studentID: carla, filters: pagination.Filters{Page: 1, PageSize: 20}, want: []int{}, wantTotal: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			submissions, metadata, err := repo.ListSubmissions(ctx, course.ID, tt.studentID, tt.filters)
+			if err != nil {
+				t.Fatalf("ListSubmissions() error = %v", err)
+			}
+
+			got := []int{}
+			for _, s := range submissions {
+				got = append(got, s.UserID)
+
+				wantHints := 0
+				if s.UserID == ana {
+					wantHints = 2
+				}
+				if s.ChallengeID != inUnit || s.HintsUsed != wantHints {
+					t.Errorf("submission %+v, want challenge %d with %d hints", s, inUnit, wantHints)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ListSubmissions() users = %v, want %v", got, tt.want)
+			}
+			if metadata.TotalRecords != tt.wantTotal {
+				t.Errorf("total_records = %d, want %d", metadata.TotalRecords, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package courses
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"apschool/internal/auth"
+	"apschool/internal/pagination"
+)
+
+// Join codes skip look-alike characters (0/O, 1/I/L) so they can be read out
+// in class.
+const (
+	joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 8
+	joinCodeAttempts = 5
+)
+
+var (
+	ErrCourseNotFound      = errors.New("course not found")
+	ErrInvalidJoinCode     = errors.New("invalid join code")
+	ErrNotCourseMember     = errors.New("not a member of this course")
+	ErrNotCourseInstructor = errors.New("not an instructor of this course")
+	ErrUnknownUnit         = errors.New("unknown unit")
+
+	errJoinCodeTaken = errors.New("join code already in use")
+)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) CreateCourse(ctx context.Context, course *Course) error {
+	// Codes are random, so a collision just means drawing again
+	for range joinCodeAttempts {
+		code, err := generateJoinCode()
+		if err != nil {
+			return err
+		}
+		course.JoinCode = code
+
+		err = s.repo.Insert(ctx, course)
+		if !errors.Is(err, errJoinCodeTaken) {
+			if err == nil {
+				course.MemberRole = MemberInstructor
+			}
+			return err
+		}
+	}
+
+	return errJoinCodeTaken
+}
+
+func (s *Service) ListCourses(ctx context.Context, userID int) ([]Course, error) {
+	courses, err := s.repo.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range courses {
+		if courses[i].MemberRole != MemberInstructor {
+			courses[i].JoinCode = ""
+		}
+	}
+
+	return courses, nil
+}
+
+// GetCourse returns the course as seen by the caller: the join code is only
+// included for instructors.
+func (s *Service) GetCourse(ctx context.Context, courseID, userID int, role string) (*Course, error) {
+	course, err := s.getCourse(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberRole, err := s.MemberRole(ctx, courseID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	course.MemberRole = memberRole
+	if memberRole != MemberInstructor {
+		course.JoinCode = ""
+	}
+
+	return course, nil
+}
+
+// JoinCourse enrolls the user as a student. Joining a course twice is not an
+// error and leaves the existing membership untouched.
+func (s *Service) JoinCourse(ctx context.Context, userID int, code string) (*Course, error) {
+	course, err := s.repo.GetByJoinCode(ctx, NormalizeJoinCode(code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidJoinCode
+		}
+		return nil, err
+	}
+
+	if err := s.repo.AddMember(ctx, course.ID, userID, MemberStudent); err != nil {
+		return nil, err
+	}
+
+	course.MemberRole, err = s.repo.GetMemberRole(ctx, course.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if course.MemberRole != MemberInstructor {
+		course.JoinCode = ""
+	}
+
+	return course, nil
+}
+
+func (s *Service) SetUnits(ctx context.Context, courseID, userID int, role string, units []string) (*Course, error) {
+	if err := s.RequireInstructor(ctx, courseID, userID, role); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetUnits(ctx, courseID, units); err != nil {
+		return nil, err
+	}
+
+	return s.GetCourse(ctx, courseID, userID, role)
+}
+
+func (s *Service) RegenerateJoinCode(ctx context.Context, courseID, userID int, role string) (string, error) {
+	if err := s.RequireInstructor(ctx, courseID, userID, role); err != nil {
+		return "", err
+	}
+
+	for range joinCodeAttempts {
+		code, err := generateJoinCode()
+		if err != nil {
+			return "", err
+		}
+
+		err = s.repo.UpdateJoinCode(ctx, courseID, code)
+		if !errors.Is(err, errJoinCodeTaken) {
+			if err != nil {
+				return "", err
+			}
+			return code, nil
+		}
+	}
+
+	return "", errJoinCodeTaken
+}
+
+func (s *Service) ListMembers(ctx context.Context, courseID, userID int, role string) ([]Member, error) {
+	if err := s.RequireInstructor(ctx, courseID, userID, role); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListMembers(ctx, courseID)
+}
+
+// ListSubmissions lets instructors browse their students' attempts. A
+// studentID of 0 lists every student.
+func (s *Service) ListSubmissions(ctx context.Context, courseID, userID int, role string, studentID int, filters pagination.Filters) ([]CourseSubmission, pagination.Metadata, error) {
+	if err := s.RequireInstructor(ctx, courseID, userID, role); err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+
+	return s.repo.ListSubmissions(ctx, courseID, studentID, filters)
+}
+
+func (s *Service) getCourse(ctx context.Context, courseID int) (*Course, error) {
+	course, err := s.repo.GetByID(ctx, courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCourseNotFound
+		}
+		return nil, err
+	}
+
+	return course, nil
+}
+
+// MemberRole returns the caller's role in the course. Admins act as
+// instructors of every course without being members.
+func (s *Service) MemberRole(ctx context.Context, courseID, userID int, role string) (string, error) {
+	memberRole, err := s.repo.GetMemberRole(ctx, courseID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if role == auth.RoleAdmin {
+				return MemberInstructor, nil
+			}
+			return "", ErrNotCourseMember
+		}
+		return "", err
+	}
+
+	if role == auth.RoleAdmin {
+		return MemberInstructor, nil
+	}
+
+	return memberRole, nil
+}
+
+// RequireInstructor returns ErrNotCourseInstructor unless the caller teaches
+// the course.
+func (s *Service) RequireInstructor(ctx context.Context, courseID, userID int, role string) error {
+	if _, err := s.getCourse(ctx, courseID); err != nil {
+		return err
+	}
+
+	memberRole, err := s.MemberRole(ctx, courseID, userID, role)
+	if err != nil {
+		if errors.Is(err, ErrNotCourseMember) {
+			return ErrNotCourseInstructor
+		}
+		return err
+	}
+
+	if memberRole != MemberInstructor {
+		return ErrNotCourseInstructor
+	}
+
+	return nil
+}
+
+func generateJoinCode() (string, error) {
+	b := make([]byte, joinCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	// 256 is not a multiple of the alphabet size; the slight bias doesn't
+	// matter for a code that only gates joining a class
+	for i := range b {
+		b[i] = joinCodeAlphabet[int(b[i])%len(joinCodeAlphabet)]
+	}
+
+	return string(b), nil
+}
+
+// NormalizeJoinCode makes codes case-insensitive and tolerant of the dashes
+// or spaces people add when copying them.
+func NormalizeJoinCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+	return code
+}
@@ -0,0 +1,53 @@
+package courses
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateJoinCode(t *testing.T) {
+	seen := make(map[string]bool)
+
+	for range 100 {
+		code, err := generateJoinCode()
+		if err != nil {
+			t.Fatalf("generateJoinCode() error = %v", err)
+		}
+
+		if len(code) != joinCodeLength {
+			t.Errorf("generateJoinCode() = %q, want length %d", code, joinCodeLength)
+		}
+		for _, c := range code {
+			if !strings.ContainsRune(joinCodeAlphabet, c) {
+				t.Errorf("generateJoinCode() = %q, contains %q outside the alphabet", code, c)
+			}
+		}
+
+		seen[code] = true
+	}
+
+	if len(seen) < 100 {
+		t.Errorf("generateJoinCode() produced %d distinct codes out of 100", len(seen))
+	}
+}
+
+func TestNormalizeJoinCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"already normalized", "ABCD2345", "ABCD2345"},
+		{"lowercase", "abcd2345", "ABCD2345"},
+		{"dashes", "ABCD-2345", "ABCD2345"},
+		{"spaces", " abcd 2345 ", "ABCD2345"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeJoinCode(tt.code); got != tt.want {
+				t.Errorf("NormalizeJoinCode(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,9 +4,23 @@ import "context"
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey  contextKey = "userID"
+	RoleKey    contextKey = "role"
+	TokenIDKey contextKey = "tokenID"
+)
 
 func GetUserID(ctx context.Context) (int, bool) {
 	userID, ok := ctx.Value(UserIDKey).(int)
 	return userID, ok
 }
+
+func GetRole(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(RoleKey).(string)
+	return role, ok
+}
+
+func GetTokenID(ctx context.Context) (string, bool) {
+	tokenID, ok := ctx.Value(TokenIDKey).(string)
+	return tokenID, ok
+}
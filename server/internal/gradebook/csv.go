@@ -0,0 +1,39 @@
+package gradebook
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// CSVWriter writes rows as CSV, flushing after every row so the response is
+// streamed.
+type CSVWriter struct {
+	w *csv.Writer
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+func (c *CSVWriter) WriteRow(cells []string) error {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = escapeFormula(cell)
+	}
+
+	if err := c.w.Write(escaped); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula stops spreadsheet apps from evaluating user-controlled text
+// such as usernames as formulas.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
@@ -0,0 +1,103 @@
+package gradebook
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"apschool/internal/courses"
+	"apschool/internal/ctxkeys"
+	"apschool/internal/response"
+	"apschool/internal/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+// ExportHandler streams the course gradebook as CSV (the default) or XLSX.
+// Once the first byte is out the status can't change anymore, so failures
+// past that point are only logged and the download ends truncated.
+func (h *Handler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+	role, _ := ctxkeys.GetRole(r.Context())
+
+	courseID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid id")
+		return
+	}
+
+	qs := r.URL.Query()
+	format := qs.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	unit := qs.Get("unit")
+
+	v := validator.New()
+	v.Check(validator.PermittedValue(format, "csv", "xlsx"), "format", "must be csv or xlsx")
+
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	gb, err := h.service.Prepare(r.Context(), courseID, userID, role, unit)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownUnit):
+			v.AddError("unit", "is not part of this course")
+			response.ValidationError(w, v.Errors)
+		case errors.Is(err, courses.ErrCourseNotFound), errors.Is(err, courses.ErrNotCourseMember):
+			response.NotFound(w)
+		case errors.Is(err, courses.ErrNotCourseInstructor):
+			response.Forbidden(w)
+		default:
+			response.ServerError(w, r, h.logger, err)
+		}
+		return
+	}
+
+	filename := fmt.Sprintf("course-%d-gradebook", courseID)
+	if unit != "" {
+		filename += "-" + unit
+	}
+
+	switch format {
+	case "xlsx":
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, filename))
+
+		xw, err := NewXLSXWriter(w)
+		if err == nil {
+			err = h.service.Write(r.Context(), gb, xw)
+		}
+		if err == nil {
+			err = xw.Close()
+		}
+		if err != nil {
+			h.logger.Error("gradebook export failed", "course_id", courseID, "format", format, "error", err)
+		}
+	default:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+
+		if err := h.service.Write(r.Context(), gb, NewCSVWriter(w)); err != nil {
+			h.logger.Error("gradebook export failed", "course_id", courseID, "format", format, "error", err)
+		}
+	}
+}
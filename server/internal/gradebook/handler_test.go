@@ -0,0 +1,116 @@
+package gradebook
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"apschool/internal/auth"
+	"apschool/internal/courses"
+	"apschool/internal/ctxkeys"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newGradebookRouter mounts the export handler the way routes.go does, minus
+// the auth middleware.
+func newGradebookRouter(repo *Repository) http.Handler {
+	service := NewService(repo, courses.NewService(courses.NewRepository(testDB.DB)))
+	h := NewHandler(service, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r := chi.NewRouter()
+	r.Get("/api/courses/{id}/gradebook", h.ExportHandler)
+	return r
+}
+
+// getAs sends a GET as the given user, the way RequireAuth leaves the
+// context.
+func getAs(router http.Handler, userID int, role, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	ctx := context.WithValue(req.Context(), ctxkeys.UserIDKey, userID)
+	ctx = context.WithValue(ctx, ctxkeys.RoleKey, role)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req.WithContext(ctx))
+	return rr
+}
+
+func TestExportHandler_Permissions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, _ := setupRepository(t)
+	router := newGradebookRouter(repo)
+
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	otherInstructor := createUser(t, "otro", auth.RoleInstructor)
+	student := createUser(t, "ana", auth.RoleStudent)
+	admin := createUser(t, "admin", auth.RoleAdmin)
+
+	courseID := createCourse(t, "ABCD2345", owner, student)
+	createCourse(t, "EFGH6789", otherInstructor)
+	path := "/api/courses/" + strconv.Itoa(courseID) + "/gradebook"
+
+	tests := []struct {
+		name       string
+		userID     int
+		role       string
+		target     string
+		wantStatus int
+	}{
+		{"student", student, auth.RoleStudent, path, http.StatusForbidden},
+		{"instructor of another course", otherInstructor, auth.RoleInstructor, path, http.StatusNotFound},
+		{"owner", owner, auth.RoleInstructor, path, http.StatusOK},
+		{"owner exports one unit", owner, auth.RoleInstructor, path + "?unit=unit-1&format=xlsx", http.StatusOK},
+		{"owner exports a unit outside the course", owner, auth.RoleInstructor, path + "?unit=unit-3", http.StatusUnprocessableEntity},
+		{"owner asks for an unknown format", owner, auth.RoleInstructor, path + "?format=pdf", http.StatusUnprocessableEntity},
+		{"admin without joining", admin, auth.RoleAdmin, path, http.StatusOK},
+		{"unknown course", owner, auth.RoleInstructor, "/api/courses/999999/gradebook", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := getAs(router, tt.userID, tt.role, tt.target)
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+		})
+	}
+}
+
+func TestExportHandler_CSV(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	router := newGradebookRouter(repo)
+
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	beto := createUser(t, "beto", auth.RoleStudent)
+	ana := createUser(t, "ana", auth.RoleStudent)
+	courseID := createCourse(t, "ABCD2345", owner, beto, ana)
+
+	submit(t, ana, ids["a-suma"], true, time.Date(2025, 3, 10, 8, 0, 0, 0, time.FixedZone("ECT", -5*60*60)))
+
+	rr := getAs(router, owner, auth.RoleInstructor, "/api/courses/"+strconv.Itoa(courseID)+"/gradebook")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+	}
+
+	want := "username,email,solved,lista passed,lista first accepted,a-suma passed,a-suma first accepted,b-resta passed,b-resta first accepted\n" +
+		"ana,ana@example.com,1,no,,yes,2025-03-10T13:00:00Z,no,\n" +
+		"beto,beto@example.com,0,no,,no,,no,\n"
+	if got := rr.Body.String(); got != want {
+		t.Errorf("body =\n%s\nwant\n%s", got, want)
+	}
+	if got := rr.Header().Get("Content-Disposition"); got != `attachment; filename="course-`+strconv.Itoa(courseID)+`-gradebook.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
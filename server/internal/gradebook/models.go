@@ -0,0 +1,26 @@
+package gradebook
+
+import "time"
+
+// Column is a challenge in the gradebook, in course unit order.
+type Column struct {
+	ChallengeID int
+	Slug        string
+	Category    string
+}
+
+// Cell is one student's result on one challenge. FirstPass is nil when the
+// student has no accepted submission.
+type Cell struct {
+	UserID      int
+	Username    string
+	Email       string
+	ChallengeID int
+	FirstPass   *time.Time
+}
+
+type Gradebook struct {
+	CourseID int
+	Units    []string
+	Columns  []Column
+}
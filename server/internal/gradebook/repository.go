@@ -0,0 +1,83 @@
+package gradebook
+
+import (
+	"context"
+	"database/sql"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetColumns returns the active challenges of the given units, ordered by the
+// units' position in the course and then by slug.
+func (r *Repository) GetColumns(ctx context.Context, courseID int, units []string) ([]Column, error) {
+
+	query := `SELECT ch.id, ch.slug, ch.category
+	FROM course_units cu
+	JOIN challenges ch ON ch.category = cu.category AND ch.is_active = true
+	WHERE cu.course_id = $1 AND cu.category = ANY($2)
+	ORDER BY cu.position, ch.slug`
+
+	rows, err := r.db.QueryContext(ctx, query, courseID, units)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []Column{}
+	for rows.Next() {
+		var c Column
+		if err := rows.Scan(&c.ChallengeID, &c.Slug, &c.Category); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
+
+// EachCell calls fn for every student × challenge pair of the given units,
+// grouped by student, without loading the whole matrix in memory.
+func (r *Repository) EachCell(ctx context.Context, courseID int, units []string, fn func(Cell) error) error {
+
+	query := `SELECT u.id, u.username, u.email, ch.id, fp.first_pass
+	FROM course_members m
+	JOIN users u ON u.id = m.user_id
+	CROSS JOIN course_units cu
+	JOIN challenges ch ON ch.category = cu.category AND ch.is_active = true
+	LEFT JOIN LATERAL (
+		SELECT min(s.created_at) AS first_pass
+		FROM submissions s
+		WHERE s.user_id = m.user_id AND s.challenge_id = ch.id AND s.passed = true
+	) fp ON true
+	WHERE m.course_id = $1 AND m.role = 'student'
+		AND cu.course_id = $1 AND cu.category = ANY($2)
+	ORDER BY u.username, u.id, cu.position, ch.slug`
+
+	rows, err := r.db.QueryContext(ctx, query, courseID, units)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c Cell
+		if err := rows.Scan(&c.UserID, &c.Username, &c.Email, &c.ChallengeID, &c.FirstPass); err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,224 @@
+package gradebook
+
+import (
+	"context"
+	"flag"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"apschool/internal/auth"
+	"apschool/internal/courses"
+	"apschool/internal/testutil"
+)
+
+var testDB *testutil.TestDB
+
+func TestMain(m *testing.M) {
+	// Parse flags first so we can check -short
+	flag.Parse()
+
+	// Skip container setup entirely in short mode (for CI without Docker)
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	ctx := context.Background()
+
+	var err error
+	testDB, err = testutil.SetupTestDB(ctx)
+	if err != nil {
+		panic("failed to setup test db: " + err.Error())
+	}
+
+	code := m.Run()
+
+	testDB.Teardown(ctx)
+	os.Exit(code)
+}
+
+// catalog is the challenge id by slug of the units setupRepository creates.
+type catalog map[string]int
+
+// setupRepository empties users, which courses cascade from, and the catalog,
+// then creates three units. unit-1 has an inactive challenge and unit-3 is
+// left out of the courses the tests create.
+func setupRepository(t *testing.T) (*Repository, catalog) {
+	t.Helper()
+
+	testDB.TruncateTables(t)
+	if _, err := testDB.DB.Exec("TRUNCATE challenges, categories CASCADE"); err != nil {
+		t.Fatalf("failed to truncate challenges: %v", err)
+	}
+	for i, slug := range []string{"unit-1", "unit-2", "unit-3"} {
+		_, err := testDB.DB.Exec(`INSERT INTO categories (slug, title, display_order) VALUES ($1, $1, $2)`, slug, i)
+		if err != nil {
+			t.Fatalf("failed to create unit %s: %v", slug, err)
+		}
+	}
+
+	challenges := []struct {
+		slug, unit string
+		active     bool
+	}{
+		{"b-resta", "unit-1", true},
+		{"a-suma", "unit-1", true},
+		{"viejo", "unit-1", false},
+		{"lista", "unit-2", true},
+		{"bucle", "unit-3", true},
+	}
+
+	ids := catalog{}
+	for _, c := range challenges {
+		var id int
+		err := testDB.DB.QueryRow(`INSERT INTO challenges (slug, category, title, description, template, test_code, is_active)
+		VALUES ($1, $2, $1, '', '', 'assert True', $3) RETURNING id`, c.slug, c.unit, c.active).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create challenge %s: %v", c.slug, err)
+		}
+		ids[c.slug] = id
+	}
+
+	return NewRepository(testDB.DB), ids
+}
+
+// createUser inserts a user with the given global role and returns their id.
+func createUser(t *testing.T, username, role string) int {
+	t.Helper()
+
+	var id int
+	err := testDB.DB.QueryRow(`INSERT INTO users (username, email, role) VALUES ($1, $2, $3) RETURNING id`,
+		username, username+"@example.com", role).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create user %s: %v", username, err)
+	}
+	return id
+}
+
+// createCourse creates a course with units unit-2 then unit-1, taught by
+// ownerID, with the given students.
+func createCourse(t *testing.T, code string, ownerID int, students ...int) int {
+	t.Helper()
+
+	repo := courses.NewRepository(testDB.DB)
+	course := &courses.Course{Name: "Fundamentos " + code, JoinCode: code, OwnerID: ownerID, Units: []string{"unit-2", "unit-1"}}
+	if err := repo.Insert(context.Background(), course); err != nil {
+		t.Fatalf("failed to create course %s: %v", code, err)
+	}
+	for _, id := range students {
+		if err := repo.AddMember(context.Background(), course.ID, id, courses.MemberStudent); err != nil {
+			t.Fatalf("failed to enroll student %d: %v", id, err)
+		}
+	}
+	return course.ID
+}
+
+func submit(t *testing.T, userID, challengeID int, passed bool, at time.Time) {
+	t.Helper()
+
+	status := "failed"
+	if passed {
+		status = "passed"
+	}
+	_, err := testDB.DB.Exec(`INSERT INTO submissions (user_id, challenge_id, code, passed, status, created_at) VALUES ($1, $2, 'x', $3, $4, $5)`,
+		userID, challengeID, passed, status, at)
+	if err != nil {
+		t.Fatalf("failed to create submission: %v", err)
+	}
+}
+
+func TestRepository_GetColumns(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	courseID := createCourse(t, "ABCD2345", createUser(t, "profe", auth.RoleInstructor))
+
+	tests := []struct {
+		name  string
+		units []string
+		want  []int
+	}{
+		{name: "every unit", units: []string{"unit-2", "unit-1"}, want: []int{ids["lista"], ids["a-suma"], ids["b-resta"]}},
+		{name: "one unit", units: []string{"unit-1"}, want: []int{ids["a-suma"], ids["b-resta"]}},
+		{name: "unit outside the course", units: []string{"unit-3"}, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := repo.GetColumns(context.Background(), courseID, tt.units)
+			if err != nil {
+				t.Fatalf("GetColumns() error = %v", err)
+			}
+
+			got := []int{}
+			for _, c := range columns {
+				got = append(got, c.ChallengeID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetColumns() = %+v, want challenges %v", columns, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_EachCell(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	base := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	owner := createUser(t, "profe", auth.RoleInstructor)
+	beto := createUser(t, "beto", auth.RoleStudent)
+	ana := createUser(t, "ana", auth.RoleStudent)
+	carla := createUser(t, "carla", auth.RoleStudent)
+	courseID := createCourse(t, "ABCD2345", owner, beto, ana)
+	createCourse(t, "EFGH6789", owner, carla)
+
+	firstPass := base.Add(time.Hour)
+	submit(t, ana, ids["a-suma"], false, base)
+	submit(t, ana, ids["a-suma"], true, firstPass)
+	submit(t, ana, ids["a-suma"], true, base.Add(2*time.Hour))
+	submit(t, ana, ids["b-resta"], false, base)
+	submit(t, beto, ids["viejo"], true, base)
+	submit(t, carla, ids["a-suma"], true, base)
+	submit(t, owner, ids["a-suma"], true, base)
+
+	type cell struct {
+		user      int
+		challenge int
+		firstPass *time.Time
+	}
+	want := []cell{
+		{ana, ids["lista"], nil},
+		{ana, ids["a-suma"], &firstPass},
+		{ana, ids["b-resta"], nil},
+		{beto, ids["lista"], nil},
+		{beto, ids["a-suma"], nil},
+		{beto, ids["b-resta"], nil},
+	}
+
+	got := []cell{}
+	err := repo.EachCell(context.Background(), courseID, []string{"unit-2", "unit-1"}, func(c Cell) error {
+		got = append(got, cell{c.UserID, c.ChallengeID, c.FirstPass})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachCell() error = %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("EachCell() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		samePass := (g.firstPass == nil) == (w.firstPass == nil) && (g.firstPass == nil || g.firstPass.Equal(*w.firstPass))
+		if g.user != w.user || g.challenge != w.challenge || !samePass {
+			t.Errorf("cell %d = {%d %d %v}, want {%d %d %v}", i, g.user, g.challenge, g.firstPass, w.user, w.challenge, w.firstPass)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package gradebook
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"apschool/internal/courses"
+)
+
+var ErrUnknownUnit = errors.New("unit is not part of this course")
+
+// RowWriter receives the gradebook one row at a time.
+type RowWriter interface {
+	WriteRow(cells []string) error
+}
+
+type Service struct {
+	repo    *Repository
+	courses *courses.Service
+}
+
+func NewService(repo *Repository, courses *courses.Service) *Service {
+	return &Service{repo: repo, courses: courses}
+}
+
+// Prepare checks that the caller teaches the course and resolves the
+// challenge columns. An empty unit exports every unit of the course. Nothing
+// is written yet, so errors can still become a normal error response.
+func (s *Service) Prepare(ctx context.Context, courseID, userID int, role, unit string) (*Gradebook, error) {
+	course, err := s.courses.GetCourse(ctx, courseID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if course.MemberRole != courses.MemberInstructor {
+		return nil, courses.ErrNotCourseInstructor
+	}
+
+	units := course.Units
+	if unit != "" {
+		if !slices.Contains(course.Units, unit) {
+			return nil, ErrUnknownUnit
+		}
+		units = []string{unit}
+	}
+
+	columns, err := s.repo.GetColumns(ctx, courseID, units)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Gradebook{CourseID: courseID, Units: units, Columns: columns}, nil
+}
+
+// Write streams the header and one row per student to w. Each challenge
+// takes two columns: whether it was passed and when it was first accepted.
+func (s *Service) Write(ctx context.Context, gb *Gradebook, w RowWriter) error {
+	if err := w.WriteRow(header(gb.Columns)); err != nil {
+		return err
+	}
+
+	index := make(map[int]int, len(gb.Columns))
+	for i, c := range gb.Columns {
+		index[c.ChallengeID] = i
+	}
+
+	var current *studentRow
+	err := s.repo.EachCell(ctx, gb.CourseID, gb.Units, func(c Cell) error {
+		if current != nil && current.userID != c.UserID {
+			if err := w.WriteRow(current.cells()); err != nil {
+				return err
+			}
+			current = nil
+		}
+		if current == nil {
+			current = newStudentRow(c, len(gb.Columns))
+		}
+
+		if i, ok := index[c.ChallengeID]; ok {
+			current.results[i] = c.FirstPass
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if current != nil {
+		return w.WriteRow(current.cells())
+	}
+	return nil
+}
+
+func header(columns []Column) []string {
+	row := []string{"username", "email", "solved"}
+	for _, c := range columns {
+		row = append(row, c.Slug+" passed", c.Slug+" first accepted")
+	}
+	return row
+}
+
+type studentRow struct {
+	userID   int
+	username string
+	email    string
+	results  []*time.Time
+}
+
+func newStudentRow(c Cell, columns int) *studentRow {
+	return &studentRow{
+		userID:   c.UserID,
+		username: c.Username,
+		email:    c.Email,
+		results:  make([]*time.Time, columns),
+	}
+}
+
+func (r *studentRow) cells() []string {
+	solved := 0
+	row := []string{r.username, r.email, ""}
+
+	for _, firstPass := range r.results {
+		if firstPass == nil {
+			row = append(row, "no", "")
+			continue
+		}
+		solved++
+		row = append(row, "yes", firstPass.UTC().Format(time.RFC3339))
+	}
+
+	row[2] = strconv.Itoa(solved)
+	return row
+}
@@ -0,0 +1,95 @@
+package gradebook
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, tt := range tests {
+		if got := columnName(tt.index); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	xw, err := NewXLSXWriter(&buf)
+	if err != nil {
+		t.Fatalf("NewXLSXWriter() error = %v", err)
+	}
+	if err := xw.WriteRow([]string{"username", "email"}); err != nil {
+		t.Fatalf("WriteRow() error = %v", err)
+	}
+	if err := xw.WriteRow([]string{"<ana & co>", "ana@espol.edu.ec"}); err != nil {
+		t.Fatalf("WriteRow() error = %v", err)
+	}
+	if err := xw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("output is not a zip: %v", err)
+	}
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{`<c r="B2" t="inlineStr">`, "&lt;ana &amp; co&gt;", "</sheetData></worksheet>"} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet does not contain %q", want)
+		}
+	}
+}
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{"ana", "ana"},
+		{"", ""},
+		{"=HYPERLINK(\"x\")", "'=HYPERLINK(\"x\")"},
+		{"+1", "'+1"},
+		{"@cmd", "'@cmd"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeFormula(tt.cell); got != tt.want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package grader
+
+import (
+	"context"
+	"time"
+)
+
+// PassMarker is printed by a challenge's tests when every assertion passed.
+const PassMarker = "ALL_TESTS_PASSED"
+
+// HiddenFailure replaces the error of a failed hidden suite so its asserts
+// and expected values never reach the student.
+const HiddenFailure = "hidden tests failed"
+
+type Grader interface {
+	Grade(ctx context.Context, code string, tests Tests) (*Result, error)
+}
+
+// Tests is everything a submission is graded against. A submission passes
+// when every suite prints PassMarker and every case passes.
+type Tests struct {
+	Suites []Suite
+	Cases  []Case
+}
+
+// Suite is one test script. Suites run in order after the user's code and
+// each one has to print PassMarker for the submission to pass.
+type Suite struct {
+	Name   string
+	Code   string
+	Hidden bool
+}
+
+// Case is one declarative test case. When Call is empty the program is run
+// with Stdin and what it prints is compared with ExpectedStdout, ignoring
+// trailing whitespace. Otherwise the function named Call is called with Args
+// and its return value, converted to JSON, is compared with Expected.
+type Case struct {
+	Name           string `json:"name" yaml:"name"`
+	Stdin          string `json:"stdin,omitzero" yaml:"stdin"`
+	ExpectedStdout string `json:"expected_stdout,omitzero" yaml:"expected_stdout"`
+	Call           string `json:"call,omitzero" yaml:"call"`
+	Args           []any  `json:"args,omitempty" yaml:"args"`
+	Expected       any    `json:"expected,omitempty" yaml:"expected"`
+	Hidden         bool   `json:"hidden,omitzero" yaml:"hidden"`
+}
+
+// CaseResult is the outcome of one case. Expected, Actual and Error are left
+// out for hidden cases.
+type CaseResult struct {
+	Name     string  `json:"name"`
+	Passed   bool    `json:"passed"`
+	Hidden   bool    `json:"hidden,omitzero"`
+	Expected any     `json:"expected,omitempty"`
+	Actual   any     `json:"actual,omitempty"`
+	Error    string  `json:"error,omitzero"`
+	TimeMS   float64 `json:"time_ms"`
+}
+
+// ChallengeTests returns the tests of a challenge: the public suite students
+// also run in the browser, the hidden suite if there is one, and the cases.
+func ChallengeTests(testCode, hiddenTestCode string, cases []Case) Tests {
+	tests := Tests{Cases: cases}
+	if testCode != "" {
+		tests.Suites = append(tests.Suites, Suite{Name: "tests.py", Code: testCode})
+	}
+	if hiddenTestCode != "" {
+		tests.Suites = append(tests.Suites, Suite{Name: "hidden_tests.py", Code: hiddenTestCode, Hidden: true})
+	}
+	return tests
+}
+
+// PublicCases returns the cases students are allowed to see.
+func PublicCases(cases []Case) []Case {
+	public := []Case{}
+	for _, c := range cases {
+		if !c.Hidden {
+			public = append(public, c)
+		}
+	}
+	return public
+}
+
+// SuiteResult is the outcome of one suite that ran. Clients tell a failed
+// hidden suite by Hidden, not by the text of the error.
+type SuiteResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Hidden bool   `json:"hidden,omitzero"`
+}
+
+type Result struct {
+	Passed   bool          `json:"passed"`
+	Output   string        `json:"output"`
+	Error    string        `json:"error,omitzero"`
+	Suites   []SuiteResult `json:"suites,omitempty"`
+	Cases    []CaseResult  `json:"cases,omitempty"`
+	Duration time.Duration `json:"-"`
+}
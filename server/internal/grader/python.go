@@ -0,0 +1,284 @@
+package grader
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+//go:embed runner.py
+var runnerScript []byte
+
+var (
+	ErrTimeLimit   = errors.New("time limit exceeded")
+	ErrNoVerdict   = errors.New("grader produced no verdict")
+	ErrOutputLimit = errors.New("output limit exceeded")
+)
+
+type Limits struct {
+	CPUTime        time.Duration
+	WallTime       time.Duration
+	MemoryBytes    int64
+	MaxOutputBytes int
+}
+
+var DefaultLimits = Limits{
+	CPUTime:        5 * time.Second,
+	WallTime:       10 * time.Second,
+	MemoryBytes:    256 << 20,
+	MaxOutputBytes: 64 << 10,
+}
+
+// PythonGrader runs submissions in a local python subprocess. The runner runs
+// user code in child interpreters with CPU, memory and file size rlimits set
+// and the whole process group is killed when the wall-clock limit expires. This keeps runaway or
+// hostile code from hurting the API process, but it is not a security boundary
+// on its own: deployments should still run the server as an unprivileged user.
+type PythonGrader struct {
+	python string
+	limits Limits
+	slots  chan struct{}
+}
+
+func NewPythonGrader(python string, limits Limits) *PythonGrader {
+	return &PythonGrader{
+		python: python,
+		limits: limits,
+		slots:  make(chan struct{}, runtime.NumCPU()),
+	}
+}
+
+type runnerJob struct {
+	Code           string        `json:"code"`
+	Suites         []runnerSuite `json:"suites"`
+	Cases          []Case        `json:"cases"`
+	Nonce          string        `json:"nonce"`
+	PassMarker     string        `json:"pass_marker"`
+	CPUSeconds     int           `json:"cpu_seconds"`
+	MemoryBytes    int64         `json:"memory_bytes"`
+	MaxOutputBytes int           `json:"max_output_bytes"`
+}
+
+type runnerSuite struct {
+	Name string `json:"name"`
+	Code string `json:"code"`
+}
+
+// runnerResult holds one entry in Suites per suite that ran. The runner
+// stops at the first suite that fails, and runs none when the user code
+// itself fails. Cases run independently, so Cases has one entry per case.
+type runnerResult struct {
+	UserOutput string              `json:"user_output"`
+	Error      string              `json:"error"`
+	Suites     []runnerSuiteResult `json:"suites"`
+	Cases      []runnerCaseResult  `json:"cases"`
+}
+
+// runnerSuiteResult.Passed is decided by the runner's parent process, which
+// looks for the marker the suite was given in place of PassMarker. Output
+// has PassMarker back in its place.
+type runnerSuiteResult struct {
+	Output string `json:"output"`
+	Error  string `json:"error"`
+	Passed bool   `json:"passed"`
+}
+
+type runnerCaseResult struct {
+	Passed bool    `json:"passed"`
+	Actual any     `json:"actual"`
+	Error  string  `json:"error"`
+	TimeMS float64 `json:"time_ms"`
+}
+
+func (g *PythonGrader) Grade(ctx context.Context, code string, tests Tests) (*Result, error) {
+	// Bound the number of interpreters running at the same time
+	select {
+	case g.slots <- struct{}{}:
+		defer func() { <-g.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	dir, err := os.MkdirTemp("", "apschool-grader-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	runnerPath := filepath.Join(dir, "runner.py")
+	if err := os.WriteFile(runnerPath, runnerScript, 0o600); err != nil {
+		return nil, err
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	jobSuites := make([]runnerSuite, len(tests.Suites))
+	for i, suite := range tests.Suites {
+		jobSuites[i] = runnerSuite{Name: suite.Name, Code: suite.Code}
+	}
+
+	job, err := json.Marshal(runnerJob{
+		Code:           code,
+		Suites:         jobSuites,
+		Cases:          tests.Cases,
+		Nonce:          nonce,
+		PassMarker:     PassMarker,
+		CPUSeconds:     max(1, int(g.limits.CPUTime.Seconds())),
+		MemoryBytes:    g.limits.MemoryBytes,
+		MaxOutputBytes: g.limits.MaxOutputBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, g.limits.WallTime)
+	defer cancel()
+
+	// -I keeps PYTHONPATH, user site-packages and the cwd out of sys.path
+	cmd := exec.CommandContext(runCtx, g.python, "-I", runnerPath)
+	cmd.Dir = dir
+	cmd.Env = []string{"PYTHONIOENCODING=utf-8", "PYTHONDONTWRITEBYTECODE=1"}
+	cmd.Stdin = bytes.NewReader(job)
+	stdout := &cappedBuffer{limit: 4*g.limits.MaxOutputBytes + 1024}
+	cmd.Stdout = stdout
+	cmd.WaitDelay = time.Second
+	killProcessGroup(cmd)
+
+	start := time.Now()
+	runErr := cmd.Run()
+	duration := time.Since(start)
+
+	if runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return &Result{Passed: false, Error: ErrTimeLimit.Error(), Duration: duration}, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	result, err := parseRunnerOutput(stdout.String(), nonce)
+	if err != nil {
+		if runErr != nil {
+			// Killed by an rlimit (SIGXCPU, SIGKILL) or crashed before reporting
+			return &Result{Passed: false, Error: fmt.Sprintf("execution failed: %v", runErr), Duration: duration}, nil
+		}
+		if stdout.truncated {
+			return &Result{Passed: false, Error: ErrOutputLimit.Error(), Duration: duration}, nil
+		}
+		return nil, err
+	}
+
+	verdict := verdictOf(result, tests)
+	verdict.Duration = duration
+	return verdict, nil
+}
+
+// verdictOf turns the runner's report into a Result. The output and error of
+// hidden suites and the details of hidden cases are left out.
+func verdictOf(result *runnerResult, tests Tests) *Result {
+	suites := tests.Suites
+	verdict := &Result{
+		Passed: result.Error == "" && len(result.Suites) == len(suites) && len(result.Cases) == len(tests.Cases),
+		Output: result.UserOutput,
+		Error:  result.Error,
+	}
+
+	// Nothing to grade against means nothing was verified
+	if len(suites) == 0 && len(tests.Cases) == 0 {
+		verdict.Passed = false
+	}
+
+	for i, suiteResult := range result.Suites[:min(len(result.Suites), len(suites))] {
+		suite := suites[i]
+		if !suite.Hidden && suiteResult.Output != "" {
+			verdict.Output += "\n---\n" + suiteResult.Output
+		}
+
+		passed := suiteResult.Passed
+		verdict.Suites = append(verdict.Suites, SuiteResult{Name: suite.Name, Passed: passed, Hidden: suite.Hidden})
+		if passed {
+			continue
+		}
+
+		verdict.Passed = false
+		verdict.Error = suiteResult.Error
+		if suite.Hidden {
+			verdict.Error = HiddenFailure
+		}
+		break
+	}
+
+	for i, caseResult := range result.Cases[:min(len(result.Cases), len(tests.Cases))] {
+		c := tests.Cases[i]
+		cr := CaseResult{Name: c.Name, Passed: caseResult.Passed, Hidden: c.Hidden, TimeMS: caseResult.TimeMS}
+		if !c.Hidden {
+			cr.Expected = c.Expected
+			if c.Call == "" {
+				cr.Expected = c.ExpectedStdout
+			}
+			cr.Actual = caseResult.Actual
+			cr.Error = caseResult.Error
+		}
+
+		verdict.Passed = verdict.Passed && cr.Passed
+		verdict.Cases = append(verdict.Cases, cr)
+	}
+
+	return verdict
+}
+
+func parseRunnerOutput(stdout, nonce string) (*runnerResult, error) {
+	for line := range strings.SplitSeq(stdout, "\n") {
+		payload, ok := strings.CutPrefix(line, nonce)
+		if !ok {
+			continue
+		}
+
+		var result runnerResult
+		if err := json.Unmarshal([]byte(payload), &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	}
+
+	return nil, ErrNoVerdict
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// cappedBuffer discards anything written past limit so a chatty process
+// can't exhaust the server's memory.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.limit - b.Len(); len(p) > room {
+		p = p[:max(room, 0)]
+		b.truncated = true
+	}
+	b.Buffer.Write(p)
+	return n, nil
+}
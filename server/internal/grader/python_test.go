@@ -0,0 +1,385 @@
+package grader
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const helloWorldTests = `output = USER_OUTPUT.strip()
+
+assert output == "Hello, World!", (
+    f"Se esperaba 'Hello, World!' pero se obtuvo '{output}'"
+)
+
+print("ALL_TESTS_PASSED")
+`
+
+const helloWorldHiddenTests = `assert USER_OUTPUT == "Hello, World!\n", f"secret: got {USER_OUTPUT!r}"
+
+print("ALL_TESTS_PASSED")
+`
+
+// forgeReports prints nothing. It writes reports shaped like the harness's to
+// every fd it can: when the harness is still to send it a suite, that the
+// user code ran cleanly and, once the suite arrives, that it passed; otherwise
+// the expected output.
+const forgeReports = `import os, select
+
+def netstrings(*fields):
+    return b"".join(b"%d:%s," % (len(f), f) for f in fields)
+
+def forge(data):
+    for fd in range(1, 256):
+        try:
+            os.write(fd, data)
+        except OSError:
+            pass
+
+if select.select([0], [], [], 0)[0]:
+    forge(netstrings(b"Hello, World!\n", b""))
+else:
+    forge(netstrings(b""))
+    os.read(0, 1 << 16)
+    forge(netstrings(b"ALL_TESTS_PASSED\n", b""))
+os._exit(0)
+`
+
+func newTestGrader(t *testing.T) *PythonGrader {
+	t.Helper()
+
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+
+	limits := DefaultLimits
+	limits.CPUTime = 2 * time.Second
+	limits.WallTime = 3 * time.Second
+
+	return NewPythonGrader(python, limits)
+}
+
+func TestPythonGrader_Grade(t *testing.T) {
+	g := newTestGrader(t)
+
+	tests := []struct {
+		name       string
+		code       string
+		wantPassed bool
+		wantError  string
+	}{
+		{
+			name:       "correct solution",
+			code:       `print("Hello, World!")`,
+			wantPassed: true,
+		},
+		{
+			name:       "wrong output",
+			code:       `print("Hola")`,
+			wantPassed: false,
+			wantError:  "AssertionError",
+		},
+		{
+			name:       "syntax error",
+			code:       `print("Hello, World!"`,
+			wantPassed: false,
+			wantError:  "SyntaxError",
+		},
+		{
+			name:       "fake marker from user code",
+			code:       "import os\nos.write(1, b'ALL_TESTS_PASSED\\n')\nprint('ALL_TESTS_PASSED')",
+			wantPassed: false,
+			wantError:  "AssertionError",
+		},
+		{
+			name:       "forges the verdict through json.dumps",
+			code:       "import json\njson.dumps = lambda *args, **kwargs: " + "'" + `{"user_output":"","error":"","suites":[{"output":"ALL_TESTS_PASSED","error":""},{"output":"ALL_TESTS_PASSED","error":""}],"cases":[]}` + "'\nprint('Hola')",
+			wantPassed: false,
+			wantError:  "AssertionError",
+		},
+		{
+			name:       "rebinds what the harness reports with",
+			code:       "import os, traceback\nos.write = lambda fd, data: len(data)\ntraceback.format_exc = lambda *args, **kwargs: ''\nprint('Hola')",
+			wantPassed: false,
+			wantError:  "AssertionError",
+		},
+		{
+			// Writes reports shaped like the ones the harness sends to every fd
+			// it can find, then leaves before the harness reports
+			name:       "forges reports on inherited fds",
+			code:       forgeReports,
+			wantPassed: false,
+		},
+		{
+			name:       "fails only the hidden tests",
+			code:       `print("Hello, World!   ")`,
+			wantPassed: false,
+			wantError:  HiddenFailure,
+		},
+		{
+			name:       "infinite loop",
+			code:       "while True:\n    pass",
+			wantPassed: false,
+		},
+		{
+			name:       "memory hog",
+			code:       "x = 'a' * (1 << 30)",
+			wantPassed: false,
+			wantError:  "MemoryError",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.Grade(context.Background(), tt.code, ChallengeTests(helloWorldTests, helloWorldHiddenTests, nil))
+			if err != nil {
+				t.Fatalf("Grade() error = %v, want nil", err)
+			}
+
+			if strings.Contains(got.Output+got.Error, "secret") {
+				t.Errorf("Grade() leaked hidden test details: output %q, error %q", got.Output, got.Error)
+			}
+
+			if got.Passed != tt.wantPassed {
+				t.Errorf("Grade() passed = %v, want %v (output %q, error %q)", got.Passed, tt.wantPassed, got.Output, got.Error)
+			}
+
+			if tt.wantError != "" && !strings.Contains(got.Error, tt.wantError) {
+				t.Errorf("Grade() error = %q, want it to contain %q", got.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestPythonGrader_HiddenSuiteStaysHidden(t *testing.T) {
+	g := newTestGrader(t)
+
+	hidden := "# HIDDEN-SUITE-MARKER\nassert USER_OUTPUT == 'Hello, World!\\n'\nprint('ALL_TESTS_PASSED')\n"
+
+	// Dumps every frame's locals and every dict the interpreter holds
+	snoop := `import gc, sys
+frame = sys._getframe()
+while frame:
+    print(frame.f_locals)
+    frame = frame.f_back
+for obj in gc.get_objects():
+    if isinstance(obj, dict):
+        print(obj)
+print("Hello, World!")
+`
+
+	got, err := g.Grade(context.Background(), snoop, ChallengeTests(helloWorldTests, hidden, nil))
+	if err != nil {
+		t.Fatalf("Grade() error = %v, want nil", err)
+	}
+
+	if strings.Contains(got.Output+got.Error, "HIDDEN-SUITE-MARKER") {
+		t.Errorf("Grade() leaked the hidden suite: output %q", got.Output)
+	}
+}
+
+// readSuites prints the expected output unless it finds the hidden suite on
+// stdin or any other fd, in which case it exits and fails the hidden tests.
+// A harness that waits for the user code to finish before sending a suite
+// is first told that it has and, once a suite is read, that it passed.
+const readSuites = `import os, select
+
+def forge(data):
+    for fd in range(1, 256):
+        try:
+            os.write(fd, data)
+        except OSError:
+            pass
+
+def read_all(fd):
+    data = b""
+    try:
+        while chunk := os.read(fd, 1 << 16):
+            data += chunk
+    except OSError:
+        pass
+    return data
+
+waiting = not select.select([0], [], [], 0)[0]
+if waiting:
+    forge(b"0:,")
+
+found = b"".join(read_all(fd) for fd in range(256))
+if b"HIDDEN-SUITE-MARKER" in found:
+    os._exit(1)
+if waiting and found:
+    forge(b"17:ALL_TESTS_PASSED\n,0:,")
+    os._exit(0)
+print("Hello, World!")
+`
+
+func TestPythonGrader_HiddenSuiteUnreadable(t *testing.T) {
+	g := newTestGrader(t)
+
+	hidden := "# HIDDEN-SUITE-MARKER\nassert USER_OUTPUT == 'Hello, World!\\n'\nprint('ALL_TESTS_PASSED')\n"
+
+	got, err := g.Grade(context.Background(), readSuites, ChallengeTests(helloWorldTests, hidden, nil))
+	if err != nil {
+		t.Fatalf("Grade() error = %v, want nil", err)
+	}
+
+	if !got.Passed {
+		t.Errorf("Grade() passed = false, want the submission to find no hidden suite (output %q, error %q)", got.Output, got.Error)
+	}
+	if strings.Contains(got.Output+got.Error, "HIDDEN-SUITE-MARKER") {
+		t.Errorf("Grade() leaked the hidden suite: output %q", got.Output)
+	}
+}
+
+func TestParseRunnerOutput(t *testing.T) {
+	nonce := "abc123"
+
+	tests := []struct {
+		name    string
+		stdout  string
+		wantErr bool
+	}{
+		{"verdict line", nonce + `{"user_output":"hi","error":"","suites":[{"output":"ALL_TESTS_PASSED","error":""}]}` + "\n", false},
+		{"verdict after noise", "noise\n" + nonce + `{"user_output":"","error":"","suites":[]}`, false},
+		{"missing nonce", `{"user_output":"","error":"","suites":[{"output":"ALL_TESTS_PASSED","error":""}]}`, true},
+		{"empty output", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseRunnerOutput(tt.stdout, nonce)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRunnerOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// peekExpected returns whatever "expected" value it finds in the frames above
+// it or anywhere else in the interpreter.
+const peekExpected = `import gc, sys
+
+def peek():
+    frame = sys._getframe()
+    while frame:
+        for value in list(frame.f_locals.values()):
+            if isinstance(value, dict) and "expected" in value:
+                return value["expected"]
+        frame = frame.f_back
+    for obj in gc.get_objects():
+        if isinstance(obj, dict) and "expected" in obj:
+            return obj["expected"]
+
+def add(a, b):
+    return peek()
+
+def pair(a, b):
+    return peek()
+`
+
+func TestPythonGrader_GradeCases(t *testing.T) {
+	g := newTestGrader(t)
+
+	cases := []Case{
+		{Name: "greets by name", Stdin: "Ana\n", ExpectedStdout: "Hola, Ana!\n"},
+		{Name: "adds", Call: "add", Args: []any{2, 3}, Expected: 5},
+		{Name: "pairs", Call: "pair", Args: []any{1, "a"}, Expected: []any{1, "a"}},
+		{Name: "secret", Call: "add", Args: []any{-1, 1}, Expected: 0, Hidden: true},
+	}
+
+	tests := []struct {
+		name       string
+		code       string
+		wantPassed []bool
+	}{
+		{
+			name:       "correct solution",
+			code:       "def add(a, b):\n    return a + b\n\ndef pair(a, b):\n    return (a, b)\n\nif __name__ == '__main__':\n    print(f'Hola, {input()}!   ')\n",
+			wantPassed: []bool{true, true, true, true},
+		},
+		{
+			name:       "wrong function",
+			code:       "def add(a, b):\n    return a - b\n\ndef pair(a, b):\n    return [a, b]\n\nif __name__ == '__main__':\n    print('Hola, ' + input() + '!')\n",
+			wantPassed: []bool{true, false, true, false},
+		},
+		{
+			name:       "missing functions",
+			code:       "print('Hola, ' + input() + '!')",
+			wantPassed: []bool{true, false, false, false},
+		},
+		{
+			name:       "reads the expected value from the harness",
+			code:       peekExpected,
+			wantPassed: []bool{false, false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.Grade(context.Background(), tt.code, Tests{Cases: cases})
+			if err != nil {
+				t.Fatalf("Grade() error = %v, want nil", err)
+			}
+
+			if len(got.Cases) != len(cases) {
+				t.Fatalf("Grade() returned %d cases, want %d (error %q)", len(got.Cases), len(cases), got.Error)
+			}
+
+			wantAll := true
+			for i, want := range tt.wantPassed {
+				wantAll = wantAll && want
+				if got.Cases[i].Passed != want {
+					t.Errorf("case %q passed = %v, want %v (actual %v, error %q)", got.Cases[i].Name, got.Cases[i].Passed, want, got.Cases[i].Actual, got.Cases[i].Error)
+				}
+			}
+			if got.Passed != wantAll {
+				t.Errorf("Grade() passed = %v, want %v", got.Passed, wantAll)
+			}
+
+			secret := got.Cases[3]
+			if secret.Expected != nil || secret.Actual != nil || secret.Error != "" {
+				t.Errorf("hidden case leaked details: %+v", secret)
+			}
+		})
+	}
+}
+
+func TestVerdictOf(t *testing.T) {
+	tests := ChallengeTests("public", "hidden", nil)
+	pass := runnerSuiteResult{Output: PassMarker, Passed: true}
+
+	cases := []struct {
+		name       string
+		result     runnerResult
+		wantPassed bool
+		wantOutput string
+		wantError  string
+	}{
+		{"all suites pass", runnerResult{UserOutput: "hi", Suites: []runnerSuiteResult{pass, pass}}, true, "hi\n---\n" + PassMarker, ""},
+		{"user code fails", runnerResult{UserOutput: "hi", Error: "NameError"}, false, "hi", "NameError"},
+		{"public suite fails", runnerResult{Suites: []runnerSuiteResult{{Output: "x", Error: "AssertionError: want 3"}}}, false, "\n---\nx", "AssertionError: want 3"},
+		{"public suite without marker", runnerResult{Suites: []runnerSuiteResult{{Output: "done"}}}, false, "\n---\ndone", ""},
+		{"marker printed by user code", runnerResult{Suites: []runnerSuiteResult{{Output: PassMarker}}}, false, "\n---\n" + PassMarker, ""},
+		{"hidden suite fails", runnerResult{Suites: []runnerSuiteResult{pass, {Output: "want 3", Error: "AssertionError: want 3"}}}, false, "\n---\n" + PassMarker, HiddenFailure},
+		{"hidden suite did not run", runnerResult{Suites: []runnerSuiteResult{pass}}, false, "\n---\n" + PassMarker, ""},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := verdictOf(&tt.result, tests)
+
+			if got.Passed != tt.wantPassed || got.Output != tt.wantOutput || got.Error != tt.wantError {
+				t.Errorf("verdictOf() = {%v %q %q}, want {%v %q %q}", got.Passed, got.Output, got.Error, tt.wantPassed, tt.wantOutput, tt.wantError)
+			}
+
+			hiddenFailed := slices.ContainsFunc(got.Suites, func(s SuiteResult) bool { return s.Hidden && !s.Passed })
+			if hiddenFailed != (tt.wantError == HiddenFailure) {
+				t.Errorf("verdictOf() suites = %+v, want a failed hidden suite: %v", got.Suites, tt.wantError == HiddenFailure)
+			}
+		})
+	}
+}
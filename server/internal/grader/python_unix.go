@@ -0,0 +1,18 @@
+//go:build unix
+
+package grader
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup puts the runner in its own process group and makes
+// cancelling cmd kill the whole group, so the child interpreters it starts
+// don't outlive it.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
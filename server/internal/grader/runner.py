@@ -0,0 +1,294 @@
+# Harness used by PythonGrader. It mirrors what the browser does with Pyodide:
+# run the user's code capturing its stdout, expose it as USER_OUTPUT and then
+# run each test suite on the resulting namespace, stopping at the first suite
+# that fails. Each suite and each declarative case runs the
+# user's code again, in an interpreter of its own.
+#
+# The script plays two parts. Started by PythonGrader it is the parent: it
+# reads the job as JSON on stdin and hands every run of user code to a child,
+# a fresh interpreter running this script with --child under the job's
+# rlimits. User code never runs in the parent, which alone writes the verdict
+# to stdout, as a single line prefixed with a per-run nonce.
+#
+# A child reads its whole task from stdin before any user code runs and
+# reports only through its own stdout and stderr, which the parent reads raw.
+# User code can write anything there, so the parent trusts none of it: it
+# compares case results itself, and a suite only passes when its stdout holds
+# a token the parent swapped in for PassMarker before sending the suite.
+
+import contextlib
+import io
+import json
+import os
+import secrets
+import selectors
+import signal
+import subprocess
+import sys
+import time
+import traceback
+
+
+def apply_limits(limits):
+    try:
+        import resource
+    except ImportError:
+        return
+
+    cpu = limits["cpu_seconds"]
+    memory = limits["memory_bytes"]
+
+    resource.setrlimit(resource.RLIMIT_CPU, (cpu, cpu + 1))
+    if memory > 0:
+        resource.setrlimit(resource.RLIMIT_AS, (memory, memory))
+    resource.setrlimit(resource.RLIMIT_FSIZE, (0, 0))
+    with contextlib.suppress(ValueError, OSError):
+        resource.setrlimit(resource.RLIMIT_NPROC, (0, 0))
+
+
+def fd_writer(fd):
+    # Bound now so user code rebinding os.write can't swallow the report
+    write, encode = os.write, str.encode
+
+    def send(text):
+        data = encode(text, "utf-8", "surrogatepass")
+        while data:
+            data = data[write(fd, data):]
+
+    return send
+
+
+def code_runner():
+    # Bound now so user code rebinding them can't hide an exception or swap
+    # the captured output
+    string_io, compile_, exec_, type_ = io.StringIO, compile, exec, type
+    format_exc = traceback.format_exc
+    redirect_stdout, redirect_stderr = contextlib.redirect_stdout, contextlib.redirect_stderr
+    sys_ = sys
+
+    def describe(exc):
+        try:
+            error = format_exc(limit=-1).strip()
+        except BaseException:
+            error = ""
+        return error or type_(exc).__name__
+
+    def run(source, filename, namespace, stdin=""):
+        buf = string_io()
+        error = ""
+        saved_stdin = sys_.stdin
+        sys_.stdin = string_io(stdin)
+        try:
+            with redirect_stdout(buf), redirect_stderr(buf):
+                try:
+                    exec_(compile_(source, filename, "exec"), namespace)
+                except BaseException as exc:
+                    error = describe(exc)
+        finally:
+            sys_.stdin = saved_stdin
+        return buf.getvalue(), error
+
+    def call(func, args):
+        buf = string_io()
+        with redirect_stdout(buf), redirect_stderr(buf):
+            try:
+                return func(*args), ""
+            except BaseException as exc:
+                return None, describe(exc)
+
+    return run, call
+
+
+def normalize(text):
+    return "\n".join(line.rstrip() for line in text.rstrip().splitlines())
+
+
+def child():
+    task = json.loads(sys.stdin.buffer.read())
+    # The suite came with the task, so there is nothing left on stdin for
+    # user code to go looking for
+    devnull = os.open(os.devnull, os.O_RDONLY)
+    os.dup2(devnull, 0)
+    os.close(devnull)
+    apply_limits(task["limits"])
+    run, call = code_runner()
+    out, err = fd_writer(1), fd_writer(2)
+    dumps, perf_counter, is_callable, to_str = json.dumps, time.perf_counter, callable, str
+
+    if task["kind"] == "program":
+        output, error = run(task["code"], "solution.py", {"__name__": "__main__"})
+        out(output)
+        err(error)
+        return
+
+    if task["kind"] == "suite":
+        namespace = {"__name__": "__main__"}
+        _, error = run(task["code"], "solution.py", namespace)
+        if error:
+            err(error)
+            return
+
+        namespace["USER_OUTPUT"] = task["user_output"]
+        output, error = run(task["suite"], task["name"], namespace)
+        out(output)
+        err(error)
+        return
+
+    # A case: the child gets what it takes to run it but not the expected
+    # value, which only the parent holds. The time goes last on stderr.
+    start = perf_counter()
+    if task["call"]:
+        # Not __main__, so `if __name__ == "__main__":` blocks don't run
+        namespace = {"__name__": "solution"}
+        _, error = run(task["code"], "solution.py", namespace, task["stdin"])
+        if not error:
+            func = namespace.get(task["call"])
+            if not is_callable(func):
+                error = f"NameError: function '{task['call']}' is not defined"
+            else:
+                actual, error = call(func, task["args"])
+                if not error:
+                    out(dumps(actual, default=repr))
+    else:
+        output, error = run(task["code"], "solution.py", {"__name__": "__main__"}, task["stdin"])
+        out(output)
+
+    err(error + "\n" + to_str(round((perf_counter() - start) * 1000, 3)))
+
+
+def run_child(task, limits):
+    """Runs task in a child and returns its exit status and what it wrote to
+    stdout and stderr, each cut to max_output_bytes."""
+    proc = subprocess.Popen(
+        [sys.executable, "-I", os.path.abspath(__file__), "--child"],
+        stdin=subprocess.PIPE,
+        stdout=subprocess.PIPE,
+        stderr=subprocess.PIPE,
+    )
+    with contextlib.suppress(BrokenPipeError):
+        proc.stdin.write(json.dumps(task).encode())
+    with contextlib.suppress(BrokenPipeError):
+        proc.stdin.close()
+
+    limit = limits["max_output_bytes"]
+    kept = {proc.stdout: b"", proc.stderr: b""}
+    with selectors.DefaultSelector() as selector:
+        for stream in kept:
+            selector.register(stream, selectors.EVENT_READ)
+        while selector.get_map():
+            for key, _ in selector.select():
+                chunk = os.read(key.fd, 1 << 16)
+                if not chunk:
+                    selector.unregister(key.fileobj)
+                    key.fileobj.close()
+                kept[key.fileobj] += chunk[:limit - len(kept[key.fileobj])]
+
+    stdout, stderr = (data.decode("utf-8", "replace") for data in kept.values())
+    return proc.wait(), stdout, stderr
+
+
+def failure(status):
+    """Describes why a child stopped early."""
+    if status < 0:
+        return f"execution failed: killed by {signal.Signals(-status).name}"
+    return f"execution failed: exited with status {status}"
+
+
+def run_suites(job, limits):
+    # USER_OUTPUT comes from a run with no tests around, then every suite
+    # gets a child of its own, so no hidden suite ever shares an interpreter
+    # with the run whose output the student sees
+    status, user_output, error = run_child({"kind": "program", "code": job["code"], "limits": limits}, limits)
+    if status:
+        error = error or failure(status)
+    if error:
+        return user_output, error, []
+
+    suites = []
+    for suite in job["suites"]:
+        result = run_suite(job, suite, user_output, limits)
+        suites.append(result)
+        if not result["passed"]:
+            break
+
+    return user_output, error, suites
+
+
+def run_suite(job, suite, user_output, limits):
+    # Only the suite knows the token, so user code printing PassMarker, or
+    # anything else it likes, can't pass it
+    marker, token = job["pass_marker"], secrets.token_hex(16)
+    status, output, error = run_child({
+        "kind": "suite",
+        "code": job["code"],
+        "name": suite["name"],
+        "suite": suite["code"].replace(marker, token),
+        "user_output": user_output,
+        "limits": limits,
+    }, limits)
+    if status:
+        error = error or failure(status)
+
+    passed = not error and token in output
+    return {"output": output.replace(token, marker), "error": error, "passed": passed}
+
+
+def run_case(job, case, limits):
+    status, actual, stderr = run_child({
+        "kind": "case",
+        "code": job["code"],
+        "stdin": case.get("stdin", ""),
+        "call": case.get("call", ""),
+        "args": case.get("args") or [],
+        "limits": limits,
+    }, limits)
+    if status:
+        return {"passed": False, "actual": None, "error": failure(status), "time_ms": 0}
+
+    # The child ran user code, so what it wrote is checked before it's used
+    error, _, time_ms = stderr.rpartition("\n")
+    try:
+        time_ms = float(time_ms)
+    except ValueError:
+        error, time_ms = stderr or "the case reported no usable result", 0
+
+    if case.get("call"):
+        try:
+            actual = json.loads(actual) if not error else None
+        except ValueError:
+            actual, error = None, "the case reported no usable result"
+        passed = not error and actual == case.get("expected")
+    else:
+        passed = not error and normalize(actual) == normalize(case.get("expected_stdout", ""))
+
+    return {"passed": passed, "actual": actual, "error": error, "time_ms": time_ms}
+
+
+def main():
+    job = json.loads(sys.stdin.read())
+    limits = {
+        "cpu_seconds": job["cpu_seconds"],
+        "memory_bytes": job["memory_bytes"],
+        "max_output_bytes": job["max_output_bytes"],
+    }
+
+    user_output, error, suites = "", "", []
+    if job["suites"]:
+        user_output, error, suites = run_suites(job, limits)
+
+    cases = [run_case(job, case, limits) for case in job["cases"] or []]
+
+    result = {
+        "user_output": user_output,
+        "error": error,
+        "suites": suites,
+        "cases": cases,
+    }
+    sys.stdout.write(job["nonce"] + json.dumps(result) + "\n")
+
+
+if __name__ == "__main__":
+    if sys.argv[1:] == ["--child"]:
+        child()
+    else:
+        main()
@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"apschool/internal/pagination"
+	"apschool/internal/response"
+	"apschool/internal/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+// ListJobsHandler serves an optional ?status= so admins can find the jobs
+// that ended up dead.
+func (h *Handler) ListJobsHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	v := validator.New()
+
+	status := qs.Get("status")
+	if status != "" {
+		v.Check(validator.PermittedValue(status, StatusQueued, StatusRunning, StatusDone, StatusDead), "status", "must be queued, running, done or dead")
+	}
+
+	filters := pagination.FromQuery(qs, v)
+
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	jobs, metadata, err := h.service.ListJobs(r.Context(), status, filters)
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"jobs": jobs, "metadata": metadata}, nil)
+}
+
+// RetryJobHandler requeues a dead job. Jobs in any other state are not found.
+func (h *Handler) RetryJobHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid id")
+		return
+	}
+
+	if err := h.service.RetryJob(r.Context(), id); err != nil {
+		if errors.Is(err, ErrJobNotFound) {
+			response.NotFound(w)
+			return
+		}
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"message": "job queued"}, nil)
+}
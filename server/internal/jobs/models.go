@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// A job is queued until a worker claims it. Failed jobs go back to queued
+// with a later run_at until they run out of attempts and become dead, the
+// dead-letter state that only an admin retry gets them out of.
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusDead    = "dead"
+)
+
+const (
+	DefaultMaxAttempts = 5
+
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+)
+
+type Job struct {
+	ID          int             `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitzero"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+}
+
+// Backoff is how long to wait before retrying a job that failed its
+// attempt-th attempt: 10s, 20s, 40s... up to an hour.
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt > 20 {
+		return maxBackoff
+	}
+	return min(baseBackoff<<(attempt-1), maxBackoff)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that retrying won't fix, such as a payload that
+// doesn't decode. The job goes straight to dead.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,183 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"apschool/internal/pagination"
+)
+
+type RepositoryInterface interface {
+	Insert(ctx context.Context, kind, uniqueKey string, payload any) (int, error)
+	Claim(ctx context.Context, kinds []string, lease time.Duration) (*Job, error)
+	Complete(ctx context.Context, id int) error
+	Retry(ctx context.Context, id int, runAt time.Time, lastError string) error
+	Bury(ctx context.Context, id int, lastError string) error
+	Revive(ctx context.Context, id int) error
+	List(ctx context.Context, status string, filters pagination.Filters) ([]Job, pagination.Metadata, error)
+}
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Insert queues a job. With a unique key nothing is inserted while another
+// job with the same key is still queued or running, and the returned id is 0.
+func (r *Repository) Insert(ctx context.Context, kind, uniqueKey string, payload any) (int, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO jobs (kind, payload, unique_key, max_attempts)
+	VALUES ($1, $2, NULLIF($3, ''), $4)
+	ON CONFLICT (unique_key) WHERE status IN ('queued', 'running') DO NOTHING
+	RETURNING id`
+
+	var id int
+	err = r.db.QueryRowContext(ctx, query, kind, data, uniqueKey, DefaultMaxAttempts).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// Claim locks the oldest due job of one of the given kinds and marks it as
+// running. Jobs left running past the lease, because their worker died, are
+// claimable again. SKIP LOCKED lets any number of workers poll at once. It
+// returns sql.ErrNoRows when there is nothing to do.
+func (r *Repository) Claim(ctx context.Context, kinds []string, lease time.Duration) (*Job, error) {
+
+	query := `UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_at = NOW(), updated_at = NOW()
+	WHERE id = (
+		SELECT id FROM jobs
+		WHERE kind = ANY($1)
+			AND ((status = 'queued' AND run_at <= NOW())
+				OR (status = 'running' AND locked_at < NOW() - make_interval(secs => $2)))
+		ORDER BY run_at, id
+		FOR UPDATE SKIP LOCKED
+		LIMIT 1
+	)
+	RETURNING id, kind, payload, status, attempts, max_attempts, last_error, run_at, created_at`
+
+	var job Job
+	err := r.db.QueryRowContext(ctx, query, kinds, lease.Seconds()).Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Payload,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.LastError,
+		&job.RunAt,
+		&job.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (r *Repository) Complete(ctx context.Context, id int) error {
+
+	query := `UPDATE jobs SET status = 'done', last_error = '', locked_at = NULL, updated_at = NOW(), finished_at = NOW()
+	WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// Retry puts a failed job back in the queue to run again at runAt.
+func (r *Repository) Retry(ctx context.Context, id int, runAt time.Time, lastError string) error {
+
+	query := `UPDATE jobs SET status = 'queued', run_at = $2, last_error = $3, locked_at = NULL, updated_at = NOW()
+	WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, runAt, lastError)
+	return err
+}
+
+// Bury moves a job to the dead-letter state.
+func (r *Repository) Bury(ctx context.Context, id int, lastError string) error {
+
+	query := `UPDATE jobs SET status = 'dead', last_error = $2, locked_at = NULL, updated_at = NOW(), finished_at = NOW()
+	WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, lastError)
+	return err
+}
+
+// Revive queues a dead job again with a fresh set of attempts.
+func (r *Repository) Revive(ctx context.Context, id int) error {
+
+	query := `UPDATE jobs SET status = 'queued', attempts = 0, run_at = NOW(), finished_at = NULL, updated_at = NOW()
+	WHERE id = $1 AND status = 'dead'`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// List returns jobs newest first, optionally only those with the given
+// status.
+func (r *Repository) List(ctx context.Context, status string, filters pagination.Filters) ([]Job, pagination.Metadata, error) {
+
+	query := `SELECT count(*) OVER(), id, kind, payload, status, attempts, max_attempts, last_error, run_at, created_at, finished_at
+	FROM jobs
+	WHERE ($1 = '' OR status = $1)
+	ORDER BY created_at DESC, id DESC
+	LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryContext(ctx, query, status, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	jobs := []Job{}
+	for rows.Next() {
+		var job Job
+		err := rows.Scan(
+			&totalRecords,
+			&job.ID,
+			&job.Kind,
+			&job.Payload,
+			&job.Status,
+			&job.Attempts,
+			&job.MaxAttempts,
+			&job.LastError,
+			&job.RunAt,
+			&job.CreatedAt,
+			&job.FinishedAt,
+		)
+		if err != nil {
+			return nil, pagination.Metadata{}, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+
+	return jobs, pagination.NewMetadata(totalRecords, filters), nil
+}
@@ -0,0 +1,355 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"apschool/internal/pagination"
+	"apschool/internal/testutil"
+)
+
+var testDB *testutil.TestDB
+
+func TestMain(m *testing.M) {
+	// Parse flags first so we can check -short
+	flag.Parse()
+
+	// Skip container setup entirely in short mode (for CI without Docker)
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	ctx := context.Background()
+
+	var err error
+	testDB, err = testutil.SetupTestDB(ctx)
+	if err != nil {
+		panic("failed to setup test db: " + err.Error())
+	}
+
+	code := m.Run()
+
+	testDB.Teardown(ctx)
+	os.Exit(code)
+}
+
+// setupRepository empties the queue, which no user row cascades to.
+func setupRepository(t *testing.T) *Repository {
+	t.Helper()
+
+	if _, err := testDB.DB.Exec("TRUNCATE jobs"); err != nil {
+		t.Fatalf("failed to truncate jobs: %v", err)
+	}
+	return NewRepository(testDB.DB)
+}
+
+func getJob(t *testing.T, id int) Job {
+	t.Helper()
+
+	var job Job
+	err := testDB.DB.QueryRow(`SELECT id, kind, status, attempts, last_error, run_at FROM jobs WHERE id = $1`, id).Scan(
+		&job.ID, &job.Kind, &job.Status, &job.Attempts, &job.LastError, &job.RunAt,
+	)
+	if err != nil {
+		t.Fatalf("reading job %d: %v", id, err)
+	}
+	return job
+}
+
+func insertJob(t *testing.T, repo *Repository, kind string) int {
+	t.Helper()
+
+	id, err := repo.Insert(context.Background(), kind, "", map[string]int{"n": 1})
+	if err != nil || id == 0 {
+		t.Fatalf("Insert() = %d, %v", id, err)
+	}
+	return id
+}
+
+func TestRepository_Claim(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t)
+	ctx := context.Background()
+
+	first := insertJob(t, repo, "email")
+	insertJob(t, repo, "report")
+	second := insertJob(t, repo, "email")
+
+	job, err := repo.Claim(ctx, []string{"email"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if job.ID != first || job.Status != StatusRunning || job.Attempts != 1 {
+		t.Errorf("Claim() = job %d %s attempt %d, want job %d running attempt 1", job.ID, job.Status, job.Attempts, first)
+	}
+	if string(job.Payload) != `{"n": 1}` {
+		t.Errorf("payload = %s", job.Payload)
+	}
+
+	job, err = repo.Claim(ctx, []string{"email"}, time.Hour)
+	if err != nil || job.ID != second {
+		t.Fatalf("second Claim() = %v, %v, want job %d", job, err, second)
+	}
+
+	if _, err := repo.Claim(ctx, []string{"email"}, time.Hour); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Claim() on an empty queue error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestRepository_ClaimSkipsLocked(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t)
+	ctx := context.Background()
+
+	locked := insertJob(t, repo, "email")
+	free := insertJob(t, repo, "email")
+
+	// Another worker is halfway through claiming the oldest job
+	tx, err := testDB.DB.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`SELECT id FROM jobs WHERE id = $1 FOR UPDATE`, locked); err != nil {
+		t.Fatal(err)
+	}
+
+	claimCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	job, err := repo.Claim(claimCtx, []string{"email"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Claim() error = %v, want it to skip the locked job", err)
+	}
+	if job.ID != free {
+		t.Errorf("Claim() = job %d, want %d", job.ID, free)
+	}
+}
+
+func TestRepository_ClaimConcurrently(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t)
+	const workers = 8
+	for range workers {
+		insertJob(t, repo, "email")
+	}
+
+	var (
+		mu      sync.Mutex
+		claimed = make(map[int]int)
+		wg      sync.WaitGroup
+	)
+	for range workers * 2 {
+		wg.Go(func() {
+			job, err := repo.Claim(context.Background(), []string{"email"}, time.Hour)
+			if errors.Is(err, sql.ErrNoRows) {
+				return
+			}
+			if err != nil {
+				t.Errorf("Claim() error = %v", err)
+				return
+			}
+			mu.Lock()
+			claimed[job.ID]++
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	if len(claimed) != workers {
+		t.Errorf("claimed %d distinct jobs, want %d", len(claimed), workers)
+	}
+	for id, n := range claimed {
+		if n != 1 {
+			t.Errorf("job %d claimed %d times", id, n)
+		}
+	}
+}
+
+func TestRepository_ClaimExpiredLease(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t)
+	ctx := context.Background()
+	id := insertJob(t, repo, "email")
+
+	if _, err := repo.Claim(ctx, []string{"email"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// Still within the lease, so nobody else may take it
+	if _, err := repo.Claim(ctx, []string{"email"}, time.Hour); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Claim() within the lease error = %v, want sql.ErrNoRows", err)
+	}
+
+	// The worker died two hours ago
+	if _, err := testDB.DB.Exec(`UPDATE jobs SET locked_at = NOW() - interval '2 hours' WHERE id = $1`, id); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := repo.Claim(ctx, []string{"email"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Claim() past the lease error = %v", err)
+	}
+	if job.ID != id || job.Attempts != 2 {
+		t.Errorf("Claim() = job %d attempt %d, want job %d attempt 2", job.ID, job.Attempts, id)
+	}
+}
+
+func TestRepository_RetryUntilDead(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t)
+	ctx := context.Background()
+	id := insertJob(t, repo, "email")
+
+	runner := newTestRunner(repo, 1)
+	runner.Handle("email", func(ctx context.Context, payload json.RawMessage) error {
+		return errors.New("smtp timeout")
+	})
+
+	for attempt := 1; attempt <= DefaultMaxAttempts; attempt++ {
+		job, err := repo.Claim(ctx, []string{"email"}, lease)
+		if err != nil {
+			t.Fatalf("attempt %d: Claim() error = %v", attempt, err)
+		}
+		runner.process(job)
+
+		got := getJob(t, id)
+		if attempt < DefaultMaxAttempts {
+			if got.Status != StatusQueued || !got.RunAt.After(time.Now()) {
+				t.Fatalf("attempt %d: job %s due %v, want queued with a backoff", attempt, got.Status, got.RunAt)
+			}
+			// Not due yet, so it can't be claimed before its backoff
+			if _, err := repo.Claim(ctx, []string{"email"}, lease); !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("attempt %d: Claim() during backoff error = %v, want sql.ErrNoRows", attempt, err)
+			}
+			if _, err := testDB.DB.Exec(`UPDATE jobs SET run_at = NOW() WHERE id = $1`, id); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	got := getJob(t, id)
+	if got.Status != StatusDead || got.LastError != "smtp timeout" || got.Attempts != DefaultMaxAttempts {
+		t.Errorf("job = %s %q after %d attempts, want dead with the last error", got.Status, got.LastError, got.Attempts)
+	}
+
+	dead, _, err := repo.List(ctx, StatusDead, pagination.Filters{Page: 1, PageSize: 10})
+	if err != nil || len(dead) != 1 || dead[0].FinishedAt == nil {
+		t.Errorf("List(dead) = %v, %v, want the finished job", dead, err)
+	}
+
+	// An admin retry brings it back with a fresh set of attempts
+	if err := repo.Revive(ctx, id); err != nil {
+		t.Fatalf("Revive() error = %v", err)
+	}
+	if got := getJob(t, id); got.Status != StatusQueued || got.Attempts != 0 {
+		t.Errorf("revived job = %s attempt %d, want queued attempt 0", got.Status, got.Attempts)
+	}
+	if err := repo.Revive(ctx, id); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Revive() of a queued job error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestRepository_InsertUniqueKey(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t)
+	ctx := context.Background()
+
+	first, err := repo.Insert(ctx, "leaderboard.refresh", "leaderboard.refresh", struct{}{})
+	if err != nil || first == 0 {
+		t.Fatalf("Insert() = %d, %v", first, err)
+	}
+
+	// Another process's schedule fires while the first copy is pending
+	id, err := repo.Insert(ctx, "leaderboard.refresh", "leaderboard.refresh", struct{}{})
+	if err != nil || id != 0 {
+		t.Fatalf("Insert() of a pending key = %d, %v, want 0", id, err)
+	}
+
+	if _, err := repo.Claim(ctx, []string{"leaderboard.refresh"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := repo.Insert(ctx, "leaderboard.refresh", "leaderboard.refresh", struct{}{}); id != 0 {
+		t.Fatalf("Insert() of a running key = %d, want 0", id)
+	}
+
+	// Jobs without a key never collide
+	for range 2 {
+		if id, err := repo.Insert(ctx, "email", "", struct{}{}); err != nil || id == 0 {
+			t.Fatalf("Insert() without a key = %d, %v", id, err)
+		}
+	}
+
+	if err := repo.Complete(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	id, err = repo.Insert(ctx, "leaderboard.refresh", "leaderboard.refresh", struct{}{})
+	if err != nil || id == 0 {
+		t.Errorf("Insert() once the key is done = %d, %v, want a new job", id, err)
+	}
+}
+
+func TestRepository_ShutdownDrains(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := setupRepository(t)
+	id := insertJob(t, repo, "email")
+
+	started, release := make(chan struct{}), make(chan struct{})
+	runner := newTestRunner(repo, 1)
+	runner.Handle("email", func(ctx context.Context, payload json.RawMessage) error {
+		close(started)
+		<-release
+		return nil
+	})
+	runner.Start()
+
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the job was never claimed")
+	}
+
+	// Shutting down stops the workers claiming the job queued now
+	later := insertJob(t, repo, "email")
+	shutdown := make(chan error)
+	go func() { shutdown <- runner.Shutdown(context.Background()) }()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got := getJob(t, id); got.Status != StatusDone {
+		t.Errorf("in-flight job = %s, want it finished before Shutdown returned", got.Status)
+	}
+	if got := getJob(t, later); got.Status != StatusQueued {
+		t.Errorf("job queued during shutdown = %s, want it left queued", got.Status)
+	}
+}
@@ -0,0 +1,238 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// pollInterval is how long an idle worker waits before looking for work
+	// again.
+	pollInterval = time.Second
+
+	// JobTimeout bounds a single run of a job. A job still marked running a
+	// minute past it is assumed to have lost its worker and is claimed again.
+	JobTimeout = 10 * time.Minute
+	lease      = JobTimeout + time.Minute
+)
+
+// HandlerFunc runs one job. Returning an error retries the job with backoff
+// unless it is wrapped with Permanent.
+type HandlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// DeadFunc is called with the error that killed a job once it is buried, so
+// whatever the job was meant to finish can be marked as failed instead of
+// being left half done.
+type DeadFunc func(ctx context.Context, payload json.RawMessage, cause error) error
+
+type schedule struct {
+	kind     string
+	interval time.Duration
+}
+
+// Runner is a pool of workers that claim and run jobs. Any number of runners,
+// in the API process or in cmd/worker, can share the same queue.
+type Runner struct {
+	repo     RepositoryInterface
+	logger   *slog.Logger
+	workers  int
+	handlers map[string]HandlerFunc
+	onDead   map[string]DeadFunc
+	periodic []schedule
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+
+	// ctx is only cancelled when Shutdown gives up on in-flight jobs
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewRunner(repo RepositoryInterface, workers int, logger *slog.Logger) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		repo:     repo,
+		logger:   logger,
+		workers:  workers,
+		handlers: make(map[string]HandlerFunc),
+		onDead:   make(map[string]DeadFunc),
+		quit:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Handle registers the function that runs jobs of the given kind. Runners
+// only claim kinds they have a handler for. It must be called before Start.
+func (r *Runner) Handle(kind string, fn HandlerFunc) {
+	r.handlers[kind] = fn
+}
+
+// OnDead registers the function called when a job of the given kind is
+// buried, whether it failed its last attempt, failed permanently or was
+// reclaimed past its attempts. It must be called before Start.
+func (r *Runner) OnDead(kind string, fn DeadFunc) {
+	r.onDead[kind] = fn
+}
+
+// Every enqueues a job of the given kind each interval while the runner is
+// started. The job is keyed by its kind, so runners in several processes
+// don't stack up copies while one is still pending.
+func (r *Runner) Every(kind string, interval time.Duration) {
+	r.periodic = append(r.periodic, schedule{kind: kind, interval: interval})
+}
+
+func (r *Runner) Start() {
+	kinds := make([]string, 0, len(r.handlers))
+	for kind := range r.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	for range r.workers {
+		r.wg.Add(1)
+		go r.work(kinds)
+	}
+
+	for _, s := range r.periodic {
+		r.wg.Add(1)
+		go r.schedule(s)
+	}
+}
+
+// Shutdown stops claiming jobs and waits for the ones in flight to finish.
+// If ctx expires first their contexts are cancelled, they are left to be
+// retried, and Shutdown returns the context's error.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	close(r.quit)
+
+	drained := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		r.cancel()
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		<-drained
+		return ctx.Err()
+	}
+}
+
+func (r *Runner) work(kinds []string) {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.quit:
+			return
+		default:
+		}
+
+		job, err := r.repo.Claim(r.ctx, kinds, lease)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				r.logger.Error("claiming job failed", "error", err)
+			}
+
+			select {
+			case <-r.quit:
+				return
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+
+		r.process(job)
+	}
+}
+
+func (r *Runner) process(job *Job) {
+	// Bookkeeping must happen even after a forced shutdown
+	ctx := context.WithoutCancel(r.ctx)
+	logger := r.logger.With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+
+	// A job reclaimed after its worker died counts that lost run as an
+	// attempt, so one that keeps crashing workers still ends up dead
+	if job.Attempts > job.MaxAttempts {
+		logger.Error("job exceeded its attempts")
+		if err := r.bury(ctx, job, errors.New("exceeded max attempts"), logger); err != nil {
+			logger.Error("burying job failed", "error", err)
+		}
+		return
+	}
+
+	err := r.run(job)
+
+	switch {
+	case err == nil:
+		err = r.repo.Complete(ctx, job.ID)
+	case isPermanent(err) || job.Attempts >= job.MaxAttempts:
+		logger.Error("job failed for good", "error", err)
+		err = r.bury(ctx, job, err, logger)
+	default:
+		logger.Warn("job failed, will retry", "error", err)
+		err = r.repo.Retry(ctx, job.ID, time.Now().Add(Backoff(job.Attempts)), err.Error())
+	}
+
+	if err != nil {
+		logger.Error("recording job outcome failed", "error", err)
+	}
+}
+
+// bury moves the job to dead and then calls the kind's DeadFunc, whose
+// failure is only logged since the job is dead either way.
+func (r *Runner) bury(ctx context.Context, job *Job, cause error, logger *slog.Logger) error {
+	if err := r.repo.Bury(ctx, job.ID, cause.Error()); err != nil {
+		return err
+	}
+
+	if fn := r.onDead[job.Kind]; fn != nil {
+		if err := fn(ctx, job.Payload, cause); err != nil {
+			logger.Error("handling dead job failed", "error", err)
+		}
+	}
+	return nil
+}
+
+// run calls the job's handler, turning a panic into an error so one bad job
+// doesn't take the process down.
+func (r *Runner) run(job *Job) (err error) {
+	ctx, cancel := context.WithTimeout(r.ctx, JobTimeout)
+	defer cancel()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	return r.handlers[job.Kind](ctx, job.Payload)
+}
+
+func (r *Runner) schedule(s schedule) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.quit:
+			return
+		case <-ticker.C:
+			if _, err := r.repo.Insert(r.ctx, s.kind, s.kind, struct{}{}); err != nil {
+				r.logger.Error("enqueueing periodic job failed", "kind", s.kind, "error", err)
+			}
+		}
+	}
+}
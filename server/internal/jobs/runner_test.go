@@ -0,0 +1,326 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"apschool/internal/pagination"
+)
+
+// fakeRepository is an in-memory queue recording what the runner did with
+// each job.
+type fakeRepository struct {
+	mu       sync.Mutex
+	queued   []*Job
+	outcomes map[int]outcome
+	inserted []string // unique keys of inserted jobs
+}
+
+type outcome struct {
+	status    string
+	runAt     time.Time
+	lastError string
+}
+
+func newFakeRepository(jobs ...*Job) *fakeRepository {
+	return &fakeRepository{queued: jobs, outcomes: make(map[int]outcome)}
+}
+
+func (f *fakeRepository) Insert(ctx context.Context, kind, uniqueKey string, payload any) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inserted = append(f.inserted, uniqueKey)
+	return len(f.inserted), nil
+}
+
+func (f *fakeRepository) Claim(ctx context.Context, kinds []string, lease time.Duration) (*Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, job := range f.queued {
+		if slices.Contains(kinds, job.Kind) {
+			f.queued = slices.Delete(f.queued, i, i+1)
+			job.Attempts++
+			job.Status = StatusRunning
+			return job, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeRepository) Complete(ctx context.Context, id int) error {
+	return f.record(id, outcome{status: StatusDone})
+}
+
+func (f *fakeRepository) Retry(ctx context.Context, id int, runAt time.Time, lastError string) error {
+	return f.record(id, outcome{status: StatusQueued, runAt: runAt, lastError: lastError})
+}
+
+func (f *fakeRepository) Bury(ctx context.Context, id int, lastError string) error {
+	return f.record(id, outcome{status: StatusDead, lastError: lastError})
+}
+
+func (f *fakeRepository) Revive(ctx context.Context, id int) error {
+	return nil
+}
+
+func (f *fakeRepository) List(ctx context.Context, status string, filters pagination.Filters) ([]Job, pagination.Metadata, error) {
+	return nil, pagination.Metadata{}, nil
+}
+
+func (f *fakeRepository) record(id int, o outcome) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.outcomes[id] = o
+	return nil
+}
+
+func (f *fakeRepository) outcome(id int) (outcome, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o, ok := f.outcomes[id]
+	return o, ok
+}
+
+func newTestRunner(repo RepositoryInterface, workers int) *Runner {
+	return NewRunner(repo, workers, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestRunner_Process(t *testing.T) {
+	errFlaky := errors.New("smtp timeout")
+
+	tests := []struct {
+		name       string
+		attempts   int // before this claim
+		handler    HandlerFunc
+		wantStatus string
+		wantError  string
+		wantRun    bool
+		wantDead   bool
+	}{
+		{
+			name:       "success",
+			handler:    func(ctx context.Context, payload json.RawMessage) error { return nil },
+			wantStatus: StatusDone,
+			wantRun:    true,
+		},
+		{
+			name:       "failure is retried",
+			handler:    func(ctx context.Context, payload json.RawMessage) error { return errFlaky },
+			wantStatus: StatusQueued,
+			wantError:  "smtp timeout",
+			wantRun:    true,
+		},
+		{
+			name:       "panic is retried",
+			handler:    func(ctx context.Context, payload json.RawMessage) error { panic("nil map") },
+			wantStatus: StatusQueued,
+			wantError:  "panic: nil map",
+			wantRun:    true,
+		},
+		{
+			name:       "permanent failure is buried",
+			handler:    func(ctx context.Context, payload json.RawMessage) error { return Permanent(errFlaky) },
+			wantStatus: StatusDead,
+			wantError:  "smtp timeout",
+			wantRun:    true,
+			wantDead:   true,
+		},
+		{
+			name:       "failing the last attempt buries",
+			attempts:   DefaultMaxAttempts - 1,
+			handler:    func(ctx context.Context, payload json.RawMessage) error { return errFlaky },
+			wantStatus: StatusDead,
+			wantError:  "smtp timeout",
+			wantRun:    true,
+			wantDead:   true,
+		},
+		{
+			name:       "reclaimed past its attempts is buried unrun",
+			attempts:   DefaultMaxAttempts,
+			handler:    func(ctx context.Context, payload json.RawMessage) error { return nil },
+			wantStatus: StatusDead,
+			wantError:  "exceeded max attempts",
+			wantDead:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository(&Job{ID: 1, Kind: "email", Payload: json.RawMessage(`{"to": 1}`), Attempts: tt.attempts, MaxAttempts: DefaultMaxAttempts})
+			runner := newTestRunner(repo, 1)
+
+			var dead error
+			runner.OnDead("email", func(ctx context.Context, payload json.RawMessage, cause error) error {
+				if string(payload) != `{"to": 1}` {
+					t.Errorf("OnDead payload = %s, want the job's", payload)
+				}
+				dead = cause
+				return nil
+			})
+
+			ran := false
+			runner.Handle("email", func(ctx context.Context, payload json.RawMessage) error {
+				ran = true
+				return tt.handler(ctx, payload)
+			})
+
+			job, err := repo.Claim(context.Background(), []string{"email"}, lease)
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := time.Now()
+			runner.process(job)
+
+			if ran != tt.wantRun {
+				t.Errorf("handler ran = %v, want %v", ran, tt.wantRun)
+			}
+
+			got, ok := repo.outcome(1)
+			if !ok {
+				t.Fatal("no outcome recorded")
+			}
+			if got.status != tt.wantStatus || got.lastError != tt.wantError {
+				t.Errorf("outcome = %s %q, want %s %q", got.status, got.lastError, tt.wantStatus, tt.wantError)
+			}
+			if (dead != nil) != tt.wantDead {
+				t.Errorf("OnDead called with %v, want a call: %v", dead, tt.wantDead)
+			}
+			if dead != nil && dead.Error() != tt.wantError {
+				t.Errorf("OnDead cause = %q, want %q", dead, tt.wantError)
+			}
+			if got.status == StatusQueued {
+				if wait := got.runAt.Sub(before); wait < Backoff(job.Attempts) || wait > Backoff(job.Attempts)+time.Second {
+					t.Errorf("retry in %v, want %v", wait, Backoff(job.Attempts))
+				}
+			}
+		})
+	}
+}
+
+func TestRunner_OnlyClaimsHandledKinds(t *testing.T) {
+	repo := newFakeRepository(
+		&Job{ID: 1, Kind: "report", MaxAttempts: DefaultMaxAttempts},
+		&Job{ID: 2, Kind: "email", MaxAttempts: DefaultMaxAttempts},
+	)
+	runner := newTestRunner(repo, 2)
+
+	done := make(chan int, 2)
+	runner.Handle("email", func(ctx context.Context, payload json.RawMessage) error {
+		done <- 2
+		return nil
+	})
+	runner.Start()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the email job never ran")
+	}
+	if err := runner.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if _, ok := repo.outcome(1); ok {
+		t.Error("runner claimed a kind it has no handler for")
+	}
+	if got, _ := repo.outcome(2); got.status != StatusDone {
+		t.Errorf("email job status = %q, want %q", got.status, StatusDone)
+	}
+}
+
+func TestRunner_ShutdownDrains(t *testing.T) {
+	repo := newFakeRepository(&Job{ID: 1, Kind: "email", MaxAttempts: DefaultMaxAttempts})
+	runner := newTestRunner(repo, 1)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	runner.Handle("email", func(ctx context.Context, payload json.RawMessage) error {
+		close(started)
+		<-release
+		return ctx.Err()
+	})
+	runner.Start()
+	<-started
+
+	shutdown := make(chan error)
+	go func() { shutdown <- runner.Shutdown(context.Background()) }()
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown() returned %v with a job in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got, _ := repo.outcome(1); got.status != StatusDone {
+		t.Errorf("status = %q, want the drained job done", got.status)
+	}
+}
+
+func TestRunner_ShutdownTimeout(t *testing.T) {
+	repo := newFakeRepository(&Job{ID: 1, Kind: "email", MaxAttempts: DefaultMaxAttempts})
+	runner := newTestRunner(repo, 1)
+
+	started := make(chan struct{})
+	runner.Handle("email", func(ctx context.Context, payload json.RawMessage) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	runner.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := runner.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// The cancelled job is left for another worker to retry
+	if got, _ := repo.outcome(1); got.status != StatusQueued {
+		t.Errorf("status = %q, want %q", got.status, StatusQueued)
+	}
+}
+
+func TestRunner_Every(t *testing.T) {
+	repo := newFakeRepository()
+	runner := newTestRunner(repo, 0)
+	runner.Every("leaderboard.refresh", 10*time.Millisecond)
+	runner.Start()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		repo.mu.Lock()
+		n := len(repo.inserted)
+		repo.mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("enqueued %d periodic jobs, want at least 2", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := runner.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, key := range repo.inserted {
+		if key != "leaderboard.refresh" {
+			t.Errorf("periodic job keyed %q, want its kind", key)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"apschool/internal/pagination"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Enqueue queues a job for the first free worker. The payload is stored as
+// JSON and handed back to the kind's HandlerFunc.
+func (s *Service) Enqueue(ctx context.Context, kind string, payload any) (int, error) {
+	return s.repo.Insert(ctx, kind, "", payload)
+}
+
+func (s *Service) ListJobs(ctx context.Context, status string, filters pagination.Filters) ([]Job, pagination.Metadata, error) {
+	return s.repo.List(ctx, status, filters)
+}
+
+// RetryJob gives a dead job another full set of attempts.
+func (s *Service) RetryJob(ctx context.Context, id int) error {
+	err := s.repo.Revive(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrJobNotFound
+	}
+	return err
+}
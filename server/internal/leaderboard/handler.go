@@ -0,0 +1,121 @@
+package leaderboard
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"apschool/internal/ctxkeys"
+	"apschool/internal/pagination"
+	"apschool/internal/response"
+	"apschool/internal/validator"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+// GetLeaderboardHandler serves ?window=all|week and an optional ?unit=. When
+// the caller is signed in the response also carries their own entry.
+func (h *Handler) GetLeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	v := validator.New()
+
+	window := qs.Get("window")
+	if window == "" {
+		window = WindowAll
+	}
+	v.Check(validator.PermittedValue(window, WindowAll, WindowWeek), "window", "must be all or week")
+
+	unit := qs.Get("unit")
+	if unit != "" {
+		v.Check(validator.Matches(unit, validator.SlugRegex), "unit", "must be a unit slug")
+	}
+
+	filters := pagination.FromQuery(qs, v)
+
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	q := Query{Unit: unit}
+	if window == WindowWeek {
+		q.Since = WeekStart(time.Now())
+	}
+
+	entries, metadata, err := h.service.List(r.Context(), q, filters)
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	env := response.Envelope{"leaderboard": entries, "metadata": metadata}
+
+	if userID, ok := ctxkeys.GetUserID(r.Context()); ok {
+		me, err := h.service.GetUserEntry(r.Context(), q, userID)
+		if err != nil {
+			response.ServerError(w, r, h.logger, err)
+			return
+		}
+		env["me"] = me
+	}
+
+	response.WriteJSON(w, http.StatusOK, env, nil)
+}
+
+func (h *Handler) GetParticipationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+
+	optOut, err := h.service.GetOptOut(r.Context(), userID)
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"opt_out": optOut}, nil)
+}
+
+// UpdateParticipationHandler lets users hide themselves from the rankings.
+// It takes effect immediately since opt-outs are read from users, not from
+// the cached view.
+func (h *Handler) UpdateParticipationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+
+	var input struct {
+		OptOut *bool `json:"opt_out"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.OptOut != nil, "opt_out", "is required")
+
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	if err := h.service.SetOptOut(r.Context(), userID, *input.OptOut); err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"opt_out": *input.OptOut}, nil)
+}
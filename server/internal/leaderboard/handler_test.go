@@ -0,0 +1,156 @@
+package leaderboard
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"apschool/internal/auth"
+	"apschool/internal/ctxkeys"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newLeaderboardRouter mounts the leaderboard handlers the way routes.go
+// does, minus the auth middleware.
+func newLeaderboardRouter(repo *Repository) http.Handler {
+	h := NewHandler(NewService(repo), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r := chi.NewRouter()
+	r.Route("/api/leaderboard", func(r chi.Router) {
+		r.Get("/", h.GetLeaderboardHandler)
+		r.Get("/participation", h.GetParticipationHandler)
+		r.Put("/participation", h.UpdateParticipationHandler)
+	})
+	return r
+}
+
+// serveAs sends the request as the given user, the way the auth middleware
+// leaves the context. A zero userID sends it signed out.
+func serveAs(router http.Handler, userID int, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if userID != 0 {
+		ctx := context.WithValue(req.Context(), ctxkeys.UserIDKey, userID)
+		ctx = context.WithValue(ctx, ctxkeys.RoleKey, auth.RoleStudent)
+		req = req.WithContext(ctx)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestGetLeaderboardHandler(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	router := newLeaderboardRouter(repo)
+	users := seedSolves(t, ids)
+	if err := repo.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		userID     int
+		target     string
+		wantStatus int
+		wantMe     string // JSON of the me field, empty when absent
+	}{
+		{name: "signed out", target: "/api/leaderboard/", wantStatus: http.StatusOK},
+		{name: "ranked", userID: users["ana"], target: "/api/leaderboard/", wantStatus: http.StatusOK, wantMe: "ana"},
+		{name: "unranked", userID: users["dani"], target: "/api/leaderboard/", wantStatus: http.StatusOK, wantMe: "null"},
+		{name: "unit", userID: users["ana"], target: "/api/leaderboard/?unit=unit-2", wantStatus: http.StatusOK, wantMe: "null"},
+		{name: "unknown window", target: "/api/leaderboard/?window=month", wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serveAs(router, tt.userID, http.MethodGet, tt.target, "")
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var envelope map[string]json.RawMessage
+			if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+				t.Fatalf("decoding %s: %v", rr.Body, err)
+			}
+
+			me, ok := envelope["me"]
+			switch {
+			case tt.wantMe == "":
+				if ok {
+					t.Errorf("me = %s, want it absent when signed out", me)
+				}
+			case tt.wantMe == "null":
+				if string(me) != "null" {
+					t.Errorf("me = %s, want null", me)
+				}
+			default:
+				var entry Entry
+				if err := json.Unmarshal(me, &entry); err != nil || entry.Username != tt.wantMe || entry.Rank != 2 {
+					t.Errorf("me = %s, want %s ranked 2nd", me, tt.wantMe)
+				}
+			}
+		})
+	}
+}
+
+func TestParticipationHandlers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	router := newLeaderboardRouter(repo)
+	users := seedSolves(t, ids)
+	if err := repo.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	rr := serveAs(router, users["beto"], http.MethodPut, "/api/leaderboard/participation", `{}`)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("update without opt_out status = %d, want %d", rr.Code, http.StatusUnprocessableEntity)
+	}
+
+	rr = serveAs(router, users["beto"], http.MethodPut, "/api/leaderboard/participation", `{"opt_out": true}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("update status = %d, want %d; body %s", rr.Code, http.StatusOK, rr.Body)
+	}
+
+	rr = serveAs(router, users["beto"], http.MethodGet, "/api/leaderboard/participation", "")
+	var participation struct {
+		OptOut bool `json:"opt_out"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &participation); err != nil || !participation.OptOut {
+		t.Errorf("participation = %s, want opt_out true", rr.Body)
+	}
+
+	rr = serveAs(router, 0, http.MethodGet, "/api/leaderboard/", "")
+	var envelope struct {
+		Leaderboard []Entry `json:"leaderboard"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decoding %s: %v", rr.Body, err)
+	}
+	for _, e := range envelope.Leaderboard {
+		if e.Username == "beto" {
+			t.Errorf("leaderboard = %+v, want beto hidden after opting out", envelope.Leaderboard)
+		}
+	}
+
+	rr = serveAs(router, 0, http.MethodGet, "/api/leaderboard/participation", "")
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("signed out participation status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,24 @@
+package leaderboard
+
+import "time"
+
+const (
+	WindowAll  = "all"
+	WindowWeek = "week"
+)
+
+// Entry only exposes public profile fields; ids and emails stay private.
+type Entry struct {
+	Rank      int       `json:"rank"`
+	Username  string    `json:"username"`
+	AvatarURL string    `json:"avatar_url"`
+	Solved    int       `json:"solved"`
+	LastSolve time.Time `json:"last_solved_at"`
+}
+
+// Query selects the ranking window. Since is zero for all-time rankings and
+// Unit is empty for rankings across every unit.
+type Query struct {
+	Since time.Time
+	Unit  string
+}
@@ -0,0 +1,112 @@
+package leaderboard
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"apschool/internal/pagination"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// rankingQuery ranks users by solved challenges within the window, breaking
+// ties by who reached that count first. $1 is the window start (NULL for
+// all-time) and $2 the unit (empty for every unit).
+const rankingQuery = `WITH ranking AS (
+	SELECT u.id AS user_id, u.username, u.avatar_url,
+		count(*) AS solved,
+		max(ls.first_solved_at) AS last_solve,
+		rank() OVER (ORDER BY count(*) DESC, max(ls.first_solved_at) ASC) AS rank
+	FROM leaderboard_solves ls
+	JOIN users u ON u.id = ls.user_id
+	WHERE u.leaderboard_opt_out = false
+		AND ($1::timestamptz IS NULL OR ls.first_solved_at >= $1)
+		AND ($2 = '' OR ls.category = $2)
+	GROUP BY u.id
+)`
+
+func (r *Repository) List(ctx context.Context, q Query, filters pagination.Filters) ([]Entry, pagination.Metadata, error) {
+
+	query := rankingQuery + `
+	SELECT count(*) OVER(), rank, username, avatar_url, solved, last_solve
+	FROM ranking
+	ORDER BY rank, username
+	LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.QueryContext(ctx, query, since(q), q.Unit, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	entries := []Entry{}
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&totalRecords, &e.Rank, &e.Username, &e.AvatarURL, &e.Solved, &e.LastSolve); err != nil {
+			return nil, pagination.Metadata{}, err
+		}
+		entries = append(entries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+
+	return entries, pagination.NewMetadata(totalRecords, filters), nil
+}
+
+// GetUserEntry returns the user's own position, or sql.ErrNoRows when they
+// have nothing solved in the window or opted out.
+func (r *Repository) GetUserEntry(ctx context.Context, q Query, userID int) (*Entry, error) {
+
+	query := rankingQuery + `
+	SELECT rank, username, avatar_url, solved, last_solve
+	FROM ranking
+	WHERE user_id = $3`
+
+	var e Entry
+
+	err := r.db.QueryRowContext(ctx, query, since(q), q.Unit, userID).Scan(&e.Rank, &e.Username, &e.AvatarURL, &e.Solved, &e.LastSolve)
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+func (r *Repository) GetOptOut(ctx context.Context, userID int) (bool, error) {
+	var optOut bool
+
+	err := r.db.QueryRowContext(ctx, `SELECT leaderboard_opt_out FROM users WHERE id = $1`, userID).Scan(&optOut)
+	return optOut, err
+}
+
+func (r *Repository) SetOptOut(ctx context.Context, userID int, optOut bool) error {
+
+	query := `UPDATE users SET leaderboard_opt_out = $2, updated_at = NOW()
+	WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, userID, optOut)
+	return err
+}
+
+// Refresh rebuilds the solves view without blocking readers.
+func (r *Repository) Refresh(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY leaderboard_solves`)
+	return err
+}
+
+func since(q Query) *time.Time {
+	if q.Since.IsZero() {
+		return nil
+	}
+	return &q.Since
+}
@@ -0,0 +1,315 @@
+package leaderboard
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"apschool/internal/pagination"
+	"apschool/internal/testutil"
+)
+
+var testDB *testutil.TestDB
+
+func TestMain(m *testing.M) {
+	// Parse flags first so we can check -short
+	flag.Parse()
+
+	// Skip container setup entirely in short mode (for CI without Docker)
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	ctx := context.Background()
+
+	var err error
+	testDB, err = testutil.SetupTestDB(ctx)
+	if err != nil {
+		panic("failed to setup test db: " + err.Error())
+	}
+
+	code := m.Run()
+
+	testDB.Teardown(ctx)
+	os.Exit(code)
+}
+
+// base is the time of the first solve in the tests.
+var base = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func hour(n int) time.Time {
+	return base.Add(time.Duration(n) * time.Hour)
+}
+
+// setupRepository empties users and the catalog, creates suma and resta in
+// unit-1 and lista in unit-2, and refreshes the view, which truncating
+// doesn't clear.
+func setupRepository(t *testing.T) (*Repository, map[string]int) {
+	t.Helper()
+
+	testDB.TruncateTables(t)
+	if _, err := testDB.DB.Exec("TRUNCATE challenges, categories CASCADE"); err != nil {
+		t.Fatalf("failed to truncate challenges: %v", err)
+	}
+	if _, err := testDB.DB.Exec(`INSERT INTO categories (slug, title) VALUES ('unit-1', 'Unidad 1'), ('unit-2', 'Unidad 2')`); err != nil {
+		t.Fatalf("failed to create units: %v", err)
+	}
+
+	ids := make(map[string]int)
+	for slug, unit := range map[string]string{"suma": "unit-1", "resta": "unit-1", "lista": "unit-2"} {
+		var id int
+		err := testDB.DB.QueryRow(`INSERT INTO challenges (slug, category, title, description, template, test_code)
+		VALUES ($1, $2, $1, '', '', 'assert True') RETURNING id`, slug, unit).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create challenge %s: %v", slug, err)
+		}
+		ids[slug] = id
+	}
+
+	repo := NewRepository(testDB.DB)
+	if err := repo.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	return repo, ids
+}
+
+func createUser(t *testing.T, username string) int {
+	t.Helper()
+
+	var id int
+	err := testDB.DB.QueryRow(`INSERT INTO users (username, email) VALUES ($1, $2) RETURNING id`,
+		username, username+"@example.com").Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create user %s: %v", username, err)
+	}
+	return id
+}
+
+func submit(t *testing.T, userID, challengeID int, passed bool, at time.Time) {
+	t.Helper()
+
+	status := "failed"
+	if passed {
+		status = "passed"
+	}
+	_, err := testDB.DB.Exec(`INSERT INTO submissions (user_id, challenge_id, code, passed, status, created_at) VALUES ($1, $2, 'x', $3, $4, $5)`,
+		userID, challengeID, passed, status, at)
+	if err != nil {
+		t.Fatalf("failed to create submission: %v", err)
+	}
+}
+
+// seedSolves creates the users behind every ranking test. All-time, beto
+// ranks first, ana and eva tie on count and last solve, then carla and fede.
+// dani has nothing solved.
+func seedSolves(t *testing.T, ids map[string]int) map[string]int {
+	t.Helper()
+
+	users := make(map[string]int)
+	for _, username := range []string{"ana", "beto", "carla", "dani", "eva", "fede"} {
+		users[username] = createUser(t, username)
+	}
+
+	submit(t, users["ana"], ids["suma"], true, hour(1))
+	submit(t, users["ana"], ids["resta"], true, hour(3))
+	// A repeat pass counts once and doesn't move the first solve
+	submit(t, users["beto"], ids["suma"], true, hour(0))
+	submit(t, users["beto"], ids["suma"], true, hour(5))
+	submit(t, users["beto"], ids["resta"], true, hour(2))
+	submit(t, users["carla"], ids["suma"], true, hour(1))
+	submit(t, users["carla"], ids["resta"], false, hour(4))
+	submit(t, users["dani"], ids["resta"], false, hour(0))
+	submit(t, users["eva"], ids["resta"], true, hour(1))
+	submit(t, users["eva"], ids["suma"], true, hour(3))
+	submit(t, users["fede"], ids["lista"], true, hour(6))
+
+	return users
+}
+
+type ranked struct {
+	username string
+	rank     int
+	solved   int
+}
+
+func rankings(entries []Entry) []ranked {
+	got := []ranked{}
+	for _, e := range entries {
+		got = append(got, ranked{e.Username, e.Rank, e.Solved})
+	}
+	return got
+}
+
+func TestRepository_Refresh(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	ctx := context.Background()
+	seedSolves(t, ids)
+	filters := pagination.Filters{Page: 1, PageSize: 20}
+
+	// Solves only show up once the view is refreshed
+	entries, _, err := repo.List(ctx, Query{}, filters)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("List() before Refresh() = %+v, want nothing", entries)
+	}
+
+	if err := repo.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	entries, _, err = repo.List(ctx, Query{}, filters)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 5 {
+		t.Errorf("List() after Refresh() = %+v, want 5 entries", entries)
+	}
+}
+
+func TestRepository_List(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	seedSolves(t, ids)
+	if err := repo.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		query     Query
+		filters   pagination.Filters
+		want      []ranked
+		wantTotal int
+	}{
+		{
+			name:    "all time",
+			query:   Query{},
+			filters: pagination.Filters{Page: 1, PageSize: 20},
+			want: []ranked{
+				{"beto", 1, 2},
+				{"ana", 2, 2},
+				{"eva", 2, 2},
+				{"carla", 4, 1},
+				{"fede", 5, 1},
+			},
+			wantTotal: 5,
+		},
+		{
+			name:      "paginated",
+			query:     Query{},
+			filters:   pagination.Filters{Page: 2, PageSize: 2},
+			want:      []ranked{{"eva", 2, 2}, {"carla", 4, 1}},
+			wantTotal: 5,
+		},
+		{
+			name:    "window",
+			query:   Query{Since: hour(2)},
+			filters: pagination.Filters{Page: 1, PageSize: 20},
+			want: []ranked{
+				{"beto", 1, 1},
+				{"ana", 2, 1},
+				{"eva", 2, 1},
+				{"fede", 4, 1},
+			},
+			wantTotal: 4,
+		},
+		{
+			name:      "unit",
+			query:     Query{Unit: "unit-2"},
+			filters:   pagination.Filters{Page: 1, PageSize: 20},
+			want:      []ranked{{"fede", 1, 1}},
+			wantTotal: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, metadata, err := repo.List(context.Background(), tt.query, tt.filters)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := rankings(entries); !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %+v, want %+v", got, tt.want)
+			}
+			if metadata.TotalRecords != tt.wantTotal {
+				t.Errorf("total_records = %d, want %d", metadata.TotalRecords, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestRepository_OptOut(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo, ids := setupRepository(t)
+	ctx := context.Background()
+	users := seedSolves(t, ids)
+	if err := repo.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	if err := repo.SetOptOut(ctx, users["beto"], true); err != nil {
+		t.Fatalf("SetOptOut() error = %v", err)
+	}
+	if optOut, err := repo.GetOptOut(ctx, users["beto"]); err != nil || !optOut {
+		t.Errorf("GetOptOut() = %v, %v, want true", optOut, err)
+	}
+
+	// Opting out applies without waiting for a refresh
+	entries, _, err := repo.List(ctx, Query{}, pagination.Filters{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := []ranked{{"ana", 1, 2}, {"eva", 1, 2}, {"carla", 3, 1}, {"fede", 4, 1}}
+	if got := rankings(entries); !slices.Equal(got, want) {
+		t.Errorf("List() = %+v, want %+v", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		userID  int
+		want    ranked
+		wantErr error
+	}{
+		{name: "ranked", userID: users["eva"], want: ranked{"eva", 1, 2}},
+		{name: "opted out", userID: users["beto"], wantErr: sql.ErrNoRows},
+		{name: "nothing solved", userID: users["dani"], wantErr: sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := repo.GetUserEntry(ctx, Query{}, tt.userID)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("GetUserEntry() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetUserEntry() error = %v", err)
+			}
+			if got := (ranked{entry.Username, entry.Rank, entry.Solved}); got != tt.want {
+				t.Errorf("GetUserEntry() = %+v, want %+v", got, tt.want)
+			}
+			if !entry.LastSolve.Equal(hour(3)) {
+				t.Errorf("last_solved_at = %v, want %v", entry.LastSolve, hour(3))
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package leaderboard
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"apschool/internal/pagination"
+)
+
+// RefreshInterval is how stale the leaderboard may get. Solves show up on the
+// next refresh rather than immediately.
+const RefreshInterval = 5 * time.Minute
+
+// KindRefresh is the periodic job that recomputes the rankings.
+const KindRefresh = "leaderboard.refresh"
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) List(ctx context.Context, q Query, filters pagination.Filters) ([]Entry, pagination.Metadata, error) {
+	return s.repo.List(ctx, q, filters)
+}
+
+// GetUserEntry returns nil without error when the user isn't ranked.
+func (s *Service) GetUserEntry(ctx context.Context, q Query, userID int) (*Entry, error) {
+	entry, err := s.repo.GetUserEntry(ctx, q, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (s *Service) GetOptOut(ctx context.Context, userID int) (bool, error) {
+	return s.repo.GetOptOut(ctx, userID)
+}
+
+func (s *Service) SetOptOut(ctx context.Context, userID int, optOut bool) error {
+	return s.repo.SetOptOut(ctx, userID, optOut)
+}
+
+// RefreshJob is the jobs.HandlerFunc for KindRefresh.
+func (s *Service) RefreshJob(ctx context.Context, _ json.RawMessage) error {
+	return s.repo.Refresh(ctx)
+}
+
+// WeekStart returns midnight of the Monday starting the week that contains t,
+// in t's location.
+func WeekStart(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	y, m, d := t.AddDate(0, 0, -daysSinceMonday).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
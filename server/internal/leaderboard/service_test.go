@@ -0,0 +1,28 @@
+package leaderboard
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeekStart(t *testing.T) {
+	loc := time.FixedZone("ECT", -5*60*60)
+	monday := time.Date(2025, 3, 10, 0, 0, 0, 0, loc)
+
+	tests := []struct {
+		name string
+		t    time.Time
+	}{
+		{"monday midnight", monday},
+		{"wednesday afternoon", time.Date(2025, 3, 12, 15, 30, 0, 0, loc)},
+		{"sunday night", time.Date(2025, 3, 16, 23, 59, 59, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WeekStart(tt.t); !got.Equal(monday) {
+				t.Errorf("WeekStart(%v) = %v, want %v", tt.t, got, monday)
+			}
+		})
+	}
+}
@@ -5,11 +5,22 @@ import (
 	"apschool/internal/ctxkeys"
 	"apschool/internal/response"
 	"context"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 )
 
-func RequireAuth(next http.Handler) http.Handler {
+type Middleware struct {
+	tokens *auth.TokenService
+	logger *slog.Logger
+}
+
+func New(tokens *auth.TokenService, logger *slog.Logger) *Middleware {
+	return &Middleware{tokens: tokens, logger: logger}
+}
+
+func (m *Middleware) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		// Read Authorization from header
@@ -19,24 +30,122 @@ func RequireAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		// Get token (Bearer <token>)
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			response.Unauthorized(w)
+		ctx, ok := m.authenticate(w, r, authHeader)
+		if !ok {
 			return
 		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-		token := parts[1]
+// OptionalAuth lets anonymous requests through but authenticates the ones
+// that carry a token, so public endpoints can personalise their response. A
+// bad token is still rejected so the client knows to refresh it.
+func (m *Middleware) OptionalAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		// Validate token
-		userID, err := auth.ValidateJWT(token)
-		if err != nil {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, ok := m.authenticate(w, r, authHeader)
+		if !ok {
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireStreamAuth is RequireAuth for endpoints opened with EventSource. It
+// also accepts a ?ticket= issued for the request path by
+// POST /api/auth/stream-ticket.
+func (m *Middleware) RequireStreamAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+			ctx, ok := m.authenticate(w, r, authHeader)
+			if !ok {
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		value := r.URL.Query().Get("ticket")
+		if value == "" {
 			response.Unauthorized(w)
 			return
 		}
 
-		// Save to context
-		ctx := context.WithValue(r.Context(), ctxkeys.UserIDKey, userID)
+		ticket, err := m.tokens.ParseStreamTicket(value, r.URL.Path)
+		if err != nil {
+			response.InvalidAuthenticationToken(w)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ctxkeys.UserIDKey, ticket.UserID)
+		ctx = context.WithValue(ctx, ctxkeys.RoleKey, ticket.Role)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// authenticate validates the bearer token and returns a context carrying the
+// caller's identity. It writes the error response itself when it fails.
+func (m *Middleware) authenticate(w http.ResponseWriter, r *http.Request, authHeader string) (context.Context, bool) {
+
+	// Get token (Bearer <token>)
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		response.Unauthorized(w)
+		return nil, false
+	}
+
+	token := parts[1]
+
+	// Validate token
+	claims, err := m.tokens.ParseAccessToken(token)
+	if err != nil {
+		response.Unauthorized(w)
+		return nil, false
+	}
+
+	// Reject tokens revoked by a logout
+	revoked, err := m.tokens.IsRevoked(r.Context(), claims.ID)
+	if err != nil {
+		response.ServerError(w, r, m.logger, err)
+		return nil, false
+	}
+	if revoked {
+		response.InvalidAuthenticationToken(w)
+		return nil, false
+	}
+
+	// Save to context
+	ctx := context.WithValue(r.Context(), ctxkeys.UserIDKey, claims.UserID)
+	ctx = context.WithValue(ctx, ctxkeys.RoleKey, claims.Role)
+	ctx = context.WithValue(ctx, ctxkeys.TokenIDKey, claims.ID)
+	return ctx, true
+}
+
+// RequireRole only lets through users holding one of roles. It must run after
+// RequireAuth.
+func (m *Middleware) RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := ctxkeys.GetRole(r.Context())
+			if !ok {
+				response.Unauthorized(w)
+				return
+			}
+
+			if !slices.Contains(roles, role) {
+				response.Forbidden(w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
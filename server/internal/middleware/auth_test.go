@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"apschool/internal/auth"
+	"apschool/internal/ctxkeys"
+)
+
+var testSecret = []byte("test-secret")
+
+func TestRequireStreamAuth_Ticket(t *testing.T) {
+	const path = "/api/submissions/1/events"
+
+	encode := func(t *testing.T, ticket *auth.StreamTicket, secret []byte) string {
+		t.Helper()
+		value, err := ticket.Encode(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	tests := []struct {
+		name       string
+		ticket     func(t *testing.T) string
+		wantStatus int
+	}{
+		{
+			name: "valid",
+			ticket: func(t *testing.T) string {
+				return encode(t, auth.NewStreamTicket(7, auth.RoleStudent, path, time.Now()), testSecret)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "expired",
+			ticket: func(t *testing.T) string {
+				return encode(t, auth.NewStreamTicket(7, auth.RoleStudent, path, time.Now().Add(-time.Minute)), testSecret)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "issued for another path",
+			ticket: func(t *testing.T) string {
+				return encode(t, auth.NewStreamTicket(7, auth.RoleStudent, "/api/submissions/2/events", time.Now()), testSecret)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "signed with another secret",
+			ticket: func(t *testing.T) string {
+				return encode(t, auth.NewStreamTicket(7, auth.RoleStudent, path, time.Now()), []byte("other-secret"))
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "malformed",
+			ticket:     func(t *testing.T) string { return "not-a-ticket" },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "missing",
+			ticket:     func(t *testing.T) string { return "" },
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(auth.NewTokenService(nil, testSecret), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			handler := m.RequireStreamAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID, _ := ctxkeys.GetUserID(r.Context())
+				role, _ := ctxkeys.GetRole(r.Context())
+				io.WriteString(w, strconv.Itoa(userID)+" "+role)
+			}))
+
+			target := path
+			if ticket := tt.ticket(t); ticket != "" {
+				target += "?ticket=" + ticket
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+			if tt.wantStatus == http.StatusOK && rr.Body.String() != "7 "+auth.RoleStudent {
+				t.Errorf("handler saw %q, want user 7 with role %s", rr.Body, auth.RoleStudent)
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		wantStatus int
+	}{
+		{name: "admin", role: auth.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "instructor", role: auth.RoleInstructor, wantStatus: http.StatusForbidden},
+		{name: "student", role: auth.RoleStudent, wantStatus: http.StatusForbidden},
+		{name: "unauthenticated", wantStatus: http.StatusUnauthorized},
+	}
+
+	m := New(auth.NewTokenService(nil, testSecret), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := m.RequireRole(auth.RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/challenges", nil)
+			if tt.role != "" {
+				req = req.WithContext(context.WithValue(req.Context(), ctxkeys.RoleKey, tt.role))
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
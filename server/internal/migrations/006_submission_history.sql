@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE submissions DROP CONSTRAINT IF EXISTS submissions_user_id_challenge_id_key;
+ALTER TABLE submissions ADD COLUMN code_size INT NOT NULL DEFAULT 0;
+UPDATE submissions SET code_size = octet_length(code);
+CREATE INDEX IF NOT EXISTS idx_submissions_user_challenge ON submissions(user_id, challenge_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_submissions_user_challenge;
+-- Keep only the latest attempt per challenge so the old constraint can come back
+DELETE FROM submissions s
+USING submissions t
+WHERE s.user_id = t.user_id AND s.challenge_id = t.challenge_id AND s.id < t.id;
+ALTER TABLE submissions DROP COLUMN IF EXISTS code_size;
+ALTER TABLE submissions ADD CONSTRAINT submissions_user_id_challenge_id_key UNIQUE(user_id, challenge_id);
@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'student'
+    CHECK (role IN ('student', 'instructor', 'admin'));
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS role;
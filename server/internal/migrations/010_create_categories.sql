@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS categories (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    slug TEXT UNIQUE NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    display_order INT NOT NULL DEFAULT 0,
+    is_visible BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Existing challenges keep working: every category they use becomes a row
+INSERT INTO categories (slug, title)
+SELECT DISTINCT category, category FROM challenges
+ON CONFLICT (slug) DO NOTHING;
+
+ALTER TABLE challenges
+    ADD CONSTRAINT challenges_category_fkey
+    FOREIGN KEY (category) REFERENCES categories(slug) ON UPDATE CASCADE;
+
+-- +goose Down
+ALTER TABLE challenges DROP CONSTRAINT IF EXISTS challenges_category_fkey;
+DROP TABLE IF EXISTS categories;
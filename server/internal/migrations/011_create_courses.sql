@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS courses (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL,
+    term TEXT NOT NULL DEFAULT '',
+    join_code TEXT UNIQUE NOT NULL,
+    owner_id BIGINT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS course_members (
+    course_id BIGINT NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'student' CHECK (role IN ('student', 'instructor')),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (course_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_course_members_user ON course_members(user_id);
+
+CREATE TABLE IF NOT EXISTS course_units (
+    course_id BIGINT NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    category TEXT NOT NULL REFERENCES categories(slug) ON UPDATE CASCADE ON DELETE CASCADE,
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (course_id, category)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS course_units;
+DROP TABLE IF EXISTS course_members;
+DROP TABLE IF EXISTS courses;
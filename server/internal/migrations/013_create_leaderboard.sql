@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS leaderboard_opt_out BOOLEAN NOT NULL DEFAULT false;
+
+-- One row per solved challenge with the time it was first solved. The
+-- leaderboard reads this instead of scanning every attempt in submissions.
+CREATE MATERIALIZED VIEW IF NOT EXISTS leaderboard_solves AS
+SELECT s.user_id, s.challenge_id, ch.category, min(s.created_at) AS first_solved_at
+FROM submissions s
+JOIN challenges ch ON ch.id = s.challenge_id
+WHERE s.passed = true
+GROUP BY s.user_id, s.challenge_id, ch.category;
+
+-- Required for REFRESH MATERIALIZED VIEW CONCURRENTLY
+CREATE UNIQUE INDEX IF NOT EXISTS idx_leaderboard_solves_user_challenge ON leaderboard_solves(user_id, challenge_id);
+CREATE INDEX IF NOT EXISTS idx_leaderboard_solves_first_solved ON leaderboard_solves(first_solved_at);
+CREATE INDEX IF NOT EXISTS idx_leaderboard_solves_category ON leaderboard_solves(category);
+
+-- +goose Down
+DROP MATERIALIZED VIEW IF EXISTS leaderboard_solves;
+ALTER TABLE users DROP COLUMN IF EXISTS leaderboard_opt_out;
@@ -0,0 +1,50 @@
+-- +goose Up
+ALTER TABLE challenges ADD COLUMN IF NOT EXISTS difficulty TEXT NOT NULL DEFAULT 'easy'
+    CHECK (difficulty IN ('easy', 'medium', 'hard'));
+
+-- XP granted the first time a challenge of each difficulty is solved
+CREATE TABLE IF NOT EXISTS difficulty_xp (
+    difficulty TEXT PRIMARY KEY,
+    xp INT NOT NULL CHECK (xp >= 0)
+);
+
+INSERT INTO difficulty_xp (difficulty, xp) VALUES
+    ('easy', 10),
+    ('medium', 25),
+    ('hard', 50)
+ON CONFLICT (difficulty) DO NOTHING;
+
+-- Badges are data: criteria_type picks the rule, criteria_value its
+-- threshold and criteria_unit the unit for unit_completed badges
+CREATE TABLE IF NOT EXISTS badges (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    slug TEXT UNIQUE NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    icon TEXT NOT NULL DEFAULT '',
+    criteria_type TEXT NOT NULL CHECK (criteria_type IN ('solved_count', 'unit_completed', 'streak_days', 'xp_total')),
+    criteria_value INT NOT NULL DEFAULT 0,
+    criteria_unit TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO badges (slug, name, description, icon, criteria_type, criteria_value, criteria_unit) VALUES
+    ('first-challenge', 'Primer reto', 'Resuelve tu primer reto', '🎯', 'solved_count', 1, ''),
+    ('ten-challenges', 'Diez retos', 'Resuelve diez retos', '🔟', 'solved_count', 10, ''),
+    ('unit-1-complete', 'Unidad 1 completa', 'Resuelve todos los retos de la unidad 1', '🏁', 'unit_completed', 0, 'unit-1-intro'),
+    ('streak-7', 'Racha de 7 días', 'Resuelve al menos un reto durante 7 días seguidos', '🔥', 'streak_days', 7, ''),
+    ('xp-500', '500 XP', 'Acumula 500 puntos de experiencia', '⭐', 'xp_total', 500, '')
+ON CONFLICT (slug) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS user_badges (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    badge_id BIGINT NOT NULL REFERENCES badges(id) ON DELETE CASCADE,
+    awarded_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, badge_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_badges;
+DROP TABLE IF EXISTS badges;
+DROP TABLE IF EXISTS difficulty_xp;
+ALTER TABLE challenges DROP COLUMN IF EXISTS difficulty;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE challenges ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- Titles are weighted above descriptions. The content is in Spanish, and the
+-- spanish config leaves English terms like "string" searchable.
+ALTER TABLE challenges ADD COLUMN IF NOT EXISTS search tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('spanish', title), 'A') ||
+        setweight(to_tsvector('spanish', description), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_challenges_search ON challenges USING GIN (search);
+CREATE INDEX IF NOT EXISTS idx_challenges_tags ON challenges USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_challenges_difficulty ON challenges(difficulty);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_challenges_difficulty;
+DROP INDEX IF EXISTS idx_challenges_tags;
+DROP INDEX IF EXISTS idx_challenges_search;
+ALTER TABLE challenges DROP COLUMN IF EXISTS search;
+ALTER TABLE challenges DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS hint_reveals (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    challenge_id BIGINT NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    hint_number INT NOT NULL CHECK (hint_number > 0),
+    revealed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, challenge_id, hint_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_hint_reveals_challenge_id ON hint_reveals(challenge_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS hint_reveals;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- test_code holds the public example tests students run in the browser;
+-- hidden_test_code only ever runs in server-side grading.
+ALTER TABLE challenges ADD COLUMN IF NOT EXISTS hidden_test_code TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE challenges DROP COLUMN IF EXISTS hidden_test_code;
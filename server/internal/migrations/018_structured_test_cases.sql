@@ -0,0 +1,10 @@
+-- +goose Up
+-- Declarative cases graded alongside test_code; see grader.Case for the format.
+ALTER TABLE challenges ADD COLUMN IF NOT EXISTS test_cases JSONB NOT NULL DEFAULT '[]';
+
+-- Per-case outcome of each attempt, as shown to the student.
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS results JSONB NOT NULL DEFAULT '[]';
+
+-- +goose Down
+ALTER TABLE submissions DROP COLUMN IF EXISTS results;
+ALTER TABLE challenges DROP COLUMN IF EXISTS test_cases;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Reference solution used to check the tests; never sent to students.
+ALTER TABLE challenges ADD COLUMN IF NOT EXISTS solution TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE challenges DROP COLUMN IF EXISTS solution;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Submissions graded in the background go queued -> running -> passed|failed.
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'failed'
+    CHECK (status IN ('queued', 'running', 'passed', 'failed'));
+UPDATE submissions SET status = 'passed' WHERE passed = true;
+
+-- Kept so the verdict can be shown once a background run finishes.
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS output TEXT NOT NULL DEFAULT '';
+ALTER TABLE submissions ADD COLUMN IF NOT EXISTS error TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE submissions DROP COLUMN IF EXISTS error;
+ALTER TABLE submissions DROP COLUMN IF EXISTS output;
+ALTER TABLE submissions DROP COLUMN IF EXISTS status;
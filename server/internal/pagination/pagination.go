@@ -0,0 +1,80 @@
+package pagination
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+
+	"apschool/internal/validator"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type Filters struct {
+	Page     int
+	PageSize int
+}
+
+type Metadata struct {
+	CurrentPage  int `json:"current_page,omitzero"`
+	PageSize     int `json:"page_size,omitzero"`
+	FirstPage    int `json:"first_page,omitzero"`
+	LastPage     int `json:"last_page,omitzero"`
+	TotalRecords int `json:"total_records"`
+}
+
+// FromQuery reads `page` and `page_size` from the query string, recording any
+// problem in v.
+func FromQuery(qs url.Values, v *validator.Validator) Filters {
+	f := Filters{
+		Page:     readInt(qs, "page", 1, v),
+		PageSize: readInt(qs, "page_size", DefaultPageSize, v),
+	}
+
+	v.Check(f.Page > 0, "page", "must be greater than zero")
+	v.Check(f.Page <= 10_000_000, "page", "must be a maximum of 10 million")
+	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")
+	v.Check(f.PageSize <= MaxPageSize, "page_size", "must be a maximum of 100")
+
+	return f
+}
+
+func (f Filters) Limit() int {
+	return f.PageSize
+}
+
+func (f Filters) Offset() int {
+	return (f.Page - 1) * f.PageSize
+}
+
+func NewMetadata(totalRecords int, f Filters) Metadata {
+	if totalRecords == 0 {
+		return Metadata{}
+	}
+
+	return Metadata{
+		CurrentPage:  f.Page,
+		PageSize:     f.PageSize,
+		FirstPage:    1,
+		LastPage:     int(math.Ceil(float64(totalRecords) / float64(f.PageSize))),
+		TotalRecords: totalRecords,
+	}
+}
+
+func readInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		v.AddError(key, "must be an integer value")
+		return defaultValue
+	}
+
+	return i
+}
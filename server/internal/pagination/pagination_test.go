@@ -0,0 +1,51 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+
+	"apschool/internal/validator"
+)
+
+func TestFromQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		want      Filters
+		wantValid bool
+	}{
+		{"defaults", "", Filters{Page: 1, PageSize: DefaultPageSize}, true},
+		{"explicit values", "page=3&page_size=50", Filters{Page: 3, PageSize: 50}, true},
+		{"page not a number", "page=abc", Filters{Page: 1, PageSize: DefaultPageSize}, false},
+		{"page size too large", "page_size=1000", Filters{Page: 1, PageSize: 1000}, false},
+		{"zero page", "page=0", Filters{Page: 0, PageSize: DefaultPageSize}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qs, _ := url.ParseQuery(tt.query)
+			v := validator.New()
+
+			got := FromQuery(qs, v)
+
+			if got != tt.want {
+				t.Errorf("FromQuery() = %+v, want %+v", got, tt.want)
+			}
+			if v.Valid() != tt.wantValid {
+				t.Errorf("FromQuery() valid = %v, want %v (errors %v)", v.Valid(), tt.wantValid, v.Errors)
+			}
+		})
+	}
+}
+
+func TestNewMetadata(t *testing.T) {
+	got := NewMetadata(45, Filters{Page: 2, PageSize: 20})
+	want := Metadata{CurrentPage: 2, PageSize: 20, FirstPage: 1, LastPage: 3, TotalRecords: 45}
+	if got != want {
+		t.Errorf("NewMetadata() = %+v, want %+v", got, want)
+	}
+
+	if got := NewMetadata(0, Filters{Page: 1, PageSize: 20}); got != (Metadata{}) {
+		t.Errorf("NewMetadata() with no records = %+v, want zero value", got)
+	}
+}
@@ -0,0 +1,11 @@
+package progress
+
+import "time"
+
+type UnitProgress struct {
+	Slug            string     `json:"slug"`
+	Title           string     `json:"title"`
+	TotalChallenges int        `json:"total_challenges"`
+	Solved          int        `json:"solved"`
+	LastActivity    *time.Time `json:"last_activity"`
+}
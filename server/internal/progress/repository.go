@@ -0,0 +1,57 @@
+package progress
+
+import (
+	"context"
+	"database/sql"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// GetByUser summarises every visible unit for the user. A challenge counts as
+// solved once any attempt passed; the last activity is the newest attempt,
+// passed or not.
+func (r *Repository) GetByUser(ctx context.Context, userID int) ([]UnitProgress, error) {
+
+	query := `SELECT c.slug, c.title,
+		count(ch.id) AS total_challenges,
+		count(ch.id) FILTER (WHERE s.solved) AS solved,
+		max(s.last_activity) AS last_activity
+	FROM categories c
+	LEFT JOIN challenges ch ON ch.category = c.slug AND ch.is_active = true
+	LEFT JOIN (
+		SELECT challenge_id, bool_or(passed) AS solved, max(created_at) AS last_activity
+		FROM submissions
+		WHERE user_id = $1
+		GROUP BY challenge_id
+	) s ON s.challenge_id = ch.id
+	WHERE c.is_visible = true
+	GROUP BY c.id
+	ORDER BY c.display_order, c.slug`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []UnitProgress{}
+	for rows.Next() {
+		var u UnitProgress
+		if err := rows.Scan(&u.Slug, &u.Title, &u.TotalChallenges, &u.Solved, &u.LastActivity); err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return units, nil
+}
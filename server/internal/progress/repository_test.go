@@ -0,0 +1,137 @@
+package progress
+
+import (
+	"context"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"apschool/internal/testutil"
+)
+
+var testDB *testutil.TestDB
+
+func TestMain(m *testing.M) {
+	// Parse flags first so we can check -short
+	flag.Parse()
+
+	// Skip container setup entirely in short mode (for CI without Docker)
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	ctx := context.Background()
+
+	var err error
+	testDB, err = testutil.SetupTestDB(ctx)
+	if err != nil {
+		panic("failed to setup test db: " + err.Error())
+	}
+
+	code := m.Run()
+
+	testDB.Teardown(ctx)
+	os.Exit(code)
+}
+
+func createUser(t *testing.T, username string) int {
+	t.Helper()
+
+	var id int
+	err := testDB.DB.QueryRow(`INSERT INTO users (username, email) VALUES ($1, $2) RETURNING id`,
+		username, username+"@example.com").Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create user %s: %v", username, err)
+	}
+	return id
+}
+
+func createChallenge(t *testing.T, slug, unit string, active bool) int {
+	t.Helper()
+
+	var id int
+	err := testDB.DB.QueryRow(`INSERT INTO challenges (slug, category, title, description, template, test_code, is_active)
+	VALUES ($1, $2, $1, '', '', 'assert True', $3) RETURNING id`, slug, unit, active).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create challenge %s: %v", slug, err)
+	}
+	return id
+}
+
+func submit(t *testing.T, userID, challengeID int, passed bool, at time.Time) {
+	t.Helper()
+
+	status := "failed"
+	if passed {
+		status = "passed"
+	}
+	_, err := testDB.DB.Exec(`INSERT INTO submissions (user_id, challenge_id, code, passed, status, created_at) VALUES ($1, $2, 'x', $3, $4, $5)`,
+		userID, challengeID, passed, status, at)
+	if err != nil {
+		t.Fatalf("failed to create submission: %v", err)
+	}
+}
+
+func TestRepository_GetByUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	testDB.TruncateTables(t)
+	if _, err := testDB.DB.Exec("TRUNCATE challenges, categories CASCADE"); err != nil {
+		t.Fatalf("failed to truncate challenges: %v", err)
+	}
+	_, err := testDB.DB.Exec(`INSERT INTO categories (slug, title, display_order, is_visible) VALUES
+		('unit-2', 'Unidad 2', 1, true),
+		('unit-1', 'Unidad 1', 0, true),
+		('draft', 'Borrador', 0, false)`)
+	if err != nil {
+		t.Fatalf("failed to create units: %v", err)
+	}
+
+	base := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	ana := createUser(t, "ana")
+	beto := createUser(t, "beto")
+
+	suma := createChallenge(t, "suma", "unit-1", true)
+	resta := createChallenge(t, "resta", "unit-1", true)
+	viejo := createChallenge(t, "viejo", "unit-1", false)
+	lista := createChallenge(t, "lista", "unit-2", true)
+	borrador := createChallenge(t, "borrador", "draft", true)
+
+	// A failed attempt after a pass keeps the challenge solved
+	submit(t, ana, suma, true, base)
+	submit(t, ana, suma, false, base.Add(time.Hour))
+	submit(t, ana, resta, false, base.Add(2*time.Hour))
+	// None of these count for ana's visible units
+	submit(t, ana, viejo, true, base.Add(3*time.Hour))
+	submit(t, ana, borrador, true, base.Add(3*time.Hour))
+	submit(t, beto, lista, true, base.Add(3*time.Hour))
+
+	units, err := NewRepository(testDB.DB).GetByUser(context.Background(), ana)
+	if err != nil {
+		t.Fatalf("GetByUser() error = %v", err)
+	}
+	if len(units) != 2 {
+		t.Fatalf("GetByUser() = %+v, want the two visible units", units)
+	}
+
+	lastActivity := base.Add(2 * time.Hour)
+	want := []struct {
+		slug         string
+		total        int
+		solved       int
+		lastActivity *time.Time
+	}{
+		{"unit-1", 2, 1, &lastActivity},
+		{"unit-2", 1, 0, nil},
+	}
+	for i, u := range units {
+		w := want[i]
+		sameActivity := (u.LastActivity == nil) == (w.lastActivity == nil) && (u.LastActivity == nil || u.LastActivity.Equal(*w.lastActivity))
+		if u.Slug != w.slug || u.TotalChallenges != w.total || u.Solved != w.solved || !sameActivity {
+			t.Errorf("unit %d = %+v, want %s with %d of %d solved, last activity %v", i, u, w.slug, w.solved, w.total, w.lastActivity)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package progress
+
+import "context"
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) GetProgress(ctx context.Context, userID int) ([]UnitProgress, error) {
+	return s.repo.GetByUser(ctx, userID)
+}
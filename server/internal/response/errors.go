@@ -33,6 +33,15 @@ func Unauthorized(w http.ResponseWriter) {
 	ErrorResponse(w, http.StatusUnauthorized, "unauthorized")
 }
 
+func InvalidCredentials(w http.ResponseWriter) {
+	ErrorResponse(w, http.StatusUnauthorized, "invalid authentication credentials")
+}
+
+func InvalidAuthenticationToken(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	ErrorResponse(w, http.StatusUnauthorized, "invalid or expired authentication token")
+}
+
 func Forbidden(w http.ResponseWriter) {
 	ErrorResponse(w, http.StatusForbidden, "forbidden")
 }
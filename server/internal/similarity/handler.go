@@ -0,0 +1,139 @@
+package similarity
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"apschool/internal/courses"
+	"apschool/internal/ctxkeys"
+	"apschool/internal/response"
+	"apschool/internal/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+func NewHandler(service *Service, logger *slog.Logger) *Handler {
+	return &Handler{service: service, logger: logger}
+}
+
+// RequestReportHandler queues a report and answers 202 right away; clients
+// poll the report until its status is done or failed.
+func (h *Handler) RequestReportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, courseID, ok := h.courseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		ChallengeID int      `json:"challenge_id"`
+		Threshold   *float64 `json:"threshold"`
+	}
+
+	if err := response.ReadJSON(w, r, &input); err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	threshold := DefaultThreshold
+	if input.Threshold != nil {
+		threshold = *input.Threshold
+	}
+
+	v := validator.New()
+	v.Check(input.ChallengeID > 0, "challenge_id", "is required")
+	if ValidateThreshold(v, threshold); !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	report, err := h.service.RequestReport(r.Context(), courseID, userID, role, input.ChallengeID, threshold)
+	if err != nil {
+		if errors.Is(err, ErrUnknownChallenge) {
+			v.AddError("challenge_id", "does not exist")
+			response.ValidationError(w, v.Errors)
+			return
+		}
+		h.handleError(w, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", "/api/courses/"+strconv.Itoa(courseID)+"/similarity/"+strconv.Itoa(report.ID))
+
+	response.WriteJSON(w, http.StatusAccepted, response.Envelope{"report": report}, headers)
+}
+
+func (h *Handler) ListReportsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, courseID, ok := h.courseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	reports, err := h.service.ListReports(r.Context(), courseID, userID, role)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"reports": reports}, nil)
+}
+
+func (h *Handler) GetReportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, role, courseID, ok := h.courseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	reportID, err := strconv.Atoi(chi.URLParam(r, "reportID"))
+	if err != nil {
+		response.BadRequest(w, "invalid report id")
+		return
+	}
+
+	report, err := h.service.GetReport(r.Context(), courseID, reportID, userID, role)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"report": report}, nil)
+}
+
+// courseRequest reads the caller and the course {id} URL param. It writes
+// the error response itself when it fails.
+func (h *Handler) courseRequest(w http.ResponseWriter, r *http.Request) (userID int, role string, courseID int, ok bool) {
+	userID, ok = ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return 0, "", 0, false
+	}
+	role, _ = ctxkeys.GetRole(r.Context())
+
+	courseID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid id")
+		return 0, "", 0, false
+	}
+
+	return userID, role, courseID, true
+}
+
+func (h *Handler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrReportNotFound),
+		errors.Is(err, courses.ErrCourseNotFound),
+		errors.Is(err, courses.ErrNotCourseMember):
+		response.NotFound(w)
+	case errors.Is(err, courses.ErrNotCourseInstructor):
+		response.Forbidden(w)
+	default:
+		response.ServerError(w, r, h.logger, err)
+	}
+}
@@ -0,0 +1,55 @@
+package similarity
+
+import (
+	"time"
+
+	"apschool/internal/validator"
+)
+
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// DefaultThreshold is the similarity above which a pair is reported.
+const DefaultThreshold = 0.8
+
+// Report is the result of comparing every course student's latest code for a
+// challenge with everyone else's. Listings leave Pairs out and only carry
+// PairCount.
+type Report struct {
+	ID          int        `json:"id"`
+	CourseID    int        `json:"course_id"`
+	ChallengeID int        `json:"challenge_id"`
+	Threshold   float64    `json:"threshold"`
+	Status      string     `json:"status"`
+	Submissions int        `json:"submissions"`
+	PairCount   int        `json:"pair_count"`
+	Pairs       []Pair     `json:"pairs,omitempty"`
+	Error       string     `json:"error,omitzero"`
+	RequestedBy int        `json:"requested_by,omitzero"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// Submission is the code of one student that takes part in a report.
+type Submission struct {
+	ID       int    `json:"submission_id"`
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Code     string `json:"-"`
+}
+
+// Pair is two submissions whose similarity reached the threshold.
+type Pair struct {
+	A          Submission `json:"a"`
+	B          Submission `json:"b"`
+	Similarity float64    `json:"similarity"`
+	Regions    []Region   `json:"regions"`
+}
+
+func ValidateThreshold(v *validator.Validator, threshold float64) {
+	v.Check(threshold > 0 && threshold <= 1, "threshold", "must be greater than 0 and at most 1")
+}
@@ -0,0 +1,201 @@
+package similarity
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Insert(ctx context.Context, report *Report) error {
+
+	query := `INSERT INTO similarity_reports (course_id, challenge_id, threshold, requested_by)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, status, created_at`
+
+	err := r.db.QueryRowContext(ctx, query, report.CourseID, report.ChallengeID, report.Threshold, report.RequestedBy).
+		Scan(&report.ID, &report.Status, &report.CreatedAt)
+	if err != nil {
+		return translateError(err)
+	}
+
+	report.Pairs = []Pair{}
+	return nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (*Report, error) {
+
+	query := `SELECT id, course_id, challenge_id, threshold, status, submissions, pairs, error,
+		coalesce(requested_by, 0), created_at, finished_at
+	FROM similarity_reports
+	WHERE id = $1`
+
+	var report Report
+	var pairs []byte
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&report.ID,
+		&report.CourseID,
+		&report.ChallengeID,
+		&report.Threshold,
+		&report.Status,
+		&report.Submissions,
+		&pairs,
+		&report.Error,
+		&report.RequestedBy,
+		&report.CreatedAt,
+		&report.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(pairs, &report.Pairs); err != nil {
+		return nil, err
+	}
+	report.PairCount = len(report.Pairs)
+
+	return &report, nil
+}
+
+// ListByCourse returns the course's reports, newest first, without their
+// pairs.
+func (r *Repository) ListByCourse(ctx context.Context, courseID int) ([]Report, error) {
+
+	query := `SELECT id, course_id, challenge_id, threshold, status, submissions, jsonb_array_length(pairs), error,
+		coalesce(requested_by, 0), created_at, finished_at
+	FROM similarity_reports
+	WHERE course_id = $1
+	ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []Report{}
+	for rows.Next() {
+		var report Report
+		var pairCount int
+		err := rows.Scan(
+			&report.ID,
+			&report.CourseID,
+			&report.ChallengeID,
+			&report.Threshold,
+			&report.Status,
+			&report.Submissions,
+			&pairCount,
+			&report.Error,
+			&report.RequestedBy,
+			&report.CreatedAt,
+			&report.FinishedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		report.PairCount = pairCount
+		reports = append(reports, report)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (r *Repository) SetRunning(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE similarity_reports SET status = 'running' WHERE id = $1`, id)
+	return err
+}
+
+func (r *Repository) Complete(ctx context.Context, id, submissions int, pairs []Pair) error {
+	payload, err := json.Marshal(pairs)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE similarity_reports
+	SET status = 'done', submissions = $2, pairs = $3, error = '', finished_at = NOW()
+	WHERE id = $1`
+
+	_, err = r.db.ExecContext(ctx, query, id, submissions, payload)
+	return err
+}
+
+func (r *Repository) Fail(ctx context.Context, id int, message string) error {
+
+	query := `UPDATE similarity_reports
+	SET status = 'failed', error = $2, finished_at = NOW()
+	WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, message)
+	return err
+}
+
+// GetTemplate returns the starting code of the challenge.
+func (r *Repository) GetTemplate(ctx context.Context, challengeID int) (string, error) {
+	var template string
+
+	query := `SELECT template FROM challenges WHERE id = $1`
+
+	err := r.db.QueryRowContext(ctx, query, challengeID).Scan(&template)
+	if err != nil {
+		return "", err
+	}
+
+	return template, nil
+}
+
+// ListLatestSubmissions returns, for every student of the course who tried
+// the challenge, their latest accepted attempt or, if none passed, their
+// latest attempt.
+func (r *Repository) ListLatestSubmissions(ctx context.Context, courseID, challengeID int) ([]Submission, error) {
+
+	query := `SELECT DISTINCT ON (s.user_id) s.id, s.user_id, u.username, s.code
+	FROM submissions s
+	JOIN course_members m ON m.user_id = s.user_id AND m.course_id = $1 AND m.role = 'student'
+	JOIN users u ON u.id = s.user_id
+	WHERE s.challenge_id = $2
+	ORDER BY s.user_id, s.passed DESC, s.created_at DESC, s.id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, courseID, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	submissions := []Submission{}
+	for rows.Next() {
+		var s Submission
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Username, &s.Code); err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return submissions, nil
+}
+
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" && pgErr.ConstraintName == "similarity_reports_challenge_id_fkey" {
+		return ErrUnknownChallenge
+	}
+	return err
+}
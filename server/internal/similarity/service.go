@@ -0,0 +1,194 @@
+package similarity
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"apschool/internal/courses"
+	"apschool/internal/jobs"
+)
+
+// KindReport is the job that runs a requested report.
+const KindReport = "similarity.report"
+
+var (
+	ErrReportNotFound   = errors.New("similarity report not found")
+	ErrUnknownChallenge = errors.New("unknown challenge")
+)
+
+type Service struct {
+	repo    *Repository
+	courses *courses.Service
+	jobs    *jobs.Service
+}
+
+type reportPayload struct {
+	ReportID int `json:"report_id"`
+}
+
+// NewService creates the similarity service. courses and jobs may be nil for
+// callers such as the CLI that only use Analyze.
+func NewService(repo *Repository, courses *courses.Service, jobs *jobs.Service) *Service {
+	return &Service{repo: repo, courses: courses, jobs: jobs}
+}
+
+// RequestReport records a pending report and queues the job that generates
+// it.
+func (s *Service) RequestReport(ctx context.Context, courseID, userID int, role string, challengeID int, threshold float64) (*Report, error) {
+	if err := s.courses.RequireInstructor(ctx, courseID, userID, role); err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		CourseID:    courseID,
+		ChallengeID: challengeID,
+		Threshold:   threshold,
+		RequestedBy: userID,
+	}
+
+	if err := s.repo.Insert(ctx, report); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.jobs.Enqueue(ctx, KindReport, reportPayload{ReportID: report.ID}); err != nil {
+		// Nothing will ever pick the report up
+		if failErr := s.repo.Fail(context.WithoutCancel(ctx), report.ID, "could not be queued"); failErr != nil {
+			return nil, errors.Join(err, failErr)
+		}
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// RunReportJob is the jobs.HandlerFunc for KindReport.
+func (s *Service) RunReportJob(ctx context.Context, payload json.RawMessage) error {
+	var p reportPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	err := s.Generate(ctx, p.ReportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return jobs.Permanent(fmt.Errorf("report %d: %w", p.ReportID, ErrReportNotFound))
+	}
+	return err
+}
+
+// Generate runs a pending report and stores its outcome. A failure is saved
+// on the report as well as returned.
+func (s *Service) Generate(ctx context.Context, reportID int) error {
+	report, err := s.repo.GetByID(ctx, reportID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.SetRunning(ctx, reportID); err != nil {
+		return err
+	}
+
+	submissions, pairs, err := s.Analyze(ctx, report.CourseID, report.ChallengeID, report.Threshold)
+	if err != nil {
+		// The caller's context may be what failed, the report still has to
+		// leave the running state
+		if failErr := s.repo.Fail(context.WithoutCancel(ctx), reportID, err.Error()); failErr != nil {
+			return errors.Join(err, failErr)
+		}
+		return err
+	}
+
+	return s.repo.Complete(ctx, reportID, submissions, pairs)
+}
+
+// Analyze compares the latest code of every course student for the
+// challenge, leaving out what the template gave them, and returns how many submissions took part and the pairs at or
+// above the threshold.
+func (s *Service) Analyze(ctx context.Context, courseID, challengeID int, threshold float64) (int, []Pair, error) {
+	template, err := s.repo.GetTemplate(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, ErrUnknownChallenge
+		}
+		return 0, nil, err
+	}
+
+	submissions, err := s.repo.ListLatestSubmissions(ctx, courseID, challengeID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	pairs, err := FindPairs(ctx, submissions, template, threshold)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return len(submissions), pairs, nil
+}
+
+func (s *Service) GetReport(ctx context.Context, courseID, reportID, userID int, role string) (*Report, error) {
+	if err := s.courses.RequireInstructor(ctx, courseID, userID, role); err != nil {
+		return nil, err
+	}
+
+	report, err := s.repo.GetByID(ctx, reportID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReportNotFound
+		}
+		return nil, err
+	}
+
+	if report.CourseID != courseID {
+		return nil, ErrReportNotFound
+	}
+
+	return report, nil
+}
+
+func (s *Service) ListReports(ctx context.Context, courseID, userID int, role string) ([]Report, error) {
+	if err := s.courses.RequireInstructor(ctx, courseID, userID, role); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListByCourse(ctx, courseID)
+}
+
+// FindPairs compares every submission with every other one and returns the
+// pairs whose similarity is at least threshold, most similar first. Code
+// that comes from the challenge template is not counted.
+func FindPairs(ctx context.Context, submissions []Submission, template string, threshold float64) ([]Pair, error) {
+	base := NewDocument(template)
+
+	docs := make([]*Document, len(submissions))
+	for i, s := range submissions {
+		docs[i] = NewDocument(s.Code)
+		docs[i].Exclude(base)
+	}
+
+	pairs := []Pair{}
+	for i := range docs {
+		// Quadratic in the number of students; let long runs be cancelled
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		for j := i + 1; j < len(docs); j++ {
+			score, regions := Compare(docs[i], docs[j])
+			if score < threshold {
+				continue
+			}
+			pairs = append(pairs, Pair{A: submissions[i], B: submissions[j], Similarity: score, Regions: regions})
+		}
+	}
+
+	slices.SortStableFunc(pairs, func(x, y Pair) int {
+		return cmp.Compare(y.Similarity, x.Similarity)
+	})
+
+	return pairs, nil
+}
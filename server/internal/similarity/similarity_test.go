@@ -0,0 +1,256 @@
+package similarity
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const original = `def promedio(notas):
+    total = 0
+    for nota in notas:
+        total += nota
+    return total / len(notas)
+
+valores = [int(x) for x in input().split()]
+print(f"Promedio: {promedio(valores):.2f}")
+`
+
+// Same program with renamed variables, different formatting and comments.
+const disguised = `# mi solucion
+def avg(xs):
+    s=0
+    for x in xs: s+=x
+    return s/len(xs)
+
+
+data=[int(v) for v in input().split()]   # leer
+print( f'Promedio: {avg(data):.2f}' )
+`
+
+const unrelated = `n = int(input())
+for i in range(1, n + 1):
+    if i % 15 == 0:
+        print("FizzBuzz")
+    elif i % 3 == 0:
+        print("Fizz")
+    elif i % 5 == 0:
+        print("Buzz")
+    else:
+        print(i)
+`
+
+func texts(tokens []Token) []string {
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		out[i] = t.Text
+	}
+	return out
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"names are normalized", "total = total + 1", []string{"ID", "=", "ID", "+", "NUM"}},
+		{"builtins and attributes are kept", "xs.append(len(ys))", []string{"ID", ".", "append", "(", "len", "(", "ID", ")", ")"}},
+		{"comments are dropped", "x = 1  # set x\n", []string{"ID", "=", "NUM"}},
+		{"strings with prefixes", `f"{a}" + rb'\x00' + """multi
+line"""`, []string{"STR", "+", "STR", "+", "STR"}},
+		{"multi-char operators", "a **= b // c != d", []string{"ID", "**=", "ID", "//", "ID", "!=", "ID"}},
+		{"numbers", "1.5e-3 + .5 + 0x1F", []string{"NUM", "+", "NUM", "+", "NUM"}},
+		{"identifiers starting like prefixes", "fruit = bar", []string{"ID", "=", "ID"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := texts(Tokenize(tt.src))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Tokenize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeLines(t *testing.T) {
+	tokens := Tokenize("a = '''x\ny'''\nb = 2")
+	got := []int{}
+	for _, tok := range tokens {
+		got = append(got, tok.Line)
+	}
+
+	want := []int{1, 1, 1, 3, 3, 3}
+	if !slices.Equal(got, want) {
+		t.Errorf("Tokenize() lines = %v, want %v", got, want)
+	}
+}
+
+func TestFingerprints(t *testing.T) {
+	if prints := Fingerprints(Tokenize("x = 1")); prints != nil {
+		t.Errorf("Fingerprints() of a short program = %v, want nil", prints)
+	}
+
+	tokens := Tokenize(unrelated)
+	prints := Fingerprints(tokens)
+	if len(prints) == 0 {
+		t.Fatal("Fingerprints() returned nothing")
+	}
+
+	// Winnowing guarantees at least one fingerprint per window
+	for i := 1; i < len(prints); i++ {
+		if gap := prints[i].Pos - prints[i-1].Pos; gap <= 0 || gap > Window {
+			t.Errorf("fingerprints %d and %d are %d k-grams apart, want 1..%d", i-1, i, gap, Window)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		wantMin float64
+		wantMax float64
+	}{
+		{"identical", original, original, 1, 1},
+		{"renamed and reformatted", original, disguised, 1, 1},
+		{"unrelated", original, unrelated, 0, 0.2},
+		{"too short", "x = 1", "x = 1", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, regions := Compare(NewDocument(tt.a), NewDocument(tt.b))
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("Compare() = %.2f, want between %.2f and %.2f", got, tt.wantMin, tt.wantMax)
+			}
+			if got > 0 && len(regions) == 0 {
+				t.Error("Compare() found a similarity but no regions")
+			}
+		})
+	}
+}
+
+func TestCompareRegions(t *testing.T) {
+	_, regions := Compare(NewDocument(original), NewDocument(disguised))
+
+	if len(regions) != 1 {
+		t.Fatalf("Compare() regions = %+v, want a single region", regions)
+	}
+
+	want := Region{StartA: 1, EndA: 8, StartB: 2, EndB: 9}
+	if regions[0] != want {
+		t.Errorf("Compare() region = %+v, want %+v", regions[0], want)
+	}
+}
+
+func TestFindPairs(t *testing.T) {
+	submissions := []Submission{
+		{ID: 1, Username: "ana", Code: original},
+		{ID: 2, Username: "beto", Code: unrelated},
+		{ID: 3, Username: "carla", Code: disguised},
+	}
+
+	pairs, err := FindPairs(t.Context(), submissions, "", DefaultThreshold)
+	if err != nil {
+		t.Fatalf("FindPairs() error = %v", err)
+	}
+
+	if len(pairs) != 1 || pairs[0].A.ID != 1 || pairs[0].B.ID != 3 {
+		t.Fatalf("FindPairs() = %+v, want only ana and carla", pairs)
+	}
+}
+
+// template is the starting code of a challenge; every student keeps it.
+const template = `def contar_vocales(texto):
+    """Devuelve cuantas vocales tiene el texto.
+
+    >>> contar_vocales("hola")
+    2
+    """
+    # Escribe tu codigo aqui
+    pass
+
+
+def leer_frases():
+    try:
+        n = int(input("Cuantas frases? "))
+    except ValueError:
+        print("Escribe un numero")
+        return []
+    frases = []
+    for i in range(n):
+        frase = input(f"Frase {i + 1}: ").strip()
+        if frase and es_valida(frase):
+            frases.append(frase)
+    return frases
+
+
+def es_valida(frase):
+    if len(frase) > 200:
+        print("La frase es demasiado larga")
+        return False
+    if not any(c.isalpha() for c in frase):
+        print("La frase no tiene letras")
+        return False
+    return True
+
+
+def mostrar(frases):
+    for frase in frases:
+        resultado = contar_vocales(frase)
+        print(f"{frase!r} tiene {resultado} vocales")
+    total = sum(contar_vocales(f) for f in frases)
+    print(f"En total hay {total} vocales")
+
+
+if __name__ == "__main__":
+    while True:
+        mostrar(leer_frases())
+        otra = input("Otra vez? (s/n) ").strip().lower()
+        if otra != "s":
+            break
+    print("Hasta luego")
+`
+
+func TestFindPairsExcludesTemplate(t *testing.T) {
+	// Two independent solutions written into the template
+	loop := strings.Replace(template, "    pass\n", `    total = 0
+    for letra in texto.lower():
+        total += letra in "aeiou"
+    return total
+`, 1)
+	comprehension := strings.Replace(template, "    pass\n", `    return sum(1 for c in texto if c.lower() in "aeiou")
+`, 1)
+
+	submissions := []Submission{
+		{ID: 1, Username: "ana", Code: loop},
+		{ID: 2, Username: "beto", Code: comprehension},
+	}
+
+	// The shared template alone makes them look copied
+	if pairs, err := FindPairs(t.Context(), submissions, "", DefaultThreshold); err != nil || len(pairs) != 1 {
+		t.Fatalf("FindPairs() without the template = %+v, %v, want one pair", pairs, err)
+	}
+
+	pairs, err := FindPairs(t.Context(), submissions, template, 0)
+	if err != nil {
+		t.Fatalf("FindPairs() error = %v", err)
+	}
+	if len(pairs) != 1 || pairs[0].Similarity >= DefaultThreshold {
+		t.Fatalf("FindPairs() = %+v, want one pair below %.2f", pairs, DefaultThreshold)
+	}
+	// ana's solution replaces line 8 of the template and ends on line 11
+	for _, r := range pairs[0].Regions {
+		if r.StartA < 8 || r.StartA > 11 {
+			t.Errorf("region %+v starts in the template, want it in the solution", r)
+		}
+	}
+
+	// Copying is still found once the template is left out
+	pairs, err = FindPairs(t.Context(), []Submission{{ID: 1, Code: loop}, {ID: 3, Code: loop}}, template, DefaultThreshold)
+	if err != nil || len(pairs) != 1 {
+		t.Errorf("FindPairs() of a copied solution = %+v, %v, want one pair", pairs, err)
+	}
+}
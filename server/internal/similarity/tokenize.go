@@ -0,0 +1,178 @@
+package similarity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is one lexical unit of Python source after normalization. Names
+// the student chose all become "ID", literals become "NUM" or "STR", and
+// keywords, operators, builtins and attribute names are kept as written, so
+// renaming variables or reformatting code yields the same tokens.
+type Token struct {
+	Text string
+	Line int
+}
+
+var keywords = setOf(
+	"False", "None", "True", "and", "as", "assert", "async", "await", "break",
+	"class", "continue", "def", "del", "elif", "else", "except", "finally",
+	"for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal",
+	"not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+)
+
+// Builtins are usually called, not renamed, and what is called says a lot
+// about how a solution works.
+var builtins = setOf(
+	"abs", "all", "any", "bool", "dict", "enumerate", "filter", "float",
+	"input", "int", "isinstance", "len", "list", "map", "max", "min", "open",
+	"print", "range", "reversed", "round", "set", "sorted", "str", "sum",
+	"tuple", "type", "zip",
+)
+
+var operators = []string{
+	"**=", "//=", ">>=", "<<=", "...",
+	"==", "!=", "<=", ">=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=",
+	"@=", "**", "//", "->", ":=", "<<", ">>",
+}
+
+func setOf(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// Tokenize lexes Python source, dropping comments and whitespace. It is
+// forgiving: code that doesn't parse still tokenizes as far as possible.
+func Tokenize(src string) []Token {
+	var tokens []Token
+	runes := []rune(src)
+	line := 1
+
+	emit := func(text string, at int) {
+		tokens = append(tokens, Token{Text: text, Line: at})
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case r == '\n':
+			line++
+			i++
+
+		case unicode.IsSpace(r), r == '\\':
+			i++
+
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		case isStringStart(runes, i):
+			start := line
+			i, line = skipString(runes, i, line)
+			emit("STR", start)
+
+		case r == '_' || unicode.IsLetter(r):
+			j := i
+			for j < len(runes) && (runes[j] == '_' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			word := string(runes[i:j])
+			afterDot := len(tokens) > 0 && tokens[len(tokens)-1].Text == "."
+			switch {
+			case keywords[word], builtins[word], afterDot:
+				emit(word, line)
+			default:
+				emit("ID", line)
+			}
+			i = j
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || unicode.IsLetter(runes[j]) || runes[j] == '.' || runes[j] == '_' ||
+				((runes[j] == '+' || runes[j] == '-') && (runes[j-1] == 'e' || runes[j-1] == 'E'))) {
+				j++
+			}
+			emit("NUM", line)
+			i = j
+
+		default:
+			op := string(r)
+			for _, candidate := range operators {
+				if strings.HasPrefix(string(runes[i:min(i+3, len(runes))]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			emit(op, line)
+			i += len([]rune(op))
+		}
+	}
+
+	return tokens
+}
+
+// isStringStart reports whether a string literal, with an optional prefix
+// such as r, b or f, starts at i.
+func isStringStart(runes []rune, i int) bool {
+	for j := i; j < len(runes) && j < i+3; j++ {
+		switch unicode.ToLower(runes[j]) {
+		case '\'', '"':
+			return true
+		case 'r', 'b', 'f', 'u':
+			continue
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// skipString returns the index just past the string literal starting at i
+// and the line it ends on.
+func skipString(runes []rune, i, line int) (int, int) {
+	raw := false
+	for runes[i] != '\'' && runes[i] != '"' {
+		if unicode.ToLower(runes[i]) == 'r' {
+			raw = true
+		}
+		i++
+	}
+
+	quote := runes[i]
+	triple := i+2 < len(runes) && runes[i+1] == quote && runes[i+2] == quote
+	if triple {
+		i += 3
+	} else {
+		i++
+	}
+
+	for i < len(runes) {
+		switch r := runes[i]; {
+		case r == '\\' && !raw:
+			if i+1 < len(runes) && runes[i+1] == '\n' {
+				line++
+			}
+			i += 2
+		case r == '\n':
+			line++
+			i++
+			if !triple {
+				// Unterminated single-line string
+				return i, line
+			}
+		case r == quote && !triple:
+			return i + 1, line
+		case r == quote && i+2 < len(runes) && runes[i+1] == quote && runes[i+2] == quote:
+			return i + 3, line
+		default:
+			i++
+		}
+	}
+
+	return i, line
+}
@@ -0,0 +1,164 @@
+package similarity
+
+import (
+	"hash/fnv"
+	"slices"
+	"strings"
+)
+
+// Matches shorter than KGram tokens are ignored; any match of at least
+// KGram+Window-1 tokens is guaranteed to be detected.
+const (
+	KGram  = 5
+	Window = 4
+)
+
+// Fingerprint is a selected k-gram hash and the index of the k-gram's first
+// token.
+type Fingerprint struct {
+	Hash uint64
+	Pos  int
+}
+
+// Fingerprints winnows the token stream: it hashes every k-gram and keeps
+// the smallest hash of each window of Window consecutive hashes, taking the
+// rightmost one on ties.
+func Fingerprints(tokens []Token) []Fingerprint {
+	if len(tokens) < KGram {
+		return nil
+	}
+
+	hashes := make([]uint64, len(tokens)-KGram+1)
+	for i := range hashes {
+		hashes[i] = hashKGram(tokens[i : i+KGram])
+	}
+
+	var prints []Fingerprint
+	last := -1
+	for start := 0; start+min(Window, len(hashes)) <= len(hashes); start++ {
+		end := start + min(Window, len(hashes))
+
+		best := start
+		for i := start; i < end; i++ {
+			if hashes[i] <= hashes[best] {
+				best = i
+			}
+		}
+
+		if best != last {
+			prints = append(prints, Fingerprint{Hash: hashes[best], Pos: best})
+			last = best
+		}
+	}
+
+	return prints
+}
+
+func hashKGram(tokens []Token) uint64 {
+	texts := make([]string, len(tokens))
+	for i, t := range tokens {
+		texts[i] = t.Text
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(texts, "\x00")))
+	return h.Sum64()
+}
+
+// Document is a submission ready to be compared.
+type Document struct {
+	Tokens []Token
+	Prints []Fingerprint
+	hashes map[uint64][]int
+}
+
+func NewDocument(src string) *Document {
+	tokens := Tokenize(src)
+	prints := Fingerprints(tokens)
+
+	hashes := make(map[uint64][]int, len(prints))
+	for _, p := range prints {
+		hashes[p.Hash] = append(hashes[p.Hash], p.Pos)
+	}
+
+	return &Document{Tokens: tokens, Prints: prints, hashes: hashes}
+}
+
+// Exclude drops the fingerprints d shares with base, such as the template
+// every student starts from, so they count neither as similarity nor as
+// matched regions.
+func (d *Document) Exclude(base *Document) {
+	if base == nil {
+		return
+	}
+
+	d.Prints = slices.DeleteFunc(d.Prints, func(p Fingerprint) bool {
+		_, ok := base.hashes[p.Hash]
+		return ok
+	})
+	for hash := range base.hashes {
+		delete(d.hashes, hash)
+	}
+}
+
+// Region is a stretch of code found in both documents, as 1-based inclusive
+// line ranges.
+type Region struct {
+	StartA int `json:"start_a"`
+	EndA   int `json:"end_a"`
+	StartB int `json:"start_b"`
+	EndB   int `json:"end_b"`
+}
+
+// Compare returns the Jaccard similarity of the two documents' fingerprint
+// sets and the regions where they match.
+func Compare(a, b *Document) (float64, []Region) {
+	if len(a.hashes) == 0 || len(b.hashes) == 0 {
+		return 0, nil
+	}
+
+	type pair struct{ a, b int }
+	var pairs []pair
+	shared := 0
+	for hash, positionsA := range a.hashes {
+		positionsB, ok := b.hashes[hash]
+		if !ok {
+			continue
+		}
+		shared++
+		for _, pa := range positionsA {
+			for _, pb := range positionsB {
+				pairs = append(pairs, pair{pa, pb})
+			}
+		}
+	}
+
+	similarity := float64(shared) / float64(len(a.hashes)+len(b.hashes)-shared)
+
+	slices.SortFunc(pairs, func(x, y pair) int {
+		if x.a != y.a {
+			return x.a - y.a
+		}
+		return x.b - y.b
+	})
+
+	// Matching k-grams that follow each other closely in both documents
+	// belong to the same region
+	var regions []Region
+	var last pair
+	for i, p := range pairs {
+		startA, endA := a.Tokens[p.a].Line, a.Tokens[p.a+KGram-1].Line
+		startB, endB := b.Tokens[p.b].Line, b.Tokens[p.b+KGram-1].Line
+
+		n := len(regions)
+		if i > 0 && p.a-last.a <= KGram+Window && p.b > last.b && p.b-last.b <= KGram+Window {
+			regions[n-1].EndA = max(regions[n-1].EndA, endA)
+			regions[n-1].EndB = max(regions[n-1].EndB, endB)
+		} else {
+			regions = append(regions, Region{StartA: startA, EndA: endA, StartB: startB, EndB: endB})
+		}
+		last = p
+	}
+
+	return similarity, regions
+}
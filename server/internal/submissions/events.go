@@ -0,0 +1,157 @@
+package submissions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"apschool/internal/ctxkeys"
+	"apschool/internal/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Variables rather than constants so tests can speed streams up.
+var (
+	// pollInterval is how often an open stream checks the submission. Jobs
+	// may run in another process, so the database is the only shared view.
+	pollInterval = 500 * time.Millisecond
+
+	// heartbeatInterval keeps proxies from closing a quiet stream.
+	heartbeatInterval = 15 * time.Second
+
+	// maxStreamDuration ends streams of submissions that never finish, for
+	// instance because their grading job died.
+	maxStreamDuration = 15 * time.Minute
+)
+
+// statusEvent is the data of a "status" event. Output and error are only set
+// once the submission is finished.
+type statusEvent struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+	Passed bool   `json:"passed"`
+	Output string `json:"output,omitzero"`
+	Error  string `json:"error,omitzero"`
+}
+
+func eventsPath(id int) string {
+	return "/api/submissions/" + strconv.Itoa(id) + "/events"
+}
+
+// EventsHandler streams a submission's progress as Server-Sent Events: a
+// "status" event for every transition (queued, running, passed, failed) and,
+// before the final one, a "result" event per test case. The stream ends after
+// the final status.
+func (h *Handler) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid id")
+		return
+	}
+
+	submission, err := h.service.GetSubmission(r.Context(), userID, id)
+	if err != nil {
+		if errors.Is(err, ErrSubmissionNotFound) {
+			response.NotFound(w)
+			return
+		}
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	// The stream outlives the server's WriteTimeout, it is bounded by
+	// maxStreamDuration instead
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	deadline := time.After(maxStreamDuration)
+
+	sent := ""
+	for {
+		if submission.Status != sent {
+			if err := writeStatus(w, submission); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			if submission.Finished() {
+				return
+			}
+			sent = submission.Status
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-deadline:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-poll.C:
+			submission, err = h.service.GetSubmission(r.Context(), userID, id)
+			if err != nil {
+				// Headers are gone, so the client just sees the stream end
+				if r.Context().Err() == nil {
+					h.logger.Error("reading submission for events failed", "submission_id", id, "error", err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// writeStatus writes the submission's status event, preceded by its case
+// results when it is finished. The grader already redacted hidden cases.
+func writeStatus(w http.ResponseWriter, s *Submission) error {
+	event := statusEvent{ID: s.ID, Status: s.Status, Passed: s.Passed}
+
+	if s.Finished() {
+		for _, result := range s.Results {
+			if err := writeEvent(w, "result", result); err != nil {
+				return err
+			}
+		}
+		event.Output = s.Output
+		event.Error = s.Error
+	}
+
+	return writeEvent(w, "status", event)
+}
+
+func writeEvent(w http.ResponseWriter, event string, data any) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, js)
+	return err
+}
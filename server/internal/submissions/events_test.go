@@ -0,0 +1,151 @@
+package submissions
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"apschool/internal/ctxkeys"
+	"apschool/internal/grader"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newEventsServer serves the events endpoint to user 7 with short intervals.
+func newEventsServer(t *testing.T, repo *memoryRepository) *httptest.Server {
+	t.Helper()
+
+	saved := []time.Duration{pollInterval, heartbeatInterval, maxStreamDuration}
+	pollInterval, heartbeatInterval, maxStreamDuration = 10*time.Millisecond, 20*time.Millisecond, 5*time.Second
+	t.Cleanup(func() {
+		pollInterval, heartbeatInterval, maxStreamDuration = saved[0], saved[1], saved[2]
+	})
+
+	h := newTestHandler(repo, nil)
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxkeys.UserIDKey, 7)))
+		})
+	})
+	router.Get("/api/submissions/{id}/events", h.EventsHandler)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// nextLine reads the stream up to the next line that isn't a blank separator.
+func nextLine(t *testing.T, stream *bufio.Reader) string {
+	t.Helper()
+
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			return line
+		}
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	repo := newMemoryRepository()
+	repo.submissions[1] = &Submission{ID: 1, UserID: 7, ChallengeID: 1, Status: StatusQueued}
+	server := newEventsServer(t, repo)
+
+	resp, err := http.Get(server.URL + eventsPath(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+
+	stream := bufio.NewReader(resp.Body)
+	if got := nextLine(t, stream); got != "event: status" {
+		t.Fatalf("first line = %q, want the queued status", got)
+	}
+	if got := nextLine(t, stream); !strings.Contains(got, `"status":"queued"`) {
+		t.Fatalf("first status = %q, want queued", got)
+	}
+
+	// Nothing changes, so the next thing on the stream is a heartbeat
+	if got := nextLine(t, stream); got != ": keep-alive" {
+		t.Fatalf("line = %q, want a heartbeat", got)
+	}
+
+	repo.SaveVerdict(context.Background(), &Submission{
+		ID: 1, UserID: 7, ChallengeID: 1, Status: StatusPassed, Passed: true,
+		Results: []grader.CaseResult{{Name: "adds", Passed: true}, {Name: "hidden 1", Passed: true, Hidden: true}},
+	})
+
+	var events []string
+	for len(events) < 3 {
+		line := nextLine(t, stream)
+		if event, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, event)
+			data := nextLine(t, stream)
+			if event == "status" && !strings.Contains(data, `"status":"passed"`) {
+				t.Errorf("final status = %q, want passed", data)
+			}
+		}
+	}
+	if strings.Join(events, ",") != "result,result,status" {
+		t.Errorf("events = %v, want two results then the final status", events)
+	}
+
+	// The terminal event closes the stream
+	if rest, err := stream.ReadString(0); err == nil || strings.TrimSpace(rest) != "" {
+		t.Errorf("stream continued after the final status: %q, %v", rest, err)
+	}
+}
+
+func TestEventsHandler_FinishedSubmission(t *testing.T) {
+	repo := newMemoryRepository()
+	repo.submissions[1] = &Submission{ID: 1, UserID: 7, ChallengeID: 1, Status: StatusFailed, Error: "boom"}
+	server := newEventsServer(t, repo)
+
+	resp, err := http.Get(server.URL + eventsPath(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	stream := bufio.NewReader(resp.Body)
+	nextLine(t, stream)
+	if got := nextLine(t, stream); !strings.Contains(got, `"status":"failed"`) || !strings.Contains(got, `"error":"boom"`) {
+		t.Errorf("status = %q, want failed with its error", got)
+	}
+	if rest, err := stream.ReadString(0); err == nil || strings.TrimSpace(rest) != "" {
+		t.Errorf("stream continued after the final status: %q, %v", rest, err)
+	}
+}
+
+func TestEventsHandler_NotFound(t *testing.T) {
+	repo := newMemoryRepository()
+	repo.submissions[1] = &Submission{ID: 1, UserID: 8, ChallengeID: 1, Status: StatusQueued}
+	server := newEventsServer(t, repo)
+
+	for _, path := range []string{eventsPath(1), eventsPath(2)} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s status = %d, want %d", path, resp.StatusCode, http.StatusNotFound)
+		}
+	}
+}
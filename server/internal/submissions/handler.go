@@ -2,12 +2,14 @@ package submissions
 
 import (
 	"apschool/internal/ctxkeys"
+	"apschool/internal/pagination"
 	"apschool/internal/response"
 	"apschool/internal/validator"
 	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -44,10 +46,47 @@ func (h *Handler) CreateSubmissionsHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Clients that follow the events stream ask for the verdict later with
+	// Prefer: respond-async; without a queue they get it right away
+	if preferAsync(r) {
+		err := h.service.QueueSubmission(r.Context(), userID, &submission)
+		if err == nil {
+			headers := make(http.Header)
+			headers.Set("Preference-Applied", "respond-async")
+			headers.Set("Location", eventsPath(submission.ID))
+
+			response.WriteJSON(w, http.StatusAccepted, response.Envelope{"submission": submission}, headers)
+			return
+		}
+		if errors.Is(err, ErrChallengeNotFound) {
+			response.NotFound(w)
+			return
+		}
+		if !errors.Is(err, ErrCannotQueue) {
+			response.ServerError(w, r, h.logger, err)
+			return
+		}
+	}
+
 	err := h.service.CreateSubmission(r.Context(), userID, &submission)
+	if errors.Is(err, ErrAwardFailed) {
+		// The submission itself was stored; badges are caught up later
+		h.logger.Error("awarding achievements failed", "user_id", userID, "challenge_id", submission.ChallengeID, "error", err)
+		err = nil
+	}
 	if err != nil {
 		if errors.Is(err, ErrSubmissionNotPassed) {
-			response.BadRequest(w, "submission did not pass the tests")
+			response.ErrorResponse(w, http.StatusBadRequest, response.Envelope{
+				"message": "submission did not pass the tests",
+				"output":  submission.Output,
+				"details": submission.Error,
+				"results": submission.Results,
+				"suites":  submission.Suites,
+			})
+			return
+		}
+		if errors.Is(err, ErrChallengeNotFound) {
+			response.NotFound(w)
 			return
 		}
 		response.ServerError(w, r, h.logger, err)
@@ -98,3 +137,47 @@ func (h *Handler) GetSubmissionHandler(w http.ResponseWriter, r *http.Request) {
 
 	response.WriteJSON(w, http.StatusOK, response.Envelope{"submission": submission}, nil)
 }
+
+func (h *Handler) ListAttemptsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := ctxkeys.GetUserID(r.Context())
+	if !ok {
+		response.Unauthorized(w)
+		return
+	}
+	challengeIDStr := chi.URLParam(r, "challenge_id")
+	challengeID, err := strconv.Atoi(challengeIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid challenge_id")
+		return
+	}
+
+	v := validator.New()
+	filters := pagination.FromQuery(r.URL.Query(), v)
+	if !v.Valid() {
+		response.ValidationError(w, v.Errors)
+		return
+	}
+
+	attempts, metadata, err := h.service.ListAttempts(r.Context(), userID, challengeID, filters)
+	if err != nil {
+		response.ServerError(w, r, h.logger, err)
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, response.Envelope{"attempts": attempts, "metadata": metadata}, nil)
+}
+
+// preferAsync reports whether the client sent Prefer: respond-async
+// (RFC 7240).
+func preferAsync(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for pref := range strings.SplitSeq(header, ",") {
+			// Parameters after ';' don't change the preference
+			name, _, _ := strings.Cut(pref, ";")
+			if strings.EqualFold(strings.TrimSpace(name), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package submissions
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"apschool/internal/ctxkeys"
+)
+
+func newTestHandler(repo *memoryRepository, jobs Enqueuer) *Handler {
+	service := NewService(repo, gradeAs(true), nil, jobs)
+	return NewHandler(service, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestCreateSubmissionsHandler_Prefer(t *testing.T) {
+	queue := enqueueFunc(func(ctx context.Context, kind string, payload any) (int, error) {
+		if kind != KindGrade {
+			t.Errorf("enqueued kind %q, want %q", kind, KindGrade)
+		}
+		return 1, nil
+	})
+
+	tests := []struct {
+		name       string
+		prefer     string
+		jobs       Enqueuer
+		wantStatus int
+	}{
+		{name: "respond-async", prefer: "respond-async", jobs: queue, wantStatus: http.StatusAccepted},
+		{name: "among other preferences", prefer: "wait=5, respond-async", jobs: queue, wantStatus: http.StatusAccepted},
+		{name: "with parameters", prefer: "respond-async; foo=bar", jobs: queue, wantStatus: http.StatusAccepted},
+		{name: "case insensitive", prefer: "Respond-Async", jobs: queue, wantStatus: http.StatusAccepted},
+		{name: "other preference", prefer: "return=minimal", jobs: queue, wantStatus: http.StatusCreated},
+		{name: "no preference", jobs: queue, wantStatus: http.StatusCreated},
+		{name: "no queue grades right away", prefer: "respond-async", wantStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(newMemoryRepository(), tt.jobs)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/submissions", strings.NewReader(`{"challenge_id": 1, "code": "print(5)"}`))
+			req = req.WithContext(context.WithValue(req.Context(), ctxkeys.UserIDKey, 7))
+			if tt.prefer != "" {
+				req.Header.Set("Prefer", tt.prefer)
+			}
+			rr := httptest.NewRecorder()
+
+			h.CreateSubmissionsHandler(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rr.Code, tt.wantStatus, rr.Body)
+			}
+
+			async := tt.wantStatus == http.StatusAccepted
+			if got := rr.Header().Get("Preference-Applied") == "respond-async"; got != async {
+				t.Errorf("Preference-Applied = %q", rr.Header().Get("Preference-Applied"))
+			}
+			if async && rr.Header().Get("Location") != "/api/submissions/1/events" {
+				t.Errorf("Location = %q, want %q", rr.Header().Get("Location"), "/api/submissions/1/events")
+			}
+		})
+	}
+}
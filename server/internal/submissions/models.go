@@ -2,14 +2,47 @@ package submissions
 
 import (
 	"time"
+
+	"apschool/internal/achievements"
+	"apschool/internal/grader"
+)
+
+// Submissions graded in the background start queued and move to running
+// before they get their verdict. Graded on the spot they are created passed or
+// failed.
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
 )
 
 type Submission struct {
-	ID          int       `json:"id"`
-	UserID      int       `json:"-"`
-	ChallengeID int       `json:"challenge_id"`
-	Code        string    `json:"code"`
-	Passed      bool      `json:"passed"`
-	CreatedAt   time.Time `json:"-"`
-	UpdatedAt   time.Time `json:"-"`
+	ID          int                  `json:"id"`
+	UserID      int                  `json:"-"`
+	ChallengeID int                  `json:"challenge_id"`
+	Code        string               `json:"code"`
+	Passed      bool                 `json:"passed"`
+	Status      string               `json:"status"`
+	Output      string               `json:"output,omitzero"`
+	Error       string               `json:"error,omitzero"`
+	CodeSize    int                  `json:"code_size"`
+	Results     []grader.CaseResult  `json:"results,omitempty"`
+	Suites      []grader.SuiteResult `json:"suites,omitempty"` // like Badges, only on the grading response
+	Badges      []achievements.Badge `json:"badges,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"-"`
+}
+
+// Finished reports whether the submission has its verdict.
+func (s *Submission) Finished() bool {
+	return s.Status == StatusPassed || s.Status == StatusFailed
+}
+
+// statusOf is the final status for a verdict.
+func statusOf(passed bool) string {
+	if passed {
+		return StatusPassed
+	}
+	return StatusFailed
 }
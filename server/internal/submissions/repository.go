@@ -3,10 +3,28 @@ package submissions
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+
+	"apschool/internal/grader"
+	"apschool/internal/pagination"
+)
+
+var (
+	ErrSubmissionNotFound = errors.New("submission not found")
+	ErrChallengeNotFound  = errors.New("challenge not found")
 )
 
-var ErrSubmissionNotFound = errors.New("submission not found")
+type RepositoryInterface interface {
+	Create(ctx context.Context, s *Submission) error
+	GetByID(ctx context.Context, id int) (*Submission, error)
+	SetRunning(ctx context.Context, id int) error
+	SaveVerdict(ctx context.Context, s *Submission) error
+	GetLatestAccepted(ctx context.Context, userID, challengeID int) (*Submission, error)
+	GetByUser(ctx context.Context, userID int) ([]Submission, error)
+	ListAttempts(ctx context.Context, userID, challengeID int, filters pagination.Filters) ([]Submission, pagination.Metadata, error)
+	GetChallengeTests(ctx context.Context, challengeID int) (grader.Tests, error)
+}
 
 type Repository struct {
 	db *sql.DB
@@ -16,13 +34,21 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// Create stores a new attempt. Every attempt is kept, so earlier code is never
+// overwritten.
 func (r *Repository) Create(ctx context.Context, s *Submission) error {
+	results := s.Results
+	if results == nil {
+		results = []grader.CaseResult{}
+	}
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
 
 	query := `
-	INSERT INTO submissions (user_id, challenge_id, code, passed)
-	VALUES ($1, $2, $3, $4)
-	ON CONFLICT (user_id, challenge_id)
-	DO UPDATE SET code = $3, passed = $4, updated_at = NOW()
+	INSERT INTO submissions (user_id, challenge_id, code, passed, status, output, error, code_size, results)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	RETURNING id, created_at, updated_at
 	`
 
@@ -31,25 +57,106 @@ func (r *Repository) Create(ctx context.Context, s *Submission) error {
 		s.ChallengeID,
 		s.Code,
 		s.Passed,
+		s.Status,
+		s.Output,
+		s.Error,
+		s.CodeSize,
+		resultsJSON,
 	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
 
 }
 
-func (r *Repository) GetByUserAndChallenge(ctx context.Context, userID, challengeID int) (*Submission, error) {
+// GetByID returns an attempt with its verdict, whatever its status.
+func (r *Repository) GetByID(ctx context.Context, id int) (*Submission, error) {
 
 	query := `
-	SELECT id, user_id, challenge_id, code, passed, created_at, updated_at
+	SELECT id, user_id, challenge_id, code, passed, status, output, error, code_size, results, created_at, updated_at
 	FROM submissions
-	WHERE user_id = $1 AND challenge_id = $2
+	WHERE id = $1
+	`
+
+	var s Submission
+	var results []byte
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&s.ID,
+		&s.UserID,
+		&s.ChallengeID,
+		&s.Code,
+		&s.Passed,
+		&s.Status,
+		&s.Output,
+		&s.Error,
+		&s.CodeSize,
+		&results,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSubmissionNotFound
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(results, &s.Results); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// SetRunning marks a queued attempt as being graded.
+func (r *Repository) SetRunning(ctx context.Context, id int) error {
+
+	query := `UPDATE submissions SET status = 'running', updated_at = NOW()
+	WHERE id = $1 AND status IN ('queued', 'running')`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// SaveVerdict stores the outcome of grading an attempt in the background.
+func (r *Repository) SaveVerdict(ctx context.Context, s *Submission) error {
+	results := s.Results
+	if results == nil {
+		results = []grader.CaseResult{}
+	}
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE submissions SET passed = $2, status = $3, output = $4, error = $5, results = $6, updated_at = NOW()
+	WHERE id = $1
+	RETURNING updated_at`
+
+	return r.db.QueryRowContext(ctx, query, s.ID, s.Passed, s.Status, s.Output, s.Error, resultsJSON).Scan(&s.UpdatedAt)
+}
+
+// GetLatestAccepted returns the most recent passing attempt of a user for a
+// challenge.
+func (r *Repository) GetLatestAccepted(ctx context.Context, userID, challengeID int) (*Submission, error) {
+
+	query := `
+	SELECT id, user_id, challenge_id, code, passed, status, code_size, results, created_at, updated_at
+	FROM submissions
+	WHERE user_id = $1 AND challenge_id = $2 AND passed = true
+	ORDER BY created_at DESC, id DESC
+	LIMIT 1
 	`
 
 	var s Submission
+	var results []byte
 	err := r.db.QueryRowContext(ctx, query, userID, challengeID).Scan(
 		&s.ID,
 		&s.UserID,
 		&s.ChallengeID,
 		&s.Code,
 		&s.Passed,
+		&s.Status,
+		&s.CodeSize,
+		&results,
 		&s.CreatedAt,
 		&s.UpdatedAt,
 	)
@@ -61,15 +168,22 @@ func (r *Repository) GetByUserAndChallenge(ctx context.Context, userID, challeng
 		return nil, err
 	}
 
+	if err := json.Unmarshal(results, &s.Results); err != nil {
+		return nil, err
+	}
+
 	return &s, nil
 }
 
+// GetByUser returns the latest accepted attempt for every challenge the user
+// has solved.
 func (r *Repository) GetByUser(ctx context.Context, userID int) ([]Submission, error) {
 
 	query := `
-	SELECT id, user_id, challenge_id, code, passed, created_at, updated_at
+	SELECT DISTINCT ON (challenge_id) id, user_id, challenge_id, code, passed, status, code_size, results, created_at, updated_at
 	FROM submissions
-	WHERE user_id = $1
+	WHERE user_id = $1 AND passed = true
+	ORDER BY challenge_id, created_at DESC, id DESC
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, userID)
@@ -81,17 +195,24 @@ func (r *Repository) GetByUser(ctx context.Context, userID int) ([]Submission, e
 	var submissions []Submission
 	for rows.Next() {
 		var s Submission
+		var results []byte
 		if err := rows.Scan(
 			&s.ID,
 			&s.UserID,
 			&s.ChallengeID,
 			&s.Code,
 			&s.Passed,
+			&s.Status,
+			&s.CodeSize,
+			&results,
 			&s.CreatedAt,
 			&s.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
+		if err := json.Unmarshal(results, &s.Results); err != nil {
+			return nil, err
+		}
 		submissions = append(submissions, s)
 	}
 
@@ -102,3 +223,82 @@ func (r *Repository) GetByUser(ctx context.Context, userID int) ([]Submission, e
 	return submissions, nil
 
 }
+
+// ListAttempts returns one page of a user's attempts for a challenge, newest
+// first.
+func (r *Repository) ListAttempts(ctx context.Context, userID, challengeID int, filters pagination.Filters) ([]Submission, pagination.Metadata, error) {
+
+	query := `
+	SELECT count(*) OVER(), id, user_id, challenge_id, code, passed, status, code_size, results, created_at, updated_at
+	FROM submissions
+	WHERE user_id = $1 AND challenge_id = $2
+	ORDER BY created_at DESC, id DESC
+	LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, challengeID, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	submissions := []Submission{}
+	for rows.Next() {
+		var s Submission
+		var results []byte
+		if err := rows.Scan(
+			&totalRecords,
+			&s.ID,
+			&s.UserID,
+			&s.ChallengeID,
+			&s.Code,
+			&s.Passed,
+			&s.Status,
+			&s.CodeSize,
+			&results,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		); err != nil {
+			return nil, pagination.Metadata{}, err
+		}
+		if err := json.Unmarshal(results, &s.Results); err != nil {
+			return nil, pagination.Metadata{}, err
+		}
+		submissions = append(submissions, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, pagination.Metadata{}, err
+	}
+
+	return submissions, pagination.NewMetadata(totalRecords, filters), nil
+}
+
+// GetChallengeTests returns everything an active challenge is graded
+// against, hidden tests included.
+func (r *Repository) GetChallengeTests(ctx context.Context, challengeID int) (grader.Tests, error) {
+
+	query := `
+	SELECT test_code, hidden_test_code, test_cases
+	FROM challenges
+	WHERE id = $1 AND is_active = true
+	`
+
+	var testCode, hiddenTestCode string
+	var testCases []byte
+	err := r.db.QueryRowContext(ctx, query, challengeID).Scan(&testCode, &hiddenTestCode, &testCases)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return grader.Tests{}, ErrChallengeNotFound
+		}
+		return grader.Tests{}, err
+	}
+
+	var cases []grader.Case
+	if err := json.Unmarshal(testCases, &cases); err != nil {
+		return grader.Tests{}, err
+	}
+
+	return grader.ChallengeTests(testCode, hiddenTestCode, cases), nil
+}
@@ -2,31 +2,243 @@ package submissions
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+
+	"apschool/internal/achievements"
+	"apschool/internal/grader"
+	"apschool/internal/jobs"
+	"apschool/internal/pagination"
 )
 
-var ErrSubmissionNotPassed = errors.New("submission did not pass the tests")
+// KindGrade is the job that grades a queued submission.
+const KindGrade = "submissions.grade"
+
+var (
+	ErrSubmissionNotPassed = errors.New("submission did not pass the tests")
+	ErrAwardFailed         = errors.New("awarding achievements failed")
+	ErrCannotQueue         = errors.New("background grading is not available")
+)
+
+// Awarder grants achievements once a submission is accepted.
+type Awarder interface {
+	Award(ctx context.Context, userID int) ([]achievements.Badge, error)
+}
+
+// Enqueuer queues background jobs, see jobs.Service.
+type Enqueuer interface {
+	Enqueue(ctx context.Context, kind string, payload any) (int, error)
+}
 
 type Service struct {
-	repo *Repository
+	repo    RepositoryInterface
+	grader  grader.Grader
+	awarder Awarder
+	jobs    Enqueuer
+}
+
+type gradePayload struct {
+	SubmissionID int `json:"submission_id"`
 }
 
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+// NewService creates the submissions service. When g is nil the service falls
+// back to trusting the `passed` flag reported by the browser, which is meant
+// for deployments that have no Python interpreter available. awarder may be
+// nil to disable achievements, and jobs may be nil where nothing is queued.
+func NewService(repo RepositoryInterface, g grader.Grader, awarder Awarder, jobs Enqueuer) *Service {
+	return &Service{repo: repo, grader: g, awarder: awarder, jobs: jobs}
 }
 
 func (s *Service) CreateSubmission(ctx context.Context, userID int, submission *Submission) error {
+	if s.grader != nil {
+		tests, err := s.repo.GetChallengeTests(ctx, submission.ChallengeID)
+		if err != nil {
+			return err
+		}
+
+		result, err := s.grader.Grade(ctx, submission.Code, tests)
+		if err != nil {
+			return err
+		}
+
+		// The server verdict always overrides whatever the client claimed
+		submission.Passed = result.Passed
+		submission.Output = result.Output
+		submission.Error = result.Error
+		submission.Results = result.Cases
+		submission.Suites = result.Suites
+	}
+
+	submission.UserID = userID
+	submission.CodeSize = len(submission.Code)
+	submission.Status = statusOf(submission.Passed)
+
+	// Failed attempts are stored too so the full history is available
+	if err := s.repo.Create(ctx, submission); err != nil {
+		return err
+	}
+
 	if !submission.Passed {
 		return ErrSubmissionNotPassed
 	}
 
+	// The attempt is already stored, so a failure here is reported with
+	// ErrAwardFailed and the caller can still treat the submission as accepted
+	if s.awarder != nil {
+		badges, err := s.awarder.Award(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrAwardFailed, err)
+		}
+		submission.Badges = badges
+	}
+
+	return nil
+}
+
+// QueueSubmission stores the attempt as queued and leaves grading to a job,
+// whose progress can be followed with GetSubmission. It returns
+// ErrCannotQueue when the server doesn't grade or has no queue.
+func (s *Service) QueueSubmission(ctx context.Context, userID int, submission *Submission) error {
+	if s.grader == nil || s.jobs == nil {
+		return ErrCannotQueue
+	}
+
+	// Fail fast on challenges that can't be graded
+	if _, err := s.repo.GetChallengeTests(ctx, submission.ChallengeID); err != nil {
+		return err
+	}
+
 	submission.UserID = userID
+	submission.CodeSize = len(submission.Code)
+	submission.Passed = false
+	submission.Status = StatusQueued
+	submission.Results = nil
 
-	return s.repo.Create(ctx, submission)
+	if err := s.repo.Create(ctx, submission); err != nil {
+		return err
+	}
+
+	if _, err := s.jobs.Enqueue(ctx, KindGrade, gradePayload{SubmissionID: submission.ID}); err != nil {
+		// Without its job the attempt would stay queued for good
+		submission.Status = StatusFailed
+		submission.Error = "the submission could not be queued for grading"
+		if saveErr := s.repo.SaveVerdict(context.WithoutCancel(ctx), submission); saveErr != nil {
+			return errors.Join(err, saveErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RunGradeJob is the jobs.HandlerFunc for KindGrade.
+func (s *Service) RunGradeJob(ctx context.Context, payload json.RawMessage) error {
+	var p gradePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	submission, err := s.repo.GetByID(ctx, p.SubmissionID)
+	if err != nil {
+		if errors.Is(err, ErrSubmissionNotFound) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+
+	// A retry after the verdict was saved has nothing left to do
+	if submission.Finished() {
+		return nil
+	}
+
+	if err := s.repo.SetRunning(ctx, submission.ID); err != nil {
+		return err
+	}
+
+	tests, err := s.repo.GetChallengeTests(ctx, submission.ChallengeID)
+	if errors.Is(err, ErrChallengeNotFound) {
+		// Deactivated since it was queued; the attempt still needs a verdict
+		submission.Status = StatusFailed
+		submission.Error = "the challenge is no longer available"
+		return s.repo.SaveVerdict(ctx, submission)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := s.grader.Grade(ctx, submission.Code, tests)
+	if err != nil {
+		return err
+	}
+
+	submission.Passed = result.Passed
+	submission.Status = statusOf(result.Passed)
+	submission.Output = result.Output
+	submission.Error = result.Error
+	submission.Results = result.Cases
+
+	if err := s.repo.SaveVerdict(ctx, submission); err != nil {
+		return err
+	}
+
+	// Retrying would not award again since the verdict is already saved, so
+	// the failure is left in the dead jobs instead
+	if submission.Passed && s.awarder != nil {
+		if _, err := s.awarder.Award(ctx, submission.UserID); err != nil {
+			return jobs.Permanent(fmt.Errorf("%w: %w", ErrAwardFailed, err))
+		}
+	}
+
+	return nil
+}
+
+// FailGradeJob is the jobs.DeadFunc for KindGrade. An attempt whose job died
+// before saving a verdict gets a failed one, so it doesn't stay running.
+func (s *Service) FailGradeJob(ctx context.Context, payload json.RawMessage, cause error) error {
+	var p gradePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+
+	submission, err := s.repo.GetByID(ctx, p.SubmissionID)
+	if err != nil {
+		return err
+	}
+
+	// Dead after the verdict was saved, when awarding achievements failed
+	if submission.Finished() {
+		return nil
+	}
+
+	submission.Passed = false
+	submission.Status = StatusFailed
+	submission.Error = "the submission could not be graded"
+	submission.Results = nil
+	return s.repo.SaveVerdict(ctx, submission)
+}
+
+// GetSubmission returns one of the user's attempts with its current status.
+func (s *Service) GetSubmission(ctx context.Context, userID, id int) (*Submission, error) {
+	submission, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Other users' attempts look missing
+	if submission.UserID != userID {
+		return nil, ErrSubmissionNotFound
+	}
+
+	return submission, nil
 }
 
 func (s *Service) GetUserSubmission(ctx context.Context, userID, challengeID int) (*Submission, error) {
-	return s.repo.GetByUserAndChallenge(ctx, userID, challengeID)
+	return s.repo.GetLatestAccepted(ctx, userID, challengeID)
+}
+
+func (s *Service) ListAttempts(ctx context.Context, userID, challengeID int, filters pagination.Filters) ([]Submission, pagination.Metadata, error) {
+	return s.repo.ListAttempts(ctx, userID, challengeID, filters)
 }
 
 func (s *Service) GetUserSubmissions(ctx context.Context, userID int) ([]Submission, error) {
@@ -0,0 +1,434 @@
+package submissions
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"apschool/internal/achievements"
+	"apschool/internal/grader"
+	"apschool/internal/jobs"
+	"apschool/internal/pagination"
+)
+
+// memoryRepository keeps submissions in memory. Handlers read it from other
+// goroutines, so every access is locked and submissions are copied in and out.
+type memoryRepository struct {
+	mu          sync.Mutex
+	submissions map[int]*Submission
+	tests       map[int]grader.Tests
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		submissions: make(map[int]*Submission),
+		tests:       map[int]grader.Tests{1: {Cases: []grader.Case{{Name: "adds"}}}},
+	}
+}
+
+func (m *memoryRepository) Create(ctx context.Context, s *Submission) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.ID = len(m.submissions) + 1
+	s.CreatedAt = time.Now()
+	saved := *s
+	m.submissions[s.ID] = &saved
+	return nil
+}
+
+func (m *memoryRepository) GetByID(ctx context.Context, id int) (*Submission, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.submissions[id]
+	if !ok {
+		return nil, ErrSubmissionNotFound
+	}
+	found := *s
+	return &found, nil
+}
+
+func (m *memoryRepository) SetRunning(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.submissions[id].Status = StatusRunning
+	return nil
+}
+
+func (m *memoryRepository) SaveVerdict(ctx context.Context, s *Submission) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	saved := *s
+	m.submissions[s.ID] = &saved
+	return nil
+}
+
+func (m *memoryRepository) GetLatestAccepted(ctx context.Context, userID, challengeID int) (*Submission, error) {
+	return nil, ErrSubmissionNotFound
+}
+
+func (m *memoryRepository) GetByUser(ctx context.Context, userID int) ([]Submission, error) {
+	return nil, nil
+}
+
+func (m *memoryRepository) ListAttempts(ctx context.Context, userID, challengeID int, filters pagination.Filters) ([]Submission, pagination.Metadata, error) {
+	return nil, pagination.Metadata{}, nil
+}
+
+func (m *memoryRepository) GetChallengeTests(ctx context.Context, challengeID int) (grader.Tests, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tests, ok := m.tests[challengeID]
+	if !ok {
+		return grader.Tests{}, ErrChallengeNotFound
+	}
+	return tests, nil
+}
+
+type gradeFunc func(ctx context.Context, code string, tests grader.Tests) (*grader.Result, error)
+
+func (f gradeFunc) Grade(ctx context.Context, code string, tests grader.Tests) (*grader.Result, error) {
+	return f(ctx, code, tests)
+}
+
+type awardFunc func(ctx context.Context, userID int) ([]achievements.Badge, error)
+
+func (f awardFunc) Award(ctx context.Context, userID int) ([]achievements.Badge, error) {
+	return f(ctx, userID)
+}
+
+type enqueueFunc func(ctx context.Context, kind string, payload any) (int, error)
+
+func (f enqueueFunc) Enqueue(ctx context.Context, kind string, payload any) (int, error) {
+	return f(ctx, kind, payload)
+}
+
+// gradeAs returns a grader that gives every submission the same verdict.
+func gradeAs(passed bool) gradeFunc {
+	return func(ctx context.Context, code string, tests grader.Tests) (*grader.Result, error) {
+		return &grader.Result{
+			Passed: passed,
+			Output: "5\n",
+			Cases:  []grader.CaseResult{{Name: "adds", Passed: passed}},
+		}, nil
+	}
+}
+
+func TestQueueSubmission(t *testing.T) {
+	errQueueDown := errors.New("queue is down")
+
+	tests := []struct {
+		name       string
+		jobs       Enqueuer
+		wantErr    error
+		wantStatus string
+	}{
+		{
+			name:       "queued",
+			jobs:       enqueueFunc(func(ctx context.Context, kind string, payload any) (int, error) { return 1, nil }),
+			wantStatus: StatusQueued,
+		},
+		{
+			name:       "enqueue fails",
+			jobs:       enqueueFunc(func(ctx context.Context, kind string, payload any) (int, error) { return 0, errQueueDown }),
+			wantErr:    errQueueDown,
+			wantStatus: StatusFailed,
+		},
+		{
+			name:    "no queue",
+			wantErr: ErrCannotQueue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryRepository()
+			service := NewService(repo, gradeAs(true), nil, tt.jobs)
+
+			submission := &Submission{ChallengeID: 1, Code: "print(5)"}
+			err := service.QueueSubmission(context.Background(), 7, submission)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("QueueSubmission() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantStatus == "" {
+				if len(repo.submissions) != 0 {
+					t.Errorf("stored %d submissions, want none", len(repo.submissions))
+				}
+				return
+			}
+
+			stored, err := repo.GetByID(context.Background(), submission.ID)
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("stored status = %q, want %q", stored.Status, tt.wantStatus)
+			}
+			if stored.Status == StatusFailed && stored.Error == "" {
+				t.Error("failed submission has no error")
+			}
+		})
+	}
+}
+
+func TestRunGradeJob(t *testing.T) {
+	errGrader := errors.New("grader crashed")
+	errAward := errors.New("badges table locked")
+
+	tests := []struct {
+		name       string
+		status     string
+		challenge  int
+		payload    string
+		grader     gradeFunc
+		awardErr   error
+		wantErr    error
+		wantStatus string
+		wantAwards int
+	}{
+		{
+			name:       "passing submission",
+			grader:     gradeAs(true),
+			wantStatus: StatusPassed,
+			wantAwards: 1,
+		},
+		{
+			name:       "failing submission",
+			grader:     gradeAs(false),
+			wantStatus: StatusFailed,
+		},
+		{
+			name:       "challenge removed since it was queued",
+			challenge:  2,
+			wantStatus: StatusFailed,
+		},
+		{
+			name:       "already graded",
+			status:     StatusPassed,
+			wantStatus: StatusPassed,
+		},
+		{
+			name:    "unknown submission",
+			payload: `{"submission_id": 99}`,
+			wantErr: ErrSubmissionNotFound,
+		},
+		{
+			name: "grader error is retried",
+			grader: func(ctx context.Context, code string, tests grader.Tests) (*grader.Result, error) {
+				return nil, errGrader
+			},
+			wantErr:    errGrader,
+			wantStatus: StatusRunning,
+		},
+		{
+			name:       "award failure keeps the verdict",
+			grader:     gradeAs(true),
+			awardErr:   errAward,
+			wantErr:    ErrAwardFailed,
+			wantStatus: StatusPassed,
+			wantAwards: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryRepository()
+			repo.submissions[1] = &Submission{ID: 1, UserID: 7, ChallengeID: max(tt.challenge, 1), Code: "print(5)", Status: StatusQueued}
+			if tt.status != "" {
+				repo.submissions[1].Status = tt.status
+			}
+
+			g := tt.grader
+			if g == nil {
+				g = func(ctx context.Context, code string, tests grader.Tests) (*grader.Result, error) {
+					t.Error("Grade() called, want no grading")
+					return nil, errGrader
+				}
+			}
+
+			awards := 0
+			awarder := awardFunc(func(ctx context.Context, userID int) ([]achievements.Badge, error) {
+				awards++
+				return nil, tt.awardErr
+			})
+
+			payload := tt.payload
+			if payload == "" {
+				payload = `{"submission_id": 1}`
+			}
+
+			err := NewService(repo, g, awarder, nil).RunGradeJob(context.Background(), json.RawMessage(payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RunGradeJob() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := repo.submissions[1].Status; got != tt.wantStatus && tt.wantStatus != "" {
+				t.Errorf("status = %q, want %q", got, tt.wantStatus)
+			}
+			if awards != tt.wantAwards {
+				t.Errorf("Award() called %d times, want %d", awards, tt.wantAwards)
+			}
+		})
+	}
+}
+
+func TestFailGradeJob(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		payload    string
+		wantErr    error
+		wantStatus string
+	}{
+		{
+			name:       "running attempt fails",
+			status:     StatusRunning,
+			wantStatus: StatusFailed,
+		},
+		{
+			name:       "queued attempt fails",
+			status:     StatusQueued,
+			wantStatus: StatusFailed,
+		},
+		{
+			// Buried because awarding failed after the verdict was saved
+			name:       "verdict is kept",
+			status:     StatusPassed,
+			wantStatus: StatusPassed,
+		},
+		{
+			name:       "unknown submission",
+			status:     StatusRunning,
+			payload:    `{"submission_id": 99}`,
+			wantErr:    ErrSubmissionNotFound,
+			wantStatus: StatusRunning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryRepository()
+			repo.submissions[1] = &Submission{ID: 1, UserID: 7, ChallengeID: 1, Code: "print(5)", Status: tt.status, Passed: tt.status == StatusPassed}
+
+			payload := tt.payload
+			if payload == "" {
+				payload = `{"submission_id": 1}`
+			}
+
+			err := NewService(repo, gradeAs(true), nil, nil).FailGradeJob(context.Background(), json.RawMessage(payload), errors.New("grader crashed"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("FailGradeJob() error = %v, want %v", err, tt.wantErr)
+			}
+
+			got := repo.submissions[1]
+			if got.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got.Status, tt.wantStatus)
+			}
+			if tt.wantStatus == StatusFailed && (got.Passed || got.Error == "") {
+				t.Errorf("failed attempt = passed %v, error %q, want not passed with an error", got.Passed, got.Error)
+			}
+		})
+	}
+}
+
+// oneJobQueue hands out a single job and reports what the runner did with it.
+type oneJobQueue struct {
+	job     *jobs.Job
+	claimed sync.Once
+	outcome chan string
+}
+
+func (q *oneJobQueue) Insert(ctx context.Context, kind, uniqueKey string, payload any) (int, error) {
+	return 0, nil
+}
+
+func (q *oneJobQueue) Claim(ctx context.Context, kinds []string, lease time.Duration) (*jobs.Job, error) {
+	var job *jobs.Job
+	q.claimed.Do(func() {
+		job = q.job
+		job.Attempts++
+	})
+	if job == nil {
+		return nil, sql.ErrNoRows
+	}
+	return job, nil
+}
+
+func (q *oneJobQueue) Complete(ctx context.Context, id int) error {
+	q.outcome <- jobs.StatusDone
+	return nil
+}
+
+func (q *oneJobQueue) Retry(ctx context.Context, id int, runAt time.Time, lastError string) error {
+	q.outcome <- jobs.StatusQueued
+	return nil
+}
+
+func (q *oneJobQueue) Bury(ctx context.Context, id int, lastError string) error {
+	q.outcome <- jobs.StatusDead
+	return nil
+}
+
+func (q *oneJobQueue) Revive(ctx context.Context, id int) error {
+	return nil
+}
+
+func (q *oneJobQueue) List(ctx context.Context, status string, filters pagination.Filters) ([]jobs.Job, pagination.Metadata, error) {
+	return nil, pagination.Metadata{}, nil
+}
+
+func TestGradeJob_GraderErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		attempts   int // before this claim
+		wantJob    string
+		wantStatus string
+	}{
+		{name: "retried while attempts are left", attempts: 0, wantJob: jobs.StatusQueued, wantStatus: StatusRunning},
+		{name: "failed on the last attempt", attempts: jobs.DefaultMaxAttempts - 1, wantJob: jobs.StatusDead, wantStatus: StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryRepository()
+			repo.submissions[1] = &Submission{ID: 1, UserID: 7, ChallengeID: 1, Code: "print(5)", Status: StatusQueued}
+
+			crash := gradeFunc(func(ctx context.Context, code string, tests grader.Tests) (*grader.Result, error) {
+				return nil, errors.New("grader crashed")
+			})
+			service := NewService(repo, crash, nil, nil)
+
+			queue := &oneJobQueue{
+				job:     &jobs.Job{ID: 1, Kind: KindGrade, Payload: json.RawMessage(`{"submission_id": 1}`), Attempts: tt.attempts, MaxAttempts: jobs.DefaultMaxAttempts},
+				outcome: make(chan string, 1),
+			}
+			runner := jobs.NewRunner(queue, 1, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			runner.Handle(KindGrade, service.RunGradeJob)
+			runner.OnDead(KindGrade, service.FailGradeJob)
+			runner.Start()
+
+			select {
+			case got := <-queue.outcome:
+				if got != tt.wantJob {
+					t.Errorf("job = %s, want %s", got, tt.wantJob)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("the job never ran")
+			}
+			if err := runner.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown() error = %v", err)
+			}
+
+			got, _ := repo.GetByID(context.Background(), 1)
+			if got.Status != tt.wantStatus {
+				t.Errorf("submission status = %q, want %q", got.Status, tt.wantStatus)
+			}
+		})
+	}
+}
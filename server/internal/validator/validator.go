@@ -8,6 +8,7 @@ import (
 )
 
 var (
+	SlugRegex  = regexp.MustCompile("^[a-z0-9]+(?:-[a-z0-9]+)*$")
 	EmailRegex = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 )
 
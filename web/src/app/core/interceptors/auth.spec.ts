@@ -1,17 +1,84 @@
 import { TestBed } from '@angular/core/testing';
-import { HttpInterceptorFn } from '@angular/common/http';
+import { HttpClient, HttpInterceptorFn, provideHttpClient, withInterceptors } from '@angular/common/http';
+import { HttpTestingController, provideHttpClientTesting } from '@angular/common/http/testing';
 
+import { environment } from '../../../environments/environment';
+import { REFRESH_TOKEN_KEY, TOKEN_KEY } from '../services/auth';
 import { authInterceptor } from './auth';
 
 describe('authInterceptor', () => {
   const interceptor: HttpInterceptorFn = (req, next) =>
     TestBed.runInInjectionContext(() => authInterceptor(req, next));
 
+  const refreshURL = `${environment.apiURL}/api/auth/refresh`;
+  const tokens = { token: 'new-token', refresh_token: 'new-refresh', token_expiry: '' };
+
+  let http: HttpClient;
+  let backend: HttpTestingController;
+
   beforeEach(() => {
-    TestBed.configureTestingModule({});
+    localStorage.setItem(TOKEN_KEY, 'old-token');
+    localStorage.setItem(REFRESH_TOKEN_KEY, 'old-refresh');
+
+    TestBed.configureTestingModule({
+      providers: [provideHttpClient(withInterceptors([authInterceptor])), provideHttpClientTesting()],
+    });
+    http = TestBed.inject(HttpClient);
+    backend = TestBed.inject(HttpTestingController);
+  });
+
+  afterEach(() => {
+    backend.verify();
+    localStorage.clear();
   });
 
   it('should be created', () => {
     expect(interceptor).toBeTruthy();
   });
+
+  it('refreshes once for concurrent 401s and retries both requests', () => {
+    const seen: string[] = [];
+    http.get<string>('/api/a').subscribe((body) => seen.push(body));
+    http.get<string>('/api/b').subscribe((body) => seen.push(body));
+
+    backend.expectOne('/api/a').flush(null, { status: 401, statusText: 'Unauthorized' });
+    backend.expectOne('/api/b').flush(null, { status: 401, statusText: 'Unauthorized' });
+
+    const refresh = backend.expectOne(refreshURL);
+    expect(refresh.request.body).toEqual({ refresh_token: 'old-refresh' });
+    refresh.flush({ tokens });
+
+    for (const url of ['/api/a', '/api/b']) {
+      const retry = backend.expectOne(url);
+      expect(retry.request.headers.get('Authorization')).toBe('Bearer new-token');
+      retry.flush(url);
+    }
+    expect(seen.sort()).toEqual(['/api/a', '/api/b']);
+    expect(localStorage.getItem(REFRESH_TOKEN_KEY)).toBe('new-refresh');
+  });
+
+  it('holds requests made during a refresh until it is done', () => {
+    http.get('/api/a').subscribe();
+    backend.expectOne('/api/a').flush(null, { status: 401, statusText: 'Unauthorized' });
+    const refresh = backend.expectOne(refreshURL);
+
+    http.get('/api/b').subscribe();
+    backend.expectNone('/api/b');
+
+    refresh.flush({ tokens });
+    backend.expectOne('/api/a').flush({});
+    expect(backend.expectOne('/api/b').request.headers.get('Authorization')).toBe('Bearer new-token');
+  });
+
+  it('retries with the current token when another request already refreshed', () => {
+    http.get('/api/a').subscribe();
+    const first = backend.expectOne('/api/a');
+    localStorage.setItem(TOKEN_KEY, 'new-token');
+    localStorage.setItem(REFRESH_TOKEN_KEY, 'new-refresh');
+
+    first.flush(null, { status: 401, statusText: 'Unauthorized' });
+
+    backend.expectNone(refreshURL);
+    expect(backend.expectOne('/api/a').request.headers.get('Authorization')).toBe('Bearer new-token');
+  });
 });
@@ -1,13 +1,75 @@
-import { HttpInterceptorFn } from '@angular/common/http';
+import { HttpBackend, HttpClient, HttpErrorResponse, HttpInterceptorFn, HttpRequest } from '@angular/common/http';
+import { inject } from '@angular/core';
+import { Observable, catchError, finalize, map, shareReplay, switchMap, throwError } from 'rxjs';
+
+import { environment } from '../../../environments/environment';
+import { Tokens } from '../models/auth';
+import { REFRESH_TOKEN_KEY, TOKEN_KEY, clearTokens, storeTokens } from '../services/auth';
+
+// Shared so concurrent 401s trigger a single refresh; rotating the same
+// refresh token twice would make the server revoke the whole session.
+let refreshInFlight: Observable<string> | null = null;
+
+function withToken(req: HttpRequest<unknown>, token: string | null): HttpRequest<unknown> {
+  if (!token) return req;
+  return req.clone({
+    setHeaders: {Authorization: `Bearer ${token}`}
+  });
+}
+
+function refresh(http: HttpClient, refreshToken: string): Observable<string> {
+  return http
+    .post<{ tokens: Tokens }>(`${environment.apiURL}/api/auth/refresh`, { refresh_token: refreshToken })
+    .pipe(
+      map((res) => {
+        storeTokens(res.tokens);
+        return res.tokens.token;
+      }),
+      catchError((refreshErr: unknown) => {
+        clearTokens();
+        return throwError(() => refreshErr);
+      }),
+      finalize(() => (refreshInFlight = null)),
+      shareReplay(1),
+    );
+}
 
 export const authInterceptor: HttpInterceptorFn = (req, next) => {
-  const token = localStorage.getItem('token');
+  const isAuthCall = req.url.includes('/api/auth/refresh') || req.url.includes('/api/auth/exchange');
 
-  if (token) {
-    req = req.clone({
-      setHeaders: {Authorization: `Bearer ${token}`}
-    })
+  // Requests made while a refresh is under way wait for the new token rather
+  // than going out with one that is about to be replaced
+  if (refreshInFlight && !isAuthCall) {
+    return refreshInFlight.pipe(switchMap((newToken) => next(withToken(req, newToken))));
   }
 
-  return next(req);
+  // HttpBackend skips the interceptors, so the refresh call can't loop
+  const http = new HttpClient(inject(HttpBackend));
+  const token = localStorage.getItem(TOKEN_KEY);
+
+  return next(withToken(req, token)).pipe(
+    catchError((err: unknown) => {
+      if (!(err instanceof HttpErrorResponse) || err.status !== 401 || isAuthCall) {
+        return throwError(() => err);
+      }
+
+      if (!refreshInFlight) {
+        // Another request refreshed the tokens while this one was out
+        const current = localStorage.getItem(TOKEN_KEY);
+        if (current && current !== token) {
+          return next(withToken(req, current));
+        }
+
+        // Read now: the refresh token this request started with may have
+        // been rotated since
+        const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+        if (!refreshToken) {
+          return throwError(() => err);
+        }
+        refreshInFlight = refresh(http, refreshToken);
+      }
+
+      return refreshInFlight.pipe(switchMap((newToken) => next(withToken(req, newToken))));
+    }),
+  );
 };
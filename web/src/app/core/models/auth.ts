@@ -0,0 +1,12 @@
+import { User } from './user';
+
+export interface Tokens {
+  token: string;
+  refresh_token: string;
+  token_expiry: string;
+}
+
+export interface AuthResponse {
+  tokens: Tokens;
+  user: User;
+}
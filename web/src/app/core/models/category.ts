@@ -0,0 +1,8 @@
+export interface Category {
+  id: number;
+  slug: string;
+  title: string;
+  description: string;
+  display_order: number;
+  challenge_count: number;
+}
@@ -1,3 +1,17 @@
+export interface Hint {
+  number: number;
+  content: string;
+}
+
+export interface TestCase {
+  name: string;
+  stdin?: string;
+  expected_stdout?: string;
+  call?: string;
+  args?: unknown[];
+  expected?: unknown;
+}
+
 export interface Challenge {
   id: number;
   slug: string;
@@ -6,5 +20,10 @@ export interface Challenge {
   description: string;
   template: string;
   test_code: string;
-  hints: string;
+  test_cases?: TestCase[];
+  hint_count?: number;
+  revealed_hints?: Hint[];
+  difficulty?: string;
+  tags?: string[];
+  solved?: boolean;
 }
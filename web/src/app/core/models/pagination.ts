@@ -0,0 +1,9 @@
+// Mirrors pagination.Metadata; a result with no records only has
+// total_records.
+export interface Metadata {
+  current_page?: number;
+  page_size?: number;
+  first_page?: number;
+  last_page?: number;
+  total_records: number;
+}
@@ -1,6 +1,24 @@
+export interface CaseResult {
+  name: string;
+  passed: boolean;
+  hidden?: boolean;
+  expected?: unknown;
+  actual?: unknown;
+  error?: string;
+  time_ms: number;
+}
+
+export interface SuiteResult {
+  name: string;
+  passed: boolean;
+  hidden?: boolean;
+}
+
 export interface Submission {
   id?: number;
   challenge_id: number;
   code: string;
   passed: boolean;
+  results?: CaseResult[];
+  suites?: SuiteResult[];
 }
@@ -1,6 +1,9 @@
+export type Role = 'student' | 'instructor' | 'admin';
+
 export interface User {
   id: number;
   username: string;
   email: string;
-  avatar_url: string
+  avatar_url: string;
+  role: Role;
 }
@@ -1,10 +1,23 @@
 import {computed, inject, Injectable, signal} from '@angular/core';
 import { Router } from '@angular/router';
+import { Observable, map, tap } from 'rxjs';
 import { Api } from './api';
 import { environment } from '../../../environments/environment';
 import { User } from '../models/user';
+import { AuthResponse, Tokens } from '../models/auth';
 
-const TOKEN_KEY = 'token';
+export const TOKEN_KEY = 'token';
+export const REFRESH_TOKEN_KEY = 'refresh_token';
+
+export function storeTokens(tokens: Tokens): void {
+  localStorage.setItem(TOKEN_KEY, tokens.token);
+  localStorage.setItem(REFRESH_TOKEN_KEY, tokens.refresh_token);
+}
+
+export function clearTokens(): void {
+  localStorage.removeItem(TOKEN_KEY);
+  localStorage.removeItem(REFRESH_TOKEN_KEY);
+}
 
 @Injectable({
   providedIn: 'root',
@@ -33,14 +46,28 @@ export class AuthService {
   }
 
   logout(): void {
-    localStorage.removeItem(TOKEN_KEY);
-    this.userSignal.set(null);
-    this.router.navigate(['/']);
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY) ?? '';
+    const done = () => {
+      clearTokens();
+      this.userSignal.set(null);
+      this.router.navigate(['/']);
+    };
+
+    this.api.post('/auth/logout', { refresh_token: refreshToken }).subscribe({
+      next: done,
+      error: done,
+    });
   }
 
-  setToken(token: string): void {
-    localStorage.setItem(TOKEN_KEY, token);
-    this.fetchCurrentUser();
+  // Trades the one-time code from the GitHub callback for a token pair
+  exchangeCode(code: string): Observable<void> {
+    return this.api.post<AuthResponse>('/auth/exchange', { code }).pipe(
+      tap((res) => {
+        storeTokens(res.tokens);
+        this.userSignal.set(res.user);
+      }),
+      map(() => undefined),
+    );
   }
 
   private fetchCurrentUser(): void {
@@ -50,7 +77,7 @@ export class AuthService {
         this.isLoadingSignal.set(false);
       },
       error: () => {
-        localStorage.removeItem(TOKEN_KEY);
+        clearTokens();
         this.userSignal.set(null);
         this.isLoadingSignal.set(false);
       },
@@ -0,0 +1,17 @@
+import { inject, Injectable } from '@angular/core';
+import { map, Observable } from 'rxjs';
+import { Api } from './api';
+import { Category } from '../models/category';
+
+@Injectable({
+  providedIn: 'root'
+})
+export class CategoriesService {
+  private readonly api = inject(Api);
+
+  getAll(): Observable<Category[]> {
+    return this.api
+      .get<{ categories: Category[] }>('/categories')
+      .pipe(map(res => res.categories));
+  }
+}
@@ -1,7 +1,11 @@
 import { inject, Injectable } from '@angular/core';
-import { map, Observable } from 'rxjs';
+import { EMPTY, expand, map, Observable, reduce } from 'rxjs';
 import { Api } from './api';
-import { Challenge } from '../models/challenge';
+import { Challenge, Hint } from '../models/challenge';
+import { Metadata } from '../models/pagination';
+
+// The API's maximum page size
+const PAGE_SIZE = 100;
 
 @Injectable({
   providedIn: 'root'
@@ -9,10 +13,19 @@ import { Challenge } from '../models/challenge';
 export class ChallengesService {
   private readonly api = inject(Api);
 
+  // A category page shows every challenge, so the pages are fetched until
+  // the last one
   getByCategory(category: string): Observable<Challenge[]> {
-    return this.api
-      .get<{challenges: Challenge[]}>(`/challenges?category=${category}`)
-      .pipe(map(res => res.challenges));
+    const page = (n: number) => this.api
+      .get<{challenges: Challenge[], metadata: Metadata}>(`/challenges?category=${encodeURIComponent(category)}&page=${n}&page_size=${PAGE_SIZE}`);
+
+    return page(1).pipe(
+      expand(res => {
+        const { current_page = 0, last_page = 0 } = res.metadata;
+        return current_page < last_page ? page(current_page + 1) : EMPTY;
+      }),
+      reduce((all: Challenge[], res) => all.concat(res.challenges), []),
+    );
   }
 
   getById(id : number) : Observable<Challenge> {
@@ -20,4 +33,10 @@ export class ChallengesService {
       .get<{ challenge: Challenge}>(`/challenges/${id}`)
       .pipe(map(res => res.challenge));
   }
+
+  revealHint(id: number, n: number): Observable<Hint> {
+    return this.api
+      .post<{ hint: Hint }>(`/challenges/${id}/hints/${n}`, {})
+      .pipe(map(res => res.hint));
+  }
 }
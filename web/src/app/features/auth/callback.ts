@@ -14,13 +14,20 @@ export class AuthCallback implements OnInit {
   private readonly route = inject(ActivatedRoute);
 
   ngOnInit(): void {
-    const token = this.route.snapshot.queryParamMap.get('token');
+    const code = this.route.snapshot.queryParamMap.get('code');
 
-    if (token){
-      this.authService.setToken(token);
+    if (!code) {
+      this.router.navigate(['/']);
+      return;
     }
 
-    this.router.navigate(['/']);
+    // The API redirects here with a one-time code, never the tokens. Trade it
+    // with POST /api/auth/exchange, then replace this entry in the history so
+    // the used code doesn't linger in the address bar
+    this.authService.exchangeCode(code).subscribe({
+      next: () => this.router.navigate(['/'], { replaceUrl: true }),
+      error: () => this.router.navigate(['/'], { replaceUrl: true }),
+    });
   }
 
 }
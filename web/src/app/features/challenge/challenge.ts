@@ -5,6 +5,19 @@ import { MarkdownComponent } from 'ngx-markdown';
 import { MatButtonModule } from '@angular/material/button';
 import { MatSnackBar } from '@angular/material/snack-bar';
 import { ChallengesService } from '../../core/services/challenge';
+import { Hint } from '../../core/models/challenge';
+import { CaseResult, SuiteResult } from '../../core/models/submission';
+
+function describeCase(result: CaseResult): string {
+  if (result.hidden) return `❌ ${result.name} (caso oculto)`;
+  const lines = [`❌ ${result.name}`];
+  if (result.error) {
+    lines.push(result.error);
+  } else {
+    lines.push(`Esperado: ${JSON.stringify(result.expected)}`, `Obtenido: ${JSON.stringify(result.actual)}`);
+  }
+  return lines.join('\n');
+}
 import { PyodideService, PythonResult } from '../../core/services/pyodide';
 import { SubmissionService } from '../../core/services/submission';
 import { MonacoEditorComponent } from '../../shared/components/monaco-editor/monaco-editor';
@@ -56,6 +69,8 @@ export class Challenge {
   isSubmitting = signal(false);
   isSubmitted = signal(false);
   lastResult = signal<PythonResult | null>(null);
+  isRevealing = signal(false);
+  private readonly newHints = signal<Hint[]>([]);
 
   // Computed
   pyodideLoading = this.pyodideService.isLoading;
@@ -69,6 +84,9 @@ export class Challenge {
     return this.challenge()?.template ?? '';
   });
 
+  // Hints revealed on earlier visits plus the ones revealed now
+  hints = computed(() => [...(this.challenge()?.revealed_hints ?? []), ...this.newHints()]);
+
   submitButtonText = computed(() => {
     if (this.isSubmitting()) return 'Enviando...';
     if (this.isSubmitted()) return 'Enviado';
@@ -118,15 +136,45 @@ export class Challenge {
             duration: 5000,
           });
         },
-        error: () => {
+        error: err => {
           this.isSubmitting.set(false);
-          this.snackBar.open('Error al guardar la solución', 'Cerrar', {
+          // Passing the public tests here doesn't guarantee the hidden ones pass
+          const details = err?.error?.error;
+          const failedCases = (details?.results as CaseResult[] | undefined)?.filter(r => !r.passed) ?? [];
+          if (failedCases.length > 0) {
+            this.output.set(failedCases.map(describeCase).join('\n\n'));
+          }
+          const failedSuites = (details?.suites as SuiteResult[] | undefined)?.filter(s => !s.passed) ?? [];
+          const message = failedSuites.some(s => s.hidden) || failedCases.some(r => r.hidden)
+            ? 'Tu solución no pasó los tests ocultos'
+            : 'Error al guardar la solución';
+          this.snackBar.open(message, 'Cerrar', {
             duration: 5000,
           });
         },
       });
   }
 
+  onRevealHint(): void {
+    const challenge = this.challenge();
+    if (!challenge) return;
+
+    this.isRevealing.set(true);
+
+    this.challengesService.revealHint(challenge.id, this.hints().length + 1).subscribe({
+      next: hint => {
+        this.isRevealing.set(false);
+        this.newHints.update(hints => [...hints, hint]);
+      },
+      error: () => {
+        this.isRevealing.set(false);
+        this.snackBar.open('Inicia sesión para ver las pistas', 'Cerrar', {
+          duration: 5000,
+        });
+      },
+    });
+  }
+
   onCodeChange(newCode: string): void {
     this.code.set(newCode);
     // Reset result when code changes
@@ -1,6 +1,8 @@
-import { Component } from '@angular/core';
-import { UNITS } from './models/unit';
+import { Component, inject } from '@angular/core';
+import { toSignal } from '@angular/core/rxjs-interop';
+import { UNIT_IMAGES } from './models/unit';
 import { Card } from '../../shared/components/card/card';
+import { CategoriesService } from '../../core/services/category';
 
 @Component({
   selector: 'app-home',
@@ -9,5 +11,8 @@ import { Card } from '../../shared/components/card/card';
   styleUrl: './home.scss',
 })
 export class Home {
-  units = UNITS;
+  private readonly categoriesService = inject(CategoriesService);
+
+  units = toSignal(this.categoriesService.getAll(), { initialValue: [] });
+  images = UNIT_IMAGES;
 }
@@ -1,43 +1,4 @@
-export interface Unit {
-  slug: string;
-  title: string;
-  subtitle?: string;
-  description: string;
-  imageSrc?: string;
-  imageAlt?: string;
-}
-
-export const UNITS: Unit[] = [
-  {
-    slug: 'unit-1-intro',
-    title: 'Introducción a la Programación',
-    subtitle: 'Unidad 1',
-    description: 'Aprende los fundamentos de Python',
-    imageSrc: '/assets/units/unit-1.webp',
-    imageAlt: 'Unidad 1',
-  },
-  {
-    slug: 'unit-2-variables',
-    title: 'Variables y Tipos de Datos',
-    subtitle: 'Unidad 2',
-    description: 'Primitivos, variables, E/S, strings y listas.',
-    imageSrc: '/assets/units/unit-2.webp',
-    imageAlt: 'Unidad 2',
-  },
-  {
-    slug: 'unit-3-funciones',
-    title: 'Funciones',
-    subtitle: 'Unidad 3',
-    description: 'Abstracción, parámetros, alcance y modularización.',
-    imageSrc: '/assets/units/unit-3.webp',
-    imageAlt: 'Unidad 3',
-  },
-  {
-    slug: 'unit-4-control',
-    title: 'Estructuras de Control',
-    subtitle: 'Unidad 4',
-    description: 'Condicionales, iterativas y sentencias anidadas.',
-    imageSrc: '/assets/units/unit-4.webp',
-    imageAlt: 'Unidad 4',
-  },
-];
+// Unit metadata comes from the API; only the artwork is bundled with the app.
+export const UNIT_IMAGES: Record<string, string> = {
+  'unit-1-intro': '/assets/units/unit-1.webp',
+};